@@ -0,0 +1,179 @@
+// Command promo-import bulk-loads the promo-loader output into Postgres as a
+// new version of the promo code set and, by default, makes it active. With
+// -activate-version it only switches to a version imported before, e.g. to
+// roll back.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog"
+
+	"github.com/M-Arthur/order-food-api/internal/storage"
+)
+
+const (
+	logFormatConsole = "console"
+	logFormatJSON    = "json"
+)
+
+func main() {
+	var (
+		dsn        string
+		input      string
+		activate   bool
+		activateV  int64
+		keep       int
+		timeoutStr string
+		logFormat  string
+	)
+
+	flag.StringVar(&dsn, "dsn", os.Getenv("DB_DSN"), "Postgres connection string (defaults to $DB_DSN)")
+	flag.StringVar(&input, "input", "./valid_promo_codes.txt", "Promo-loader output file, one code per line")
+	flag.BoolVar(&activate, "activate", true, "Make the imported version active")
+	flag.Int64Var(&activateV, "activate-version", 0, "Only make this already imported version active, without importing; 0 imports -input")
+	flag.IntVar(&keep, "keep", 2, "Number of versions to keep, including the active one; 0 keeps all")
+	flag.StringVar(&timeoutStr, "timeout", "0s", "Overall timeout (e.g. 30m, 1h); 0s = no timeout")
+	flag.StringVar(&logFormat, "log-format", logFormatConsole, "Progress log format: console or json")
+	flag.Parse()
+
+	if dsn == "" {
+		fmt.Fprintln(os.Stderr, "missing -dsn (or DB_DSN)")
+		os.Exit(1)
+	}
+
+	log, err := newLogger(logFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -log-format: %v\n", err)
+		os.Exit(1)
+	}
+
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid timeout: %v\n", err)
+		os.Exit(1)
+	}
+
+	if activateV < 0 {
+		fmt.Fprintf(os.Stderr, "-activate-version must be positive, got %d\n", activateV)
+		os.Exit(1)
+	}
+	if activateV > 0 {
+		var conflict string
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "input" || f.Name == "activate" || f.Name == "keep" {
+				conflict = f.Name
+			}
+		})
+		if conflict != "" {
+			fmt.Fprintf(os.Stderr, "-activate-version cannot be combined with -%s\n", conflict)
+			os.Exit(1)
+		}
+	}
+
+	ctx := log.WithContext(context.Background())
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if activateV > 0 {
+		err = activateVersion(ctx, dsn, activateV)
+	} else {
+		err = run(ctx, dsn, input, activate, keep)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, dsn, input string, activate bool, keep int) error {
+	f, err := os.Open(input)
+	if err != nil {
+		return fmt.Errorf("open input: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	importer := storage.NewPgPromoCodeImporter(db)
+	log := zerolog.Ctx(ctx)
+
+	start := time.Now()
+	log.Info().Str("stage", "import").Str("input", input).Msg("stage started")
+	v, err := importer.Import(ctx, input, f)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	log.Info().Str("stage", "import").Int64("version", v.Version).Int64("codes", v.CodeCount).
+		Dur("elapsed", time.Since(start).Round(time.Millisecond)).Msg("stage done")
+
+	if !activate {
+		log.Info().Int64("version", v.Version).Msg("version left inactive")
+		return nil
+	}
+
+	if err := importer.Activate(ctx, v.Version); err != nil {
+		return fmt.Errorf("activate: %w", err)
+	}
+	log.Info().Str("stage", "activate").Int64("version", v.Version).Msg("version is now active")
+
+	if keep > 0 {
+		dropped, err := importer.Prune(ctx, keep)
+		if err != nil {
+			return fmt.Errorf("prune: %w", err)
+		}
+		for _, d := range dropped {
+			log.Info().Str("stage", "prune").Int64("version", d).Msg("dropped version")
+		}
+	}
+
+	return nil
+}
+
+// activateVersion makes an already imported version active, such as one
+// imported with -activate=false or the previous version kept by -keep.
+func activateVersion(ctx context.Context, dsn string, version int64) error {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if err := storage.NewPgPromoCodeImporter(db).Activate(ctx, version); err != nil {
+		return fmt.Errorf("activate: %w", err)
+	}
+	zerolog.Ctx(ctx).Info().Str("stage", "activate").Int64("version", version).Msg("version is now active")
+
+	return nil
+}
+
+// newLogger returns the importer's logger, writing to stderr.
+func newLogger(format string) (zerolog.Logger, error) {
+	switch format {
+	case logFormatConsole:
+		return zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger(), nil
+	case logFormatJSON:
+		return zerolog.New(os.Stderr).With().Timestamp().Logger(), nil
+	default:
+		return zerolog.Nop(), fmt.Errorf("log format must be console or json, got %q", format)
+	}
+}
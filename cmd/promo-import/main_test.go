@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// openTestDB opens the Postgres of TEST_DB_DSN, as internal/storage's tests
+// do, and skips the test without it. Once the test is done, it puts the
+// active promo code version back and drops the versions imported from
+// input.
+func openTestDB(t *testing.T, input string) (*sql.DB, string) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+
+	var active sql.NullInt64
+	err = db.QueryRow(`SELECT version FROM promo_code_active`).Scan(&active)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("select active promo code version: %v", err)
+	}
+
+	t.Cleanup(func() {
+		defer func() {
+			_ = db.Close()
+		}()
+
+		var err error
+		if active.Valid {
+			_, err = db.Exec(`UPDATE promo_code_active SET version = $1`, active.Int64)
+		} else {
+			_, err = db.Exec(`DELETE FROM promo_code_active`)
+		}
+		if err != nil {
+			t.Errorf("cleanup: restore active promo code version: %v", err)
+		}
+		for _, v := range importedVersions(t, db, input) {
+			if _, err := db.Exec(`DROP TABLE IF EXISTS promo_codes_v` + strconv.FormatInt(v, 10)); err != nil {
+				t.Errorf("cleanup: %v", err)
+			}
+		}
+		if _, err := db.Exec(`DELETE FROM promo_code_versions WHERE source = $1`, input); err != nil {
+			t.Errorf("cleanup: %v", err)
+		}
+	})
+	return db, dsn
+}
+
+// importedVersions lists the versions imported from input, oldest first.
+func importedVersions(t *testing.T, db *sql.DB, input string) []int64 {
+	t.Helper()
+	rows, err := db.Query(`SELECT version FROM promo_code_versions WHERE source = $1 ORDER BY version`, input)
+	if err != nil {
+		t.Fatalf("list promo code versions: %v", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var versions []int64
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			t.Fatalf("scan promo code version: %v", err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("list promo code versions: %v", err)
+	}
+	return versions
+}
+
+func writeInput(t *testing.T, content string) string {
+	t.Helper()
+	input := filepath.Join(t.TempDir(), "valid_promo_codes.txt")
+	if err := os.WriteFile(input, []byte(content), 0o600); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	return input
+}
+
+func TestRun(t *testing.T) {
+	input := writeInput(t, "TESTIMPORT1\nTESTIMPORT2\n")
+	db, dsn := openTestDB(t, input)
+
+	// keep 0 leaves the versions already in the database alone
+	ctx := zerolog.Nop().WithContext(context.Background())
+	if err := run(ctx, dsn, input, true, 0); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	st, err := storage.NewPgPromoCodeValidator(db).CodeSetStatus(ctx)
+	if err != nil {
+		t.Fatalf("CodeSetStatus() error = %v", err)
+	}
+	if st.Location != input || st.CodeCount != 2 {
+		t.Fatalf("active version = %+v, want the 2 codes of %s", st, input)
+	}
+}
+
+func TestRun_Empty(t *testing.T) {
+	input := writeInput(t, "\n")
+	_, dsn := openTestDB(t, input)
+
+	ctx := zerolog.Nop().WithContext(context.Background())
+	if err := run(ctx, dsn, input, true, 0); !errors.Is(err, domain.ErrMalformedPromoCodes) {
+		t.Fatalf("run() error = %v, want %v", err, domain.ErrMalformedPromoCodes)
+	}
+}
+
+func TestActivateVersion(t *testing.T) {
+	input := writeInput(t, "TESTIMPORT1\n")
+	db, dsn := openTestDB(t, input)
+	validator := storage.NewPgPromoCodeValidator(db)
+
+	ctx := zerolog.Nop().WithContext(context.Background())
+	if err := run(ctx, dsn, input, false, 0); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if st, err := validator.CodeSetStatus(ctx); err == nil && st.Location == input {
+		t.Fatalf("version imported with activate false is active")
+	}
+
+	versions := importedVersions(t, db, input)
+	if len(versions) != 1 {
+		t.Fatalf("imported versions = %v, want one", versions)
+	}
+	if err := activateVersion(ctx, dsn, versions[0]); err != nil {
+		t.Fatalf("activateVersion(%d) error = %v", versions[0], err)
+	}
+	st, err := validator.CodeSetStatus(ctx)
+	if err != nil {
+		t.Fatalf("CodeSetStatus() error = %v", err)
+	}
+	if st.Version != strconv.FormatInt(versions[0], 10) {
+		t.Fatalf("active version = %s, want %d", st.Version, versions[0])
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/M-Arthur/order-food-api/internal/promo"
+)
+
+// runDiff implements "promo-loader diff": which codes were added or removed
+// between the outputs of two runs.
+func runDiff(args []string) {
+	var (
+		oldPath     string
+		newPath     string
+		addedPath   string
+		removedPath string
+		reportPath  string
+		logFormat   string
+	)
+
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promo-loader diff -old=<output> -new=<output> [flags]")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&oldPath, "old", "", "Output of the earlier run")
+	fs.StringVar(&newPath, "new", "./valid_promo_codes.txt", "Output of the later run")
+	fs.StringVar(&addedPath, "added-output", "", "Write the codes only in -new to this path")
+	fs.StringVar(&removedPath, "removed-output", "", "Write the codes only in -old to this path")
+	fs.StringVar(&reportPath, "report", "", "Also write the added, removed and unchanged counts as JSON to this path")
+	fs.StringVar(&logFormat, "log-format", logFormatConsole, "Progress log format: console or json")
+	_ = fs.Parse(args)
+
+	if oldPath == "" {
+		fmt.Fprintln(os.Stderr, "missing -old (output of the earlier run)")
+		os.Exit(1)
+	}
+	log, err := newLogger(logFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -log-format: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := promo.DiffOptions{
+		Old:           oldPath,
+		New:           newPath,
+		AddedOutput:   addedPath,
+		RemovedOutput: removedPath,
+	}
+	// Without list files the changes go to stdout, "+code" or "-code" per line
+	if addedPath == "" && removedPath == "" {
+		opts.Changes = os.Stdout
+	}
+
+	report, err := promo.Diff(log.WithContext(context.Background()), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	log.Info().
+		Int64("added", report.Added).
+		Int64("removed", report.Removed).
+		Int64("unchanged", report.Unchanged).
+		Msg("promo-loader diff done")
+
+	if reportPath != "" {
+		if err := promo.WriteDiffReport(nil, reportPath, report); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
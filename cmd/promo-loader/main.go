@@ -1,34 +1,82 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"flag"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/promo"
+	"github.com/rs/zerolog"
+)
+
+// Log formats accepted by -log-format.
+const (
+	logFormatConsole = "console"
+	logFormatJSON    = "json"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		runDiff(os.Args[2:])
+		return
+	}
+
 	var (
 		filesStr    string
 		tmpDir      string
 		output      string
-		sortBin     string
+		strategy    string
+		buckets     int
+		memoryMB    int
 		parallelism int
 		timeoutStr  string
+		countsOut   string
+		indexOutput string
+		blockSize   int
+		bloomBits   int
+		reportPath  string
+		rejectsPath string
+		logFormat   string
+
+		rules        = promo.DefaultRules()
+		allowedChars string
+		requireStr   string
 	)
 
-	flag.StringVar(&filesStr, "files", "", "Comma-separated list of gzip files")
+	flag.StringVar(&filesStr, "files", "", "Comma-separated list of input files: plain text, gzip or bzip2; - reads one of them from stdin")
 	flag.StringVar(&tmpDir, "tmp-dir", "./tmp/promo", "Temporary directory for intermediate files")
 	flag.StringVar(&output, "output", "./valid_promo_codes.txt", "Output file for valid promo codes")
-	flag.StringVar(&sortBin, "sort-bin", "sort", "Path to sort binary")
+	flag.StringVar(&countsOut, "counts-output", "", "Also write \"code<TAB>files<TAB>occurrences\" for every valid code to this path")
+	flag.StringVar(&reportPath, "report", "", "Also write a JSON summary of the run with per-file statistics to this path")
+	flag.StringVar(&rejectsPath, "rejects-output", "", "Also write per-file counts and sampled examples of rejected lines, as JSON, to this path")
+	flag.StringVar(&logFormat, "log-format", logFormatConsole, "Progress log format: console or json")
+	flag.StringVar(&strategy, "strategy", promo.StrategyHash, "Pipeline: hash (partition codes into buckets) or sort (sort each file and merge)")
+	flag.IntVar(&buckets, "buckets", promo.DefaultBuckets, "Number of hash buckets for -strategy=hash")
+	flag.IntVar(&memoryMB, "memory-mb", promo.DefaultMemoryBudget>>20, "Memory budget in MiB for sorting a file or counting a bucket")
+	// The name of -memory-mb before the hash strategy, kept for existing scripts
+	flag.IntVar(&memoryMB, "sort-memory-mb", promo.DefaultMemoryBudget>>20, "Deprecated: use -memory-mb")
+	// Sorting no longer runs an external sort binary; accepted for existing scripts
+	flag.String("sort-bin", "sort", "Deprecated: ignored, sorting is built in")
 	flag.IntVar(&parallelism, "parallelism", 3, "Number of files to process in parallel")
 	flag.StringVar(&timeoutStr, "timeout", "0s", "Overall timeout (e.g. 30m, 1h); 0s = no timeout")
+	flag.IntVar(&rules.MinLength, "min-length", rules.MinLength, "Minimum code length in bytes")
+	flag.IntVar(&rules.MaxLength, "max-length", rules.MaxLength, "Maximum code length in bytes")
+	flag.StringVar(&allowedChars, "allowed-chars", "", "Regexp matching one allowed character, e.g. [A-Z0-9]; empty allows any")
+	flag.StringVar(&rules.Case, "case", rules.Case, "Case normalisation before checking codes: keep, upper or lower")
+	flag.IntVar(&rules.MinFiles, "min-files", rules.MinFiles, "Number of distinct input files a code must appear in")
+	flag.StringVar(&requireStr, "require-files", "", "Comma-separated input files (as given in -files) every code must appear in")
+	flag.StringVar(&indexOutput, "index-output", "", "Also write a memory-mapped lookup index of the output to this path")
+	flag.IntVar(&blockSize, "index-block-size", promo.DefaultIndexBlockSize, "Codes per index block")
+	flag.IntVar(&bloomBits, "index-bloom-bits", promo.DefaultIndexBloomBitsPerKey, "Bloom filter bits per code in the index; 0 = no Bloom filter")
 	flag.Parse()
 
 	if filesStr == "" {
-		fmt.Fprintln(os.Stderr, "missing -files (comma-separated list of gzip files)")
+		fmt.Fprintln(os.Stderr, "missing -files (comma-separated list of input files)")
 		os.Exit(1)
 	}
 
@@ -38,23 +86,95 @@ func main() {
 		os.Exit(1)
 	}
 
+	allowed, err := promo.CompileAllowedChars(allowedChars)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -allowed-chars: %v\n", err)
+		os.Exit(1)
+	}
+	rules.AllowedChars = allowed
+	for _, req := range splitAndTrim(requireStr) {
+		i := slices.Index(files, req)
+		if i < 0 {
+			fmt.Fprintf(os.Stderr, "-require-files: %s is not in -files\n", req)
+			os.Exit(1)
+		}
+		rules.RequiredFiles = append(rules.RequiredFiles, i)
+	}
+	if err := rules.Validate(len(files)); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid rules: %v\n", err)
+		os.Exit(1)
+	}
+
+	log, err := newLogger(logFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -log-format: %v\n", err)
+		os.Exit(1)
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "sort-memory-mb":
+			log.Warn().Msg("-sort-memory-mb is deprecated, use -memory-mb")
+		case "sort-bin":
+			log.Warn().Msg("-sort-bin is deprecated and ignored, sorting is built in")
+		}
+	})
+
 	timeout, err := time.ParseDuration(timeoutStr)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "invalid timeout: %v\n", err)
 		os.Exit(1)
 	}
 
-	ctx := context.Background()
+	ctx := log.WithContext(context.Background())
 	if timeout > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, timeout)
 		defer cancel()
 	}
 
-	if err := ExtractValidPromoCodes(ctx, files, tmpDir, output, sortBin, parallelism); err != nil {
+	if strategy != promo.StrategyHash && strategy != promo.StrategySort {
+		fmt.Fprintf(os.Stderr, "-strategy must be hash or sort, got %q\n", strategy)
+		os.Exit(1)
+	}
+
+	report, err := promo.Extract(ctx, promo.Options{
+		Files:        files,
+		TmpDir:       tmpDir,
+		Output:       output,
+		CountsOutput: countsOut,
+		Rules:        rules,
+		Strategy:     strategy,
+		Buckets:      buckets,
+		MemoryBudget: int64(memoryMB) << 20,
+		Parallelism:  parallelism,
+	})
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+	log.Info().Str("output", output).Int64("valid_codes", report.ValidCodes).Msg("promo-loader done")
+
+	if indexOutput != "" {
+		opts := promo.IndexOptions{BlockSize: blockSize, BloomBitsPerKey: bloomBits}
+		if err := writeIndex(ctx, output, indexOutput, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if reportPath != "" {
+		if err := promo.WriteReport(nil, reportPath, report); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if rejectsPath != "" {
+		if err := promo.WriteRejects(nil, rejectsPath, report); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
 
 func splitAndTrim(s string) []string {
@@ -68,3 +188,38 @@ func splitAndTrim(s string) []string {
 	}
 	return out
 }
+
+// newLogger returns the loader's logger, writing to stderr so that stdout
+// stays free for piping.
+func newLogger(format string) (zerolog.Logger, error) {
+	switch format {
+	case logFormatConsole:
+		return zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger(), nil
+	case logFormatJSON:
+		return zerolog.New(os.Stderr).With().Timestamp().Logger(), nil
+	default:
+		return zerolog.Nop(), fmt.Errorf("log format must be console or json, got %q", format)
+	}
+}
+
+// writeIndex builds the lookup index served with PROMO_CODES_SOURCE=index
+// from the merged output.
+func writeIndex(ctx context.Context, output, indexPath string, opts promo.IndexOptions) error {
+	log := zerolog.Ctx(ctx)
+	log.Info().Str("stage", "index").Str("file", indexPath).Str("output", output).Msg("stage started")
+
+	in, err := os.Open(output)
+	if err != nil {
+		return fmt.Errorf("open output: %w", err)
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	if err := promo.WriteIndex(indexPath, bufio.NewReaderSize(in, 1<<20), opts); err != nil {
+		return fmt.Errorf("index stage: %w", err)
+	}
+
+	log.Info().Str("stage", "index").Str("file", indexPath).Msg("stage done")
+	return nil
+}
@@ -7,11 +7,13 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // store schedules need IANA zones even in minimal images
 
 	"github.com/M-Arthur/order-food-api/internal/bootstrap"
 	"github.com/M-Arthur/order-food-api/internal/config"
 	"github.com/M-Arthur/order-food-api/internal/httpapi"
 	"github.com/M-Arthur/order-food-api/internal/logger"
+	"github.com/M-Arthur/order-food-api/internal/ratelimit"
 	"github.com/M-Arthur/order-food-api/internal/server"
 )
 
@@ -23,6 +25,11 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name api_key
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT as "Bearer <token>"
 func main() {
 	// 1) Init logger
 	cfg := config.Load()
@@ -40,9 +47,21 @@ func main() {
 	r := httpapi.NewRouter(httpapi.RouterConfig{
 		Logger: appLogger,
 		Deps:   deps,
-		APIKey: cfg.APIKey,
+		RateLimits: httpapi.RateLimits{
+			Public:        ratelimit.PerMinute(cfg.RateLimit.Public.PerMinute, cfg.RateLimit.Public.Burst),
+			Order:         ratelimit.PerMinute(cfg.RateLimit.Order.PerMinute, cfg.RateLimit.Order.Burst),
+			Authenticated: ratelimit.PerMinute(cfg.RateLimit.Authenticated.PerMinute, cfg.RateLimit.Authenticated.Burst),
+			Auth:          ratelimit.PerMinute(cfg.RateLimit.Auth.PerMinute, cfg.RateLimit.Auth.Burst),
+		},
 	})
 
+	// Pick up new promo-loader output without a restart
+	watchCtx, stopWatching := context.WithCancel(appLogger.WithContext(context.Background()))
+	defer stopWatching()
+	if deps.Infra.PromoReloader != nil {
+		go deps.Infra.PromoReloader.Watch(watchCtx, deps.Infra.PromoReloadInterval)
+	}
+
 	// 3) Server config
 	addr := ":" + cfg.Port
 	srv := server.New(addr, r)
@@ -1,9 +1,10 @@
 -- db/migrations/001_init.sql
 
--- Drop tables if they exist (dev convenience)
-DROP TABLE IF EXISTS order_items;
-DROP TABLE IF EXISTS orders;
-DROP TABLE IF EXISTS products;
+-- Drop tables if they exist (dev convenience); CASCADE drops the foreign
+-- keys later migrations add to them
+DROP TABLE IF EXISTS order_items CASCADE;
+DROP TABLE IF EXISTS orders CASCADE;
+DROP TABLE IF EXISTS products CASCADE;
 
 -- Orders table
 CREATE TABLE orders (
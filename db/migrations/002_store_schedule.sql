@@ -0,0 +1,51 @@
+-- db/migrations/002_store_schedule.sql
+
+DROP TABLE IF EXISTS store_blackouts;
+DROP TABLE IF EXISTS store_closures;
+DROP TABLE IF EXISTS store_opening_hours;
+DROP TABLE IF EXISTS store_settings;
+
+-- Store-wide settings (single row)
+CREATE TABLE store_settings (
+    id              SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    timezone        TEXT NOT NULL,
+    -- Manual "pause ordering" switch, e.g. when the kitchen is overloaded
+    ordering_paused BOOLEAN NOT NULL DEFAULT FALSE,
+    pause_reason    TEXT NULL
+);
+
+-- Weekly opening hours in the store's local time.
+-- weekday follows Go's time.Weekday: 0 = Sunday ... 6 = Saturday.
+-- A period with closes_at <= opens_at runs past midnight.
+CREATE TABLE store_opening_hours (
+    weekday   SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    opens_at  TIME NOT NULL,
+    closes_at TIME NOT NULL,
+
+    PRIMARY KEY (weekday, opens_at)
+);
+
+-- Full-day closures (public holidays etc.) in the store's local date
+CREATE TABLE store_closures (
+    closed_on DATE PRIMARY KEY,
+    reason    TEXT NULL
+);
+
+-- Ad-hoc ordering blackout windows
+CREATE TABLE store_blackouts (
+    id        BIGSERIAL PRIMARY KEY,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at   TIMESTAMPTZ NOT NULL,
+    reason    TEXT NULL,
+
+    CONSTRAINT chk_store_blackouts_range CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_store_blackouts_ends_at ON store_blackouts (ends_at);
+
+INSERT INTO store_settings (id, timezone) VALUES (1, 'Australia/Sydney');
+
+-- Open around the clock by default; tighten these for a real store.
+INSERT INTO store_opening_hours (weekday, opens_at, closes_at)
+SELECT d, '00:00', '24:00'
+FROM generate_series(0, 6) AS d;
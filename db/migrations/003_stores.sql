@@ -0,0 +1,87 @@
+-- db/migrations/003_stores.sql
+
+DROP TABLE IF EXISTS store_products;
+DROP TABLE IF EXISTS stores CASCADE;
+
+-- Physical shops / locations
+CREATE TABLE stores (
+    id   VARCHAR(64) PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+INSERT INTO stores (id, name) VALUES
+('default', 'Oolio Desserts'),
+('cbd', 'Oolio Desserts CBD');
+
+-- Per-store availability and price overrides.
+-- Products without a row here are available at their base price.
+CREATE TABLE store_products (
+    store_id    VARCHAR(64) NOT NULL,
+    product_id  VARCHAR(64) NOT NULL,
+    available   BOOLEAN NOT NULL DEFAULT TRUE,
+    price_cents BIGINT NULL, -- NULL = use products.price_cents
+
+    PRIMARY KEY (store_id, product_id),
+
+    CONSTRAINT fk_store_products_store
+        FOREIGN KEY (store_id)
+        REFERENCES stores (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_store_products_product
+        FOREIGN KEY (product_id)
+        REFERENCES products (id)
+        ON DELETE CASCADE
+);
+
+INSERT INTO store_products (store_id, product_id, available, price_cents) VALUES
+('cbd', '1', TRUE, 750),   -- CBD rent: pricier waffles
+('cbd', '5', FALSE, NULL); -- no baklava at the CBD shop
+
+-- Orders belong to a store
+ALTER TABLE orders
+    ADD COLUMN store_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    ADD CONSTRAINT fk_orders_store
+        FOREIGN KEY (store_id)
+        REFERENCES stores (id)
+        ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_orders_store_id ON orders (store_id);
+
+-- Schedules become per store (see 002_store_schedule.sql)
+ALTER TABLE store_settings
+    ADD COLUMN store_id VARCHAR(64) NOT NULL DEFAULT 'default'
+        REFERENCES stores (id) ON DELETE CASCADE;
+ALTER TABLE store_settings DROP CONSTRAINT store_settings_pkey;
+ALTER TABLE store_settings DROP COLUMN id;
+ALTER TABLE store_settings ALTER COLUMN store_id DROP DEFAULT;
+ALTER TABLE store_settings ADD PRIMARY KEY (store_id);
+
+ALTER TABLE store_opening_hours
+    ADD COLUMN store_id VARCHAR(64) NOT NULL DEFAULT 'default'
+        REFERENCES stores (id) ON DELETE CASCADE;
+ALTER TABLE store_opening_hours DROP CONSTRAINT store_opening_hours_pkey;
+ALTER TABLE store_opening_hours ALTER COLUMN store_id DROP DEFAULT;
+ALTER TABLE store_opening_hours ADD PRIMARY KEY (store_id, weekday, opens_at);
+
+ALTER TABLE store_closures
+    ADD COLUMN store_id VARCHAR(64) NOT NULL DEFAULT 'default'
+        REFERENCES stores (id) ON DELETE CASCADE;
+ALTER TABLE store_closures DROP CONSTRAINT store_closures_pkey;
+ALTER TABLE store_closures ALTER COLUMN store_id DROP DEFAULT;
+ALTER TABLE store_closures ADD PRIMARY KEY (store_id, closed_on);
+
+ALTER TABLE store_blackouts
+    ADD COLUMN store_id VARCHAR(64) NOT NULL DEFAULT 'default'
+        REFERENCES stores (id) ON DELETE CASCADE;
+ALTER TABLE store_blackouts ALTER COLUMN store_id DROP DEFAULT;
+
+DROP INDEX IF EXISTS idx_store_blackouts_ends_at;
+CREATE INDEX IF NOT EXISTS idx_store_blackouts_store_ends_at ON store_blackouts (store_id, ends_at);
+
+-- CBD shop trades 7am-7pm
+INSERT INTO store_settings (store_id, timezone) VALUES ('cbd', 'Australia/Sydney');
+
+INSERT INTO store_opening_hours (store_id, weekday, opens_at, closes_at)
+SELECT 'cbd', d, '07:00', '19:00'
+FROM generate_series(0, 6) AS d;
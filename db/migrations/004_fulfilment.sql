@@ -0,0 +1,60 @@
+-- db/migrations/004_fulfilment.sql
+
+DROP TABLE IF EXISTS delivery_zone_postcodes;
+DROP TABLE IF EXISTS delivery_zones;
+
+-- Postcode-based delivery zones, each with a flat delivery fee
+CREATE TABLE delivery_zones (
+    id        VARCHAR(64) PRIMARY KEY,
+    store_id  VARCHAR(64) NOT NULL,
+    name      TEXT NOT NULL,
+    fee_cents BIGINT NOT NULL CHECK (fee_cents >= 0),
+
+    CONSTRAINT fk_delivery_zones_store
+        FOREIGN KEY (store_id)
+        REFERENCES stores (id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE delivery_zone_postcodes (
+    zone_id  VARCHAR(64) NOT NULL,
+    store_id VARCHAR(64) NOT NULL,
+    postcode CHAR(4) NOT NULL,
+
+    PRIMARY KEY (zone_id, postcode),
+
+    -- A postcode belongs to at most one zone per store
+    CONSTRAINT uq_delivery_zone_postcodes_store_postcode UNIQUE (store_id, postcode),
+
+    CONSTRAINT fk_delivery_zone_postcodes_zone
+        FOREIGN KEY (zone_id)
+        REFERENCES delivery_zones (id)
+        ON DELETE CASCADE
+);
+
+INSERT INTO delivery_zones (id, store_id, name, fee_cents) VALUES
+('default-inner', 'default', 'Inner suburbs', 500),
+('default-outer', 'default', 'Outer suburbs', 900),
+('cbd-city', 'cbd', 'City', 300);
+
+INSERT INTO delivery_zone_postcodes (zone_id, store_id, postcode) VALUES
+('default-inner', 'default', '2000'),
+('default-inner', 'default', '2010'),
+('default-inner', 'default', '2037'),
+('default-outer', 'default', '2040'),
+('default-outer', 'default', '2204'),
+('cbd-city', 'cbd', '2000');
+
+-- How each order is fulfilled, plus its price breakdown
+ALTER TABLE orders
+    ADD COLUMN fulfilment_type    TEXT NOT NULL DEFAULT 'takeaway'
+        CHECK (fulfilment_type IN ('dine_in', 'takeaway', 'delivery')),
+    ADD COLUMN table_number       INT NULL CHECK (table_number >= 1),
+    ADD COLUMN delivery_line1     TEXT NULL,
+    ADD COLUMN delivery_line2     TEXT NULL,
+    ADD COLUMN delivery_suburb    TEXT NULL,
+    ADD COLUMN delivery_state     TEXT NULL,
+    ADD COLUMN delivery_postcode  CHAR(4) NULL,
+    ADD COLUMN subtotal_cents     BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN delivery_fee_cents BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN total_cents        BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,25 @@
+-- db/migrations/005_customers.sql
+
+DROP TABLE IF EXISTS customers CASCADE;
+
+-- Registered customers; email is stored lower-cased
+CREATE TABLE customers (
+    id         VARCHAR(64) PRIMARY KEY,
+    name       TEXT NOT NULL,
+    email      TEXT NOT NULL,
+    phone      TEXT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT uq_customers_email UNIQUE (email)
+);
+
+-- Link orders to an optional customer and record when they were placed
+ALTER TABLE orders
+    ADD COLUMN customer_id VARCHAR(64) NULL,
+    ADD COLUMN created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD CONSTRAINT fk_orders_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers (id)
+        ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id_created_at ON orders (customer_id, created_at DESC);
@@ -0,0 +1,24 @@
+-- db/migrations/006_api_keys.sql
+
+DROP TABLE IF EXISTS api_keys;
+
+-- Per-client API keys. Only the hex SHA-256 of each key is stored.
+CREATE TABLE api_keys (
+    id          VARCHAR(64) PRIMARY KEY,
+    client_name TEXT NOT NULL,
+    key_hash    CHAR(64) NOT NULL,
+    scopes      TEXT[] NOT NULL DEFAULT '{}',
+    expires_at  TIMESTAMPTZ NULL,
+    revoked_at  TIMESTAMPTZ NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT uq_api_keys_key_hash UNIQUE (key_hash)
+);
+
+-- Keep the challenge's shared "apitest" key working as a fully-scoped dev client.
+-- Rotate it by revoking this row and inserting a new hash:
+--   INSERT INTO api_keys (id, client_name, key_hash, scopes)
+--   VALUES ('kiosk', 'Kiosk app', encode(sha256('<secret>'::bytea), 'hex'), '{order:write}');
+INSERT INTO api_keys (id, client_name, key_hash, scopes) VALUES
+('dev', 'Development client', encode(sha256('apitest'::bytea), 'hex'),
+ '{order:write,customer:read,customer:write,store:admin,product:admin}');
@@ -0,0 +1,12 @@
+-- db/migrations/007_rate_limits.sql
+
+DROP TABLE IF EXISTS rate_limit_buckets;
+
+-- Token buckets shared by all API instances when RATE_LIMIT_STORE=postgres
+CREATE TABLE rate_limit_buckets (
+    key        TEXT PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
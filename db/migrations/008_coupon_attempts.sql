@@ -0,0 +1,15 @@
+-- db/migrations/008_coupon_attempts.sql
+
+DROP TABLE IF EXISTS coupon_attempts;
+
+-- Failed coupon attempts per client or IP, shared by all API instances when
+-- COUPON_LOCKOUT_STORE=postgres
+CREATE TABLE coupon_attempts (
+    key          TEXT PRIMARY KEY,
+    failures     INTEGER NOT NULL DEFAULT 0,
+    window_start TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_attempts_updated_at ON coupon_attempts (updated_at);
@@ -0,0 +1,48 @@
+-- db/migrations/009_promo_redemptions.sql
+
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_code_limits;
+
+-- Orders can be cancelled; cancelling releases the order's coupon redemption.
+ALTER TABLE orders
+    ADD COLUMN status       TEXT NOT NULL DEFAULT 'placed',
+    ADD COLUMN cancelled_at TIMESTAMPTZ NULL,
+    ADD CONSTRAINT chk_orders_status CHECK (status IN ('placed', 'cancelled'));
+
+-- Redemption caps per promo code. Codes without a row can be redeemed without
+-- limit; a NULL column means that cap does not apply. A single-use code:
+--   INSERT INTO promo_code_limits (code, max_redemptions) VALUES ('BIRTHDAY', 1);
+CREATE TABLE promo_code_limits (
+    code             TEXT PRIMARY KEY,
+    max_redemptions  INTEGER NULL CHECK (max_redemptions > 0),
+    max_per_customer INTEGER NULL CHECK (max_per_customer > 0)
+);
+
+-- One row per order that used a coupon. Released redemptions (cancelled
+-- orders) no longer count towards the caps.
+CREATE TABLE promo_redemptions (
+    order_id    VARCHAR(64) PRIMARY KEY,
+    code        TEXT NOT NULL,
+    customer_id VARCHAR(64) NULL,
+    redeemed_at TIMESTAMPTZ NOT NULL,
+    released_at TIMESTAMPTZ NULL,
+
+    CONSTRAINT fk_promo_redemptions_order
+        FOREIGN KEY (order_id)
+        REFERENCES orders (id)
+        ON DELETE RESTRICT,
+
+    CONSTRAINT fk_promo_redemptions_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers (id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_active
+    ON promo_redemptions (code, customer_id)
+    WHERE released_at IS NULL;
+
+-- The dev client may cancel orders.
+UPDATE api_keys
+SET scopes = array_append(scopes, 'order:manage')
+WHERE id = 'dev' AND NOT ('order:manage' = ANY (scopes));
@@ -0,0 +1,36 @@
+-- db/migrations/010_promo_codes.sql
+
+DROP TABLE IF EXISTS promo_code_active;
+DROP TABLE IF EXISTS promo_codes;
+DROP TABLE IF EXISTS promo_code_versions;
+
+-- Each import of the promo-loader output is a new version of the code set.
+CREATE TABLE promo_code_versions (
+    version      BIGSERIAL PRIMARY KEY,
+    source       TEXT NOT NULL,
+    code_count   BIGINT NOT NULL DEFAULT 0,
+    imported_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    activated_at TIMESTAMPTZ NULL
+);
+
+-- Codes are partitioned by version: cmd/promo-import COPYs each version into
+-- its own table (promo_codes_v<version>), indexes it, then attaches it here.
+-- Old versions are dropped as whole partitions.
+CREATE TABLE promo_codes (
+    version BIGINT NOT NULL,
+    code    TEXT NOT NULL,
+
+    PRIMARY KEY (version, code)
+) PARTITION BY LIST (version);
+
+-- The version the API validates against. A single row; switching versions
+-- is one UPDATE, so readers see either the old or the new set.
+CREATE TABLE promo_code_active (
+    id      BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    version BIGINT NOT NULL,
+
+    CONSTRAINT fk_promo_code_active_version
+        FOREIGN KEY (version)
+        REFERENCES promo_code_versions (version)
+        ON DELETE RESTRICT
+);
@@ -0,0 +1,6 @@
+-- db/migrations/011_promo_admin_scope.sql
+
+-- The dev client may inspect the promo code set (GET /admin/promo-codes).
+UPDATE api_keys
+SET scopes = array_append(scopes, 'promo:admin')
+WHERE id = 'dev' AND NOT ('promo:admin' = ANY (scopes));
@@ -18,22 +18,60 @@ type OrderItemDTO struct {
 	Quantity  int    `json:"quantity"`
 }
 
+// DeliveryAddressDTO is the structured address used for delivery orders
+// swagger:model DeliveryAddress
+type DeliveryAddressDTO struct {
+	Line1    string `json:"line1"`
+	Line2    string `json:"line2,omitempty"`
+	Suburb   string `json:"suburb"`
+	State    string `json:"state"`
+	Postcode string `json:"postcode"`
+}
+
 // OrderReqDTO matches components.schema.OrderReq
 // Used for POST /order request bodies
 // swagger:model OrderReq
 type OrderReqDTO struct {
+	// StoreID selects the shop to order from; defaults to the main store.
+	StoreID string `json:"storeId,omitempty"`
+	// CustomerID links the order to a registered customer; omit for guest orders.
+	// For a customer token it defaults to, and must match, the token's subject.
+	CustomerID string         `json:"customerId,omitempty"`
 	CouponCode *string        `json:"couponCode,omitempty"`
 	Items      []OrderItemDTO `json:"items"`
+	// FulfilmentType is one of dine_in, takeaway or delivery; defaults to takeaway.
+	FulfilmentType  string              `json:"fulfilmentType,omitempty" enums:"dine_in,takeaway,delivery"`
+	TableNumber     *int                `json:"tableNumber,omitempty"`
+	DeliveryAddress *DeliveryAddressDTO `json:"deliveryAddress,omitempty"`
 }
 
 // OrderDTO matches components.schemas.Order
 // used for responses from POST /order (and potentially GET /order in future)
 // swagger:model Order
 type OrderDTO struct {
-	ID         string         `json:"id"`
-	Items      []OrderItemDTO `json:"items"`
-	Products   []ProductDTO   `json:"products"`
-	CouponCode string         `json:"couponCode"`
+	ID              string              `json:"id"`
+	StoreID         string              `json:"storeId"`
+	CustomerID      string              `json:"customerId,omitempty"`
+	Items           []OrderItemDTO      `json:"items"`
+	Products        []ProductDTO        `json:"products"`
+	CouponCode      string              `json:"couponCode"`
+	FulfilmentType  string              `json:"fulfilmentType"`
+	TableNumber     *int                `json:"tableNumber,omitempty"`
+	DeliveryAddress *DeliveryAddressDTO `json:"deliveryAddress,omitempty"`
+	Subtotal        float64             `json:"subtotal"`
+	DeliveryFee     float64             `json:"deliveryFee"`
+	Total           float64             `json:"total"`
+	Status          string              `json:"status,omitempty" enums:"placed,cancelled"`
+	CreatedAt       string              `json:"createdAt,omitempty"`
+	CancelledAt     string              `json:"cancelledAt,omitempty"`
+}
+
+// OrderStatusDTO reports an order's status after a change.
+// Used for POST /order/{orderId}/cancel responses
+// swagger:model OrderStatus
+type OrderStatusDTO struct {
+	ID     string `json:"id"`
+	Status string `json:"status" enums:"placed,cancelled"`
 }
 
 // ApiResponseDTO matches components.schemas.ApiResponse
@@ -43,3 +81,53 @@ type ApiResponseDTO struct {
 	Type    string `json:"type"`
 	Message string `json:"message"`
 }
+
+// StoreStatusDTO describes whether the store is currently taking orders.
+// Used for GET /store/status responses
+// swagger:model StoreStatus
+type StoreStatusDTO struct {
+	AcceptingOrders bool   `json:"acceptingOrders"`
+	Status          string `json:"status"`
+	Message         string `json:"message,omitempty"`
+	LocalTime       string `json:"localTime"`
+}
+
+// StorePauseReqDTO toggles the manual "pause ordering" switch.
+// Used for PUT /store/pause request bodies
+// swagger:model StorePauseReq
+type StorePauseReqDTO struct {
+	Paused bool   `json:"paused"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// CustomerReqDTO is the body of POST /customer.
+// swagger:model CustomerReq
+type CustomerReqDTO struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// CustomerDTO is a registered customer.
+// swagger:model Customer
+type CustomerDTO struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone,omitempty"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// PromoCodeSetDTO describes the promo code set used to validate coupons.
+// Used for GET /admin/promo-codes responses
+// swagger:model PromoCodeSet
+type PromoCodeSetDTO struct {
+	Source    string `json:"source" enums:"file,index,postgres"`
+	Location  string `json:"location"`
+	Version   string `json:"version"`
+	CodeCount int64  `json:"codeCount"`
+	LoadedAt  string `json:"loadedAt,omitempty"`
+	// LastError explains why the latest reload was rejected; the previous set stays in use.
+	LastError     string `json:"lastError,omitempty"`
+	LastCheckedAt string `json:"lastCheckedAt,omitempty"`
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/M-Arthur/order-food-api/internal/domain"
 )
@@ -18,8 +20,11 @@ func (e *ValidationError) Error() string {
 
 // OrderPayload is a helper struct used between adapter and service layers.
 type OrderPayload struct {
+	StoreID    domain.StoreID
+	CustomerID *domain.CustomerID
 	Items      []domain.OrderItem
 	CouponCode *string
+	Fulfilment domain.Fulfilment
 }
 
 // MapOrderReqToPayload validates the request and returns domain-friendly data.
@@ -46,12 +51,87 @@ func MapOrderReqToPayload(req OrderReqDTO) (*OrderPayload, error) {
 		})
 	}
 
+	storeID := domain.DefaultStoreID
+	if req.StoreID != "" {
+		storeID = domain.StoreID(req.StoreID)
+	}
+
+	var customerID *domain.CustomerID
+	if req.CustomerID != "" {
+		id := domain.CustomerID(req.CustomerID)
+		customerID = &id
+	}
+
+	fulfilment, err := mapFulfilment(req)
+	if err != nil {
+		return nil, err
+	}
+
 	return &OrderPayload{
+		StoreID:    storeID,
+		CustomerID: customerID,
 		Items:      items,
 		CouponCode: req.CouponCode,
+		Fulfilment: fulfilment,
 	}, nil
 }
 
+// mapFulfilment validates the fulfilment fields of an order request.
+func mapFulfilment(req OrderReqDTO) (domain.Fulfilment, error) {
+	f := domain.Fulfilment{Type: domain.FulfilmentTakeaway}
+	if req.FulfilmentType != "" {
+		f.Type = domain.FulfilmentType(req.FulfilmentType)
+	}
+
+	switch f.Type {
+	case domain.FulfilmentDineIn, domain.FulfilmentTakeaway, domain.FulfilmentDelivery:
+	default:
+		return f, &ValidationError{Field: "fulfilmentType", Message: "must be one of dine_in, takeaway, delivery"}
+	}
+
+	if f.Type == domain.FulfilmentDineIn {
+		if req.TableNumber == nil || *req.TableNumber < 1 {
+			return f, &ValidationError{Field: "tableNumber", Message: "required for dine_in and must be >= 1"}
+		}
+		f.TableNumber = *req.TableNumber
+	} else if req.TableNumber != nil {
+		return f, &ValidationError{Field: "tableNumber", Message: "only allowed for dine_in"}
+	}
+
+	if f.Type != domain.FulfilmentDelivery {
+		if req.DeliveryAddress != nil {
+			return f, &ValidationError{Field: "deliveryAddress", Message: "only allowed for delivery"}
+		}
+		return f, nil
+	}
+
+	a := req.DeliveryAddress
+	if a == nil {
+		return f, &ValidationError{Field: "deliveryAddress", Message: "required for delivery"}
+	}
+	for _, part := range []struct{ field, value string }{
+		{"deliveryAddress.line1", a.Line1},
+		{"deliveryAddress.suburb", a.Suburb},
+		{"deliveryAddress.state", a.State},
+	} {
+		if strings.TrimSpace(part.value) == "" {
+			return f, &ValidationError{Field: part.field, Message: "required"}
+		}
+	}
+	if !domain.IsValidPostcode(a.Postcode) {
+		return f, &ValidationError{Field: "deliveryAddress.postcode", Message: "must be 4 digits"}
+	}
+
+	f.Address = &domain.DeliveryAddress{
+		Line1:    a.Line1,
+		Line2:    a.Line2,
+		Suburb:   a.Suburb,
+		State:    a.State,
+		Postcode: a.Postcode,
+	}
+	return f, nil
+}
+
 // MapDomainProductToDTO converts a domain.Product to the API representation.
 func MapDomainProductToDTO(p domain.Product) ProductDTO {
 	return ProductDTO{
@@ -86,10 +166,99 @@ func MapDomainOrderToDTO(order *domain.Order, products []domain.Product) OrderDT
 		couponCode = *order.CouponCode
 	}
 
-	return OrderDTO{
-		ID:         string(order.ID),
-		Items:      itemDTOs,
-		Products:   MapDomainProductsToDTO(products),
-		CouponCode: couponCode,
+	dto := OrderDTO{
+		ID:             string(order.ID),
+		StoreID:        string(order.StoreID),
+		Items:          itemDTOs,
+		Products:       MapDomainProductsToDTO(products),
+		CouponCode:     couponCode,
+		FulfilmentType: string(order.Fulfilment.Type),
+		Subtotal:       order.Pricing.Subtotal.ToFloat(),
+		DeliveryFee:    order.Pricing.DeliveryFee.ToFloat(),
+		Total:          order.Pricing.Total.ToFloat(),
+		Status:         string(order.Status),
+	}
+
+	if order.Fulfilment.TableNumber > 0 {
+		tableNumber := order.Fulfilment.TableNumber
+		dto.TableNumber = &tableNumber
+	}
+
+	if a := order.Fulfilment.Address; a != nil {
+		dto.DeliveryAddress = &DeliveryAddressDTO{
+			Line1:    a.Line1,
+			Line2:    a.Line2,
+			Suburb:   a.Suburb,
+			State:    a.State,
+			Postcode: a.Postcode,
+		}
+	}
+
+	if order.CustomerID != nil {
+		dto.CustomerID = string(*order.CustomerID)
+	}
+	if !order.CreatedAt.IsZero() {
+		dto.CreatedAt = order.CreatedAt.Format(time.RFC3339)
+	}
+	if order.CancelledAt != nil {
+		dto.CancelledAt = order.CancelledAt.Format(time.RFC3339)
+	}
+
+	return dto
+}
+
+// MapDomainOrdersToDTO converts an order history into API orders, attaching
+// to each order the products its items reference, as sold at its store.
+func MapDomainOrdersToDTO(orders []domain.Order, productsByStore map[domain.StoreID]map[domain.ProductID]domain.Product) []OrderDTO {
+	out := make([]OrderDTO, 0, len(orders))
+	for i := range orders {
+		productsByID := productsByStore[orders[i].StoreID]
+		products := make([]domain.Product, 0, len(orders[i].Items))
+		for _, item := range orders[i].Items {
+			if p, ok := productsByID[item.ProductID]; ok {
+				products = append(products, p)
+			}
+		}
+		out = append(out, MapDomainOrderToDTO(&orders[i], products))
+	}
+	return out
+}
+
+// MapDomainCustomerToDTO converts a domain.Customer to the API representation.
+func MapDomainCustomerToDTO(c *domain.Customer) CustomerDTO {
+	return CustomerDTO{
+		ID:        string(c.ID),
+		Name:      c.Name,
+		Email:     c.Email,
+		Phone:     c.Phone,
+		CreatedAt: c.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// MapDomainStoreStatusToDTO converts a domain.StoreStatus to the API representation.
+func MapDomainStoreStatusToDTO(s domain.StoreStatus) StoreStatusDTO {
+	return StoreStatusDTO{
+		AcceptingOrders: s.AcceptingOrders,
+		Status:          string(s.Reason),
+		Message:         s.Message,
+		LocalTime:       s.LocalTime.Format(time.RFC3339),
+	}
+}
+
+// MapDomainPromoCodeSetStatusToDTO converts a domain.PromoCodeSetStatus to its DTO.
+func MapDomainPromoCodeSetStatusToDTO(st *domain.PromoCodeSetStatus) PromoCodeSetDTO {
+	dto := PromoCodeSetDTO{
+		Source:    st.Source,
+		Location:  st.Location,
+		Version:   st.Version,
+		CodeCount: st.CodeCount,
+		LastError: st.LastError,
+	}
+	if !st.LoadedAt.IsZero() {
+		dto.LoadedAt = st.LoadedAt.UTC().Format(time.RFC3339)
+	}
+	if !st.LastCheckedAt.IsZero() {
+		dto.LastCheckedAt = st.LastCheckedAt.UTC().Format(time.RFC3339)
 	}
+	return dto
 }
@@ -3,6 +3,7 @@ package api_test
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/M-Arthur/order-food-api/internal/api"
 	"github.com/M-Arthur/order-food-api/internal/domain"
@@ -30,6 +31,10 @@ func TestMapOrderReqToPayload_Success(t *testing.T) {
 		t.Fatalf("order.CouponCode = %v, want %s", payload.CouponCode, *req.CouponCode)
 	}
 
+	if payload.StoreID != domain.DefaultStoreID {
+		t.Fatalf("order.StoreID = %s, want default %s", payload.StoreID, domain.DefaultStoreID)
+	}
+
 	for i, item := range payload.Items {
 		want := req.Items[i]
 		if string(item.ProductID) != want.ProductID {
@@ -41,6 +46,45 @@ func TestMapOrderReqToPayload_Success(t *testing.T) {
 	}
 }
 
+func TestMapOrderReqToPayload_StoreID(t *testing.T) {
+	req := api.OrderReqDTO{
+		StoreID: "cbd",
+		Items: []api.OrderItemDTO{
+			{ProductID: "p1", Quantity: 1},
+		},
+	}
+
+	payload, err := api.MapOrderReqToPayload(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if payload.StoreID != "cbd" {
+		t.Fatalf("order.StoreID = %s, want %s", payload.StoreID, "cbd")
+	}
+	if payload.CustomerID != nil {
+		t.Fatalf("payload.CustomerID = %v, want nil for guest orders", *payload.CustomerID)
+	}
+}
+
+func TestMapOrderReqToPayload_CustomerID(t *testing.T) {
+	req := api.OrderReqDTO{
+		CustomerID: "c1",
+		Items: []api.OrderItemDTO{
+			{ProductID: "p1", Quantity: 1},
+		},
+	}
+
+	payload, err := api.MapOrderReqToPayload(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if payload.CustomerID == nil || *payload.CustomerID != "c1" {
+		t.Fatalf("payload.CustomerID = %v, want c1", payload.CustomerID)
+	}
+}
+
 func TestMapOrderReqToPayload_ValidationErrors(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -111,6 +155,105 @@ func TestMapOrderReqToPayload_ValidationErrors(t *testing.T) {
 	}
 }
 
+func TestMapOrderReqToPayload_Fulfilment(t *testing.T) {
+	items := []api.OrderItemDTO{{ProductID: "p1", Quantity: 1}}
+	address := &api.DeliveryAddressDTO{Line1: "1 George St", Suburb: "Sydney", State: "NSW", Postcode: "2000"}
+
+	tests := []struct {
+		name        string
+		req         api.OrderReqDTO
+		wantType    domain.FulfilmentType
+		wantField   string
+		wantMessage string
+	}{
+		{
+			name:     "defaults to takeaway",
+			req:      api.OrderReqDTO{Items: items},
+			wantType: domain.FulfilmentTakeaway,
+		},
+		{
+			name:     "dine in",
+			req:      api.OrderReqDTO{Items: items, FulfilmentType: "dine_in", TableNumber: ptr(7)},
+			wantType: domain.FulfilmentDineIn,
+		},
+		{
+			name:     "delivery",
+			req:      api.OrderReqDTO{Items: items, FulfilmentType: "delivery", DeliveryAddress: address},
+			wantType: domain.FulfilmentDelivery,
+		},
+		{
+			name:        "unknown type",
+			req:         api.OrderReqDTO{Items: items, FulfilmentType: "pickup"},
+			wantField:   "fulfilmentType",
+			wantMessage: "must be one of dine_in, takeaway, delivery",
+		},
+		{
+			name:        "dine in without table",
+			req:         api.OrderReqDTO{Items: items, FulfilmentType: "dine_in"},
+			wantField:   "tableNumber",
+			wantMessage: "required for dine_in and must be >= 1",
+		},
+		{
+			name:        "table for takeaway",
+			req:         api.OrderReqDTO{Items: items, TableNumber: ptr(7)},
+			wantField:   "tableNumber",
+			wantMessage: "only allowed for dine_in",
+		},
+		{
+			name:        "delivery without address",
+			req:         api.OrderReqDTO{Items: items, FulfilmentType: "delivery"},
+			wantField:   "deliveryAddress",
+			wantMessage: "required for delivery",
+		},
+		{
+			name: "delivery without suburb",
+			req: api.OrderReqDTO{Items: items, FulfilmentType: "delivery", DeliveryAddress: &api.DeliveryAddressDTO{
+				Line1: "1 George St", State: "NSW", Postcode: "2000",
+			}},
+			wantField:   "deliveryAddress.suburb",
+			wantMessage: "required",
+		},
+		{
+			name: "delivery with bad postcode",
+			req: api.OrderReqDTO{Items: items, FulfilmentType: "delivery", DeliveryAddress: &api.DeliveryAddressDTO{
+				Line1: "1 George St", Suburb: "Sydney", State: "NSW", Postcode: "NSW2000",
+			}},
+			wantField:   "deliveryAddress.postcode",
+			wantMessage: "must be 4 digits",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := api.MapOrderReqToPayload(tt.req)
+
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if payload.Fulfilment.Type != tt.wantType {
+					t.Fatalf("Fulfilment.Type = %s, want %s", payload.Fulfilment.Type, tt.wantType)
+				}
+				if err := payload.Fulfilment.Validate(); err != nil {
+					t.Fatalf("mapped fulfilment does not satisfy domain rules: %v", err)
+				}
+				return
+			}
+
+			var ve *api.ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("expected ValidationError, got %T (%v)", err, err)
+			}
+			if ve.Field != tt.wantField {
+				t.Errorf("ValidationError.Field = %q, want %q", ve.Field, tt.wantField)
+			}
+			if ve.Message != tt.wantMessage {
+				t.Errorf("ValidationError.Message = %q, want %q", ve.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
 func TestMapDomainProductToDTO(t *testing.T) {
 	p := domain.Product{
 		ID:       domain.ProductID("10"),
@@ -190,6 +333,13 @@ func TestMapDomainOrderToDTO(t *testing.T) {
 			t.Errorf("products[%d].Price = %v, want %v", i, p.Price, want.Price.ToFloat())
 		}
 	}
+
+	if dto.FulfilmentType != "" {
+		t.Errorf("dto.FulfilmentType = %s, want empty for zero-value order", dto.FulfilmentType)
+	}
+	if dto.TableNumber != nil || dto.DeliveryAddress != nil {
+		t.Errorf("dto table/address = %v/%v, want nil", dto.TableNumber, dto.DeliveryAddress)
+	}
 }
 
 func TestMapDomainOrderToDTO_ReturnCouponCode(t *testing.T) {
@@ -253,3 +403,48 @@ func TestMapDomainOrderToDTO_ReturnCouponCode(t *testing.T) {
 func ptr[T any](v T) *T {
 	return &v
 }
+
+func TestMapDomainOrderToDTO_FulfilmentAndPricing(t *testing.T) {
+	order := &domain.Order{
+		ID:    "order-123",
+		Items: []domain.OrderItem{{ProductID: "10", Quantity: 2}},
+		Fulfilment: domain.Fulfilment{
+			Type: domain.FulfilmentDelivery,
+			Address: &domain.DeliveryAddress{
+				Line1: "1 George St", Suburb: "Sydney", State: "NSW", Postcode: "2000",
+			},
+		},
+		Pricing: domain.OrderPricing{Subtotal: 2400, DeliveryFee: 500, Total: 2900},
+	}
+
+	dto := api.MapDomainOrderToDTO(order, nil)
+
+	if dto.FulfilmentType != "delivery" {
+		t.Errorf("dto.FulfilmentType = %s, want delivery", dto.FulfilmentType)
+	}
+	if dto.DeliveryAddress == nil || dto.DeliveryAddress.Postcode != "2000" {
+		t.Errorf("dto.DeliveryAddress = %+v, want postcode 2000", dto.DeliveryAddress)
+	}
+	if dto.Subtotal != 24 || dto.DeliveryFee != 5 || dto.Total != 29 {
+		t.Errorf("dto pricing = %v/%v/%v, want 24/5/29", dto.Subtotal, dto.DeliveryFee, dto.Total)
+	}
+}
+
+func TestMapDomainOrderToDTO_CancelledOrder(t *testing.T) {
+	cancelledAt := time.Date(2025, 6, 1, 12, 30, 0, 0, time.UTC)
+	order := &domain.Order{
+		ID:          "order-123",
+		Items:       []domain.OrderItem{{ProductID: "10", Quantity: 1}},
+		Status:      domain.OrderCancelled,
+		CancelledAt: &cancelledAt,
+	}
+
+	dto := api.MapDomainOrderToDTO(order, nil)
+
+	if dto.Status != "cancelled" {
+		t.Errorf("dto.Status = %q, want cancelled", dto.Status)
+	}
+	if dto.CancelledAt != "2025-06-01T12:30:00Z" {
+		t.Errorf("dto.CancelledAt = %q, want 2025-06-01T12:30:00Z", dto.CancelledAt)
+	}
+}
@@ -1,6 +1,7 @@
 package bootstrap
 
 import (
+	"crypto/rsa"
 	"database/sql"
 	"fmt"
 	"time"
@@ -8,29 +9,59 @@ import (
 	_ "github.com/lib/pq" // or your driver
 
 	"github.com/M-Arthur/order-food-api/internal/config"
+	"github.com/M-Arthur/order-food-api/internal/coupon"
 	"github.com/M-Arthur/order-food-api/internal/domain"
 	"github.com/M-Arthur/order-food-api/internal/httpapi/handlers"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/middleware"
+	"github.com/M-Arthur/order-food-api/internal/promo"
+	"github.com/M-Arthur/order-food-api/internal/ratelimit"
 	"github.com/M-Arthur/order-food-api/internal/service"
 	"github.com/M-Arthur/order-food-api/internal/storage"
 )
 
 type Infra struct {
 	DB *sql.DB
+	// JWT verifies bearer tokens; nil when JWT authentication is not configured.
+	JWT *middleware.JWTVerifier
+	// RateLimit holds the token buckets of the rate limiter.
+	RateLimit ratelimit.Store
+	// PromoCodes validates coupon codes entered with orders.
+	PromoCodes domain.PromoCodeValidator
+	// PromoCodeStatus describes the code set PromoCodes serves.
+	PromoCodeStatus domain.PromoCodeSetReporter
+	// PromoReloader watches the promo codes file or index; nil when codes
+	// come from Postgres or reloading is disabled.
+	PromoReloader *promo.ReloadingCodeSet
+	// PromoReloadInterval is how often PromoReloader checks the file.
+	PromoReloadInterval time.Duration
+	// CouponAttempts counts failed coupon attempts for lockouts.
+	CouponAttempts domain.CouponAttemptTracker
 }
 
 type Repos struct {
-	Product domain.ProductRepository
-	Order   domain.OrderRepository
+	Product  domain.ProductRepository
+	Order    domain.OrderRepository
+	Store    domain.StoreRepository
+	Zone     domain.DeliveryZoneRepository
+	Customer domain.CustomerRepository
+	APIKey   domain.APIKeyRepository
 }
 
 type Services struct {
-	Product service.ProductService
-	Order   service.OrderService
+	Product  service.ProductService
+	Order    service.OrderService
+	Store    service.StoreService
+	Customer service.CustomerService
+	APIKey   service.APIKeyService
+	Promo    service.PromoService
 }
 
 type Handlers struct {
-	Product *handlers.ProductHandler
-	Order   *handlers.OrderHandler
+	Product  *handlers.ProductHandler
+	Order    *handlers.OrderHandler
+	Store    *handlers.StoreHandler
+	Customer *handlers.CustomerHandler
+	Promo    *handlers.PromoHandler
 }
 
 type Dependencies struct {
@@ -48,7 +79,7 @@ func BuildDependencies(c config.Config) (*Dependencies, error) {
 
 	infra := *infraPtr
 	repos := buildRepos(infra)
-	services := buildServices(repos)
+	services := buildServices(infra, repos)
 	handlers := buildHandlers(services)
 
 	return &Dependencies{
@@ -78,7 +109,108 @@ func buildInfra(c config.Config) (*Infra, error) {
 		return nil, fmt.Errorf("ping db: %w", err)
 	}
 
-	return &Infra{DB: db}, nil
+	jwtVerifier, err := buildJWTVerifier(c.JWT)
+	if err != nil {
+		return nil, err
+	}
+
+	var rateLimitStore ratelimit.Store
+	switch c.RateLimit.Store {
+	case "memory":
+		rateLimitStore = ratelimit.NewMemoryStore(nil)
+	case "postgres":
+		rateLimitStore = storage.NewPgRateLimitStore(db)
+	default:
+		return nil, fmt.Errorf("RATE_LIMIT_STORE must be memory or postgres, got %q", c.RateLimit.Store)
+	}
+
+	var (
+		promoCodes      domain.PromoCodeValidator
+		promoCodeStatus domain.PromoCodeSetReporter
+		promoReloader   *promo.ReloadingCodeSet
+	)
+	switch c.Coupon.CodesSource {
+	case "file":
+		codes, err := promo.NewReloadingCodeSet(c.Coupon.CodesFile, nil)
+		if err != nil {
+			return nil, fmt.Errorf("load promo codes (PROMO_CODES_FILE): %w", err)
+		}
+		promoCodes, promoCodeStatus = codes, codes
+		if c.Coupon.CodesReloadSeconds > 0 {
+			promoReloader = codes
+		}
+	case "index":
+		codes, err := promo.NewReloadingIndex(c.Coupon.CodesIndexFile, nil)
+		if err != nil {
+			return nil, fmt.Errorf("load promo code index (PROMO_CODES_INDEX_FILE): %w", err)
+		}
+		promoCodes, promoCodeStatus = codes, codes
+		if c.Coupon.CodesReloadSeconds > 0 {
+			promoReloader = codes
+		}
+	case "postgres":
+		codes := storage.NewPgPromoCodeValidator(db)
+		promoCodes, promoCodeStatus = codes, codes
+	default:
+		return nil, fmt.Errorf("PROMO_CODES_SOURCE must be file, index or postgres, got %q", c.Coupon.CodesSource)
+	}
+
+	lockout := coupon.LockoutPolicy{
+		Threshold: c.Coupon.LockoutThreshold,
+		Window:    time.Duration(c.Coupon.LockoutWindowSeconds) * time.Second,
+		Duration:  time.Duration(c.Coupon.LockoutSeconds) * time.Second,
+	}
+	var couponAttempts domain.CouponAttemptTracker
+	switch c.Coupon.LockoutStore {
+	case "memory":
+		couponAttempts = coupon.NewMemoryLockout(lockout, nil)
+	case "postgres":
+		couponAttempts = storage.NewPgCouponLockout(db, lockout)
+	default:
+		return nil, fmt.Errorf("COUPON_LOCKOUT_STORE must be memory or postgres, got %q", c.Coupon.LockoutStore)
+	}
+
+	return &Infra{
+		DB:                  db,
+		JWT:                 jwtVerifier,
+		RateLimit:           rateLimitStore,
+		PromoCodes:          promoCodes,
+		PromoCodeStatus:     promoCodeStatus,
+		PromoReloader:       promoReloader,
+		PromoReloadInterval: time.Duration(c.Coupon.CodesReloadSeconds) * time.Second,
+		CouponAttempts:      couponAttempts,
+	}, nil
+}
+
+func buildJWTVerifier(c config.JWT) (*middleware.JWTVerifier, error) {
+	jc := middleware.JWTConfig{
+		HMACSecret: []byte(c.HMACSecret),
+		RSAKeys:    map[string]*rsa.PublicKey{},
+		Issuer:     c.Issuer,
+		Audience:   c.Audience,
+		Leeway:     time.Duration(c.LeewaySeconds) * time.Second,
+	}
+
+	if c.JWKSFile != "" {
+		keys, err := middleware.LoadJWKSFile(c.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		jc.RSAKeys = keys
+	}
+	if c.RSAPublicKeyFile != "" {
+		key, err := middleware.LoadRSAPublicKeyFile(c.RSAPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		// Used for tokens without a kid header
+		jc.RSAKeys[""] = key
+	}
+
+	if len(jc.HMACSecret) == 0 && len(jc.RSAKeys) == 0 {
+		return nil, nil
+	}
+	return middleware.NewJWTVerifier(jc)
 }
 
 func buildRepos(inf Infra) Repos {
@@ -88,28 +220,59 @@ func buildRepos(inf Infra) Repos {
 	// Order repo
 	or := storage.NewPgOrderRepository(inf.DB)
 
+	// Store repo
+	sr := storage.NewPgStoreRepository(inf.DB)
+
+	// Delivery zone repo
+	zr := storage.NewPgDeliveryZoneRepository(inf.DB)
+
+	// Customer repo
+	cr := storage.NewPgCustomerRepository(inf.DB)
+
+	// API key repo
+	kr := storage.NewPgAPIKeyRepository(inf.DB)
+
 	return Repos{
-		Product: pr,
-		Order:   or,
+		Product:  pr,
+		Order:    or,
+		Store:    sr,
+		Zone:     zr,
+		Customer: cr,
+		APIKey:   kr,
 	}
 }
 
-func buildServices(r Repos) Services {
-	ps := service.NewProductService(r.Product)
-	os := service.NewOrderService(r.Order, r.Product)
+func buildServices(inf Infra, r Repos) Services {
+	ps := service.NewProductService(r.Product, r.Store)
+	ss := service.NewStoreService(r.Store, nil)
+	cps := service.NewCouponService(inf.PromoCodes, inf.CouponAttempts)
+	os := service.NewOrderService(r.Order, r.Product, r.Zone, r.Customer, ss, cps, nil)
+	cs := service.NewCustomerService(r.Customer, r.Order, r.Product, nil)
+	ks := service.NewAPIKeyService(r.APIKey, nil)
+	prs := service.NewPromoService(inf.PromoCodeStatus)
 
 	return Services{
-		Product: ps,
-		Order:   os,
+		Product:  ps,
+		Order:    os,
+		Store:    ss,
+		Customer: cs,
+		APIKey:   ks,
+		Promo:    prs,
 	}
 }
 
 func buildHandlers(svc Services) Handlers {
 	ph := handlers.NewProductHandler(svc.Product)
 	oh := handlers.NewOrderHandler(svc.Order)
+	sh := handlers.NewStoreHandler(svc.Store)
+	ch := handlers.NewCustomerHandler(svc.Customer)
+	prh := handlers.NewPromoHandler(svc.Promo)
 
 	return Handlers{
-		Product: ph,
-		Order:   oh,
+		Product:  ph,
+		Order:    oh,
+		Store:    sh,
+		Customer: ch,
+		Promo:    prh,
 	}
 }
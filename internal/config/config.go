@@ -7,10 +7,58 @@ import (
 )
 
 type Config struct {
-	Port   string
-	AppEnv string
-	DB     DB
-	APIKey string
+	Port      string
+	AppEnv    string
+	DB        DB
+	JWT       JWT
+	RateLimit RateLimit
+	Coupon    Coupon
+}
+
+// JWT configures bearer token authentication. It is disabled when no
+// secret, public key or JWKS file is set.
+type JWT struct {
+	HMACSecret       string
+	RSAPublicKeyFile string
+	JWKSFile         string
+	Issuer           string
+	Audience         string
+	LeewaySeconds    int
+}
+
+// RateLimit configures request rate limits per route group. A group with
+// PerMinute or Burst of 0 is not limited.
+type RateLimit struct {
+	// Store is "memory" (per instance) or "postgres" (shared by all instances).
+	Store         string
+	Public        Limit
+	Order         Limit
+	Authenticated Limit
+	Auth          Limit
+}
+
+// Coupon configures promo code validation and the lockout of callers that
+// enter too many unknown codes. A LockoutThreshold of 0 disables lockouts.
+type Coupon struct {
+	// CodesSource is "file" (CodesFile, per instance), "index" (CodesIndexFile,
+	// a memory-mapped index written by cmd/promo-loader) or "postgres" (the
+	// active set imported by cmd/promo-import).
+	CodesSource    string
+	CodesFile      string
+	CodesIndexFile string
+	// CodesReloadSeconds is how often CodesFile or CodesIndexFile is checked
+	// for changes; 0 disables reloading.
+	CodesReloadSeconds int
+	// LockoutStore is "memory" (per instance) or "postgres" (shared by all instances).
+	LockoutStore         string
+	LockoutThreshold     int
+	LockoutWindowSeconds int
+	LockoutSeconds       int
+}
+
+type Limit struct {
+	PerMinute int
+	Burst     int
 }
 
 type DB struct {
@@ -30,7 +78,43 @@ func Load() Config {
 			MaxIdleConns: envInt("DB_MAX_IDLE_CONNS", 5),
 			MaxLifetime:  envInt64("DB_MAX_LIFETIME", int64(30*time.Minute)),
 		},
-		APIKey: envString("API_KEY", "apitest"),
+		JWT: JWT{
+			HMACSecret:       envString("JWT_HMAC_SECRET", ""),
+			RSAPublicKeyFile: envString("JWT_RSA_PUBLIC_KEY_FILE", ""),
+			JWKSFile:         envString("JWT_JWKS_FILE", ""),
+			Issuer:           envString("JWT_ISSUER", ""),
+			Audience:         envString("JWT_AUDIENCE", ""),
+			LeewaySeconds:    envInt("JWT_LEEWAY_SECONDS", 30),
+		},
+		RateLimit: RateLimit{
+			Store: envString("RATE_LIMIT_STORE", "memory"),
+			Public: Limit{
+				PerMinute: envInt("RATE_LIMIT_PUBLIC_PER_MINUTE", 120),
+				Burst:     envInt("RATE_LIMIT_PUBLIC_BURST", 40),
+			},
+			Order: Limit{
+				PerMinute: envInt("RATE_LIMIT_ORDER_PER_MINUTE", 20),
+				Burst:     envInt("RATE_LIMIT_ORDER_BURST", 5),
+			},
+			Authenticated: Limit{
+				PerMinute: envInt("RATE_LIMIT_AUTHENTICATED_PER_MINUTE", 60),
+				Burst:     envInt("RATE_LIMIT_AUTHENTICATED_BURST", 20),
+			},
+			Auth: Limit{
+				PerMinute: envInt("RATE_LIMIT_AUTH_PER_MINUTE", 300),
+				Burst:     envInt("RATE_LIMIT_AUTH_BURST", 100),
+			},
+		},
+		Coupon: Coupon{
+			CodesSource:          envString("PROMO_CODES_SOURCE", "file"),
+			CodesFile:            envString("PROMO_CODES_FILE", "valid_promo_codes.txt"),
+			CodesIndexFile:       envString("PROMO_CODES_INDEX_FILE", "valid_promo_codes.idx"),
+			CodesReloadSeconds:   envInt("PROMO_CODES_RELOAD_SECONDS", 30),
+			LockoutStore:         envString("COUPON_LOCKOUT_STORE", "memory"),
+			LockoutThreshold:     envInt("COUPON_LOCKOUT_THRESHOLD", 5),
+			LockoutWindowSeconds: envInt("COUPON_LOCKOUT_WINDOW_SECONDS", 600),
+			LockoutSeconds:       envInt("COUPON_LOCKOUT_SECONDS", 900),
+		},
 	}
 }
 
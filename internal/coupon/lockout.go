@@ -0,0 +1,52 @@
+// Package coupon guards coupon entry against guessing by locking out
+// clients that submit too many invalid codes. The counting policy is shared
+// by the in-memory tracker here and the database-backed one in storage.
+package coupon
+
+import (
+	"time"
+)
+
+// LockoutPolicy locks a key out for Duration once it has made Threshold
+// failed attempts within Window.
+type LockoutPolicy struct {
+	Threshold int
+	Window    time.Duration
+	Duration  time.Duration
+}
+
+// Enabled reports whether the policy locks anyone out.
+func (p LockoutPolicy) Enabled() bool {
+	return p.Threshold > 0 && p.Window > 0 && p.Duration > 0
+}
+
+// Attempts is the failure record kept per key.
+type Attempts struct {
+	Failures    int
+	WindowStart time.Time
+	LockedUntil time.Time // zero when never locked
+}
+
+// Fail is the counting shared by tracker implementations: it adds a failure
+// at now to a, starting a new window when the old one has passed, and locks
+// the key once the threshold is reached. The counter restarts after a
+// lockout, so each further Threshold failures lock the key again.
+func (p LockoutPolicy) Fail(a Attempts, now time.Time) Attempts {
+	if a.Failures == 0 || now.Sub(a.WindowStart) >= p.Window {
+		a.Failures = 0
+		a.WindowStart = now
+	}
+
+	a.Failures++
+	if a.Failures >= p.Threshold {
+		a.Failures = 0
+		a.LockedUntil = now.Add(p.Duration)
+	}
+	return a
+}
+
+// Idle reports whether a holds nothing that still matters at now, so it
+// can be forgotten.
+func (p LockoutPolicy) Idle(a Attempts, now time.Time) bool {
+	return !now.Before(a.LockedUntil) && (a.Failures == 0 || now.Sub(a.WindowStart) >= p.Window)
+}
@@ -0,0 +1,84 @@
+package coupon_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/coupon"
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+// complie-time checks
+var _ domain.CouponAttemptTracker = (*coupon.MemoryLockout)(nil)
+
+func TestLockoutPolicy_Fail(t *testing.T) {
+	p := coupon.LockoutPolicy{Threshold: 3, Window: time.Minute, Duration: time.Hour}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var a coupon.Attempts
+	a = p.Fail(a, start)
+	a = p.Fail(a, start.Add(30*time.Second))
+	if a.Failures != 2 || !a.LockedUntil.IsZero() {
+		t.Fatalf("after 2 failures: %+v, want 2 failures and no lockout", a)
+	}
+
+	// The window has passed, so counting starts again
+	a = p.Fail(a, start.Add(2*time.Minute))
+	if a.Failures != 1 || !a.LockedUntil.IsZero() {
+		t.Fatalf("after window: %+v, want 1 failure and no lockout", a)
+	}
+
+	a = p.Fail(a, start.Add(2*time.Minute))
+	a = p.Fail(a, start.Add(2*time.Minute))
+	if want := start.Add(2*time.Minute + time.Hour); !a.LockedUntil.Equal(want) || a.Failures != 0 {
+		t.Fatalf("after threshold: %+v, want locked until %v", a, want)
+	}
+	if p.Idle(a, start.Add(time.Hour)) {
+		t.Fatalf("Idle() during lockout = true, want false")
+	}
+	if !p.Idle(a, start.Add(3*time.Hour)) {
+		t.Fatalf("Idle() after lockout = false, want true")
+	}
+}
+
+func TestMemoryLockout(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := coupon.LockoutPolicy{Threshold: 2, Window: time.Minute, Duration: 5 * time.Minute}
+	l := coupon.NewMemoryLockout(policy, func() time.Time { return now })
+	ctx := context.Background()
+
+	if until, _ := l.RecordFailure(ctx, "a"); !until.IsZero() {
+		t.Fatalf("first failure locked until %v, want no lockout", until)
+	}
+	until, err := l.RecordFailure(ctx, "a")
+	if err != nil || !until.Equal(now.Add(5*time.Minute)) {
+		t.Fatalf("second failure = %v, %v, want lockout until %v", until, err, now.Add(5*time.Minute))
+	}
+	if got, _ := l.LockedUntil(ctx, "a"); !got.Equal(until) {
+		t.Fatalf("LockedUntil(a) = %v, want %v", got, until)
+	}
+	if got, _ := l.LockedUntil(ctx, "b"); !got.IsZero() {
+		t.Fatalf("LockedUntil(b) = %v, want zero", got)
+	}
+
+	// Expired lockouts are swept
+	now = now.Add(time.Hour)
+	if got, _ := l.LockedUntil(ctx, "a"); !got.IsZero() {
+		t.Fatalf("LockedUntil(a) after expiry = %v, want zero", got)
+	}
+	if _, err := l.RecordFailure(ctx, "c"); err != nil {
+		t.Fatalf("RecordFailure() error = %v", err)
+	}
+	if l.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1 after sweep", l.Len())
+	}
+
+	// A disabled policy never locks
+	off := coupon.NewMemoryLockout(coupon.LockoutPolicy{}, nil)
+	for i := 0; i < 10; i++ {
+		if until, _ := off.RecordFailure(ctx, "a"); !until.IsZero() {
+			t.Fatalf("disabled policy locked out")
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package coupon
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryLockout drops idle entries.
+const sweepInterval = time.Minute
+
+// MemoryLockout tracks failed attempts in process memory. Use it for
+// single-instance deployments; each instance counts on its own.
+type MemoryLockout struct {
+	mu        sync.Mutex
+	policy    LockoutPolicy
+	attempts  map[string]*Attempts
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryLockout builds a MemoryLockout. now defaults to time.Now when nil.
+func NewMemoryLockout(policy LockoutPolicy, now func() time.Time) *MemoryLockout {
+	if now == nil {
+		now = time.Now
+	}
+	return &MemoryLockout{
+		policy:   policy,
+		attempts: make(map[string]*Attempts),
+		now:      now,
+	}
+}
+
+func (l *MemoryLockout) LockedUntil(_ context.Context, key string) (time.Time, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.attempts[key]
+	if !ok || !l.now().Before(a.LockedUntil) {
+		return time.Time{}, nil
+	}
+	return a.LockedUntil, nil
+}
+
+func (l *MemoryLockout) RecordFailure(_ context.Context, key string) (time.Time, error) {
+	if !l.policy.Enabled() {
+		return time.Time{}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	a, ok := l.attempts[key]
+	if !ok {
+		a = &Attempts{}
+		l.attempts[key] = a
+	}
+
+	before := a.LockedUntil
+	*a = l.policy.Fail(*a, now)
+	if a.LockedUntil.Equal(before) {
+		return time.Time{}, nil
+	}
+	return a.LockedUntil, nil
+}
+
+// sweep drops entries with no live lockout and an expired window.
+func (l *MemoryLockout) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, a := range l.attempts {
+		if l.policy.Idle(*a, now) {
+			delete(l.attempts, key)
+		}
+	}
+}
+
+// Len returns the number of tracked keys.
+func (l *MemoryLockout) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.attempts)
+}
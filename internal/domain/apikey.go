@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyExpired  = errors.New("api key expired")
+	ErrAPIKeyRevoked  = errors.New("api key revoked")
+)
+
+// APIScope is a permission granted to an API client, e.g. "order:write".
+type APIScope string
+
+const (
+	ScopeOrderWrite    APIScope = "order:write"
+	ScopeOrderManage   APIScope = "order:manage"
+	ScopeCustomerRead  APIScope = "customer:read"
+	ScopeCustomerWrite APIScope = "customer:write"
+	ScopeStoreAdmin    APIScope = "store:admin"
+	ScopeProductAdmin  APIScope = "product:admin"
+	ScopePromoAdmin    APIScope = "promo:admin"
+)
+
+// APIClient is a client identified by an API key. Only the key's hash is stored.
+type APIClient struct {
+	ID        string
+	Name      string
+	KeyHash   string
+	Scopes    []APIScope
+	ExpiresAt *time.Time // nil means the key never expires
+	RevokedAt *time.Time
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 of a raw API key, as stored in the database.
+func HashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckActive returns ErrAPIKeyRevoked or ErrAPIKeyExpired when the key can no longer be used at now.
+func (c APIClient) CheckActive(now time.Time) error {
+	if c.RevokedAt != nil && !now.Before(*c.RevokedAt) {
+		return ErrAPIKeyRevoked
+	}
+	if c.ExpiresAt != nil && !now.Before(*c.ExpiresAt) {
+		return ErrAPIKeyExpired
+	}
+	return nil
+}
+
+// HasScope reports whether the client was granted scope.
+func (c APIClient) HasScope(scope APIScope) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// APIKeyRepository is the port for looking up API clients.
+type APIKeyRepository interface {
+	// FindByHash returns the client whose key hashes to keyHash.
+	//
+	// domain.ErrAPIKeyNotFound should be returned when no client has that key.
+	FindByHash(ctx context.Context, keyHash string) (*APIClient, error)
+}
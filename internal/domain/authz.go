@@ -0,0 +1,45 @@
+package domain
+
+import "slices"
+
+// Role is a coarse-grained role carried by a bearer token's "roles" claim.
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleStaff    Role = "staff"
+	RoleAdmin    Role = "admin"
+)
+
+// Policy maps roles to the permissions they grant. Permissions use the same
+// names as API key scopes, so one route requirement covers both.
+type Policy map[Role][]APIScope
+
+// DefaultPolicy is the role policy of the API.
+//
+// Customers may place orders and read their own data (enforced per route);
+// staff run the store; admins may do everything.
+func DefaultPolicy() Policy {
+	staff := []APIScope{
+		ScopeOrderWrite,
+		ScopeOrderManage,
+		ScopeCustomerRead,
+		ScopeCustomerWrite,
+		ScopeStoreAdmin,
+	}
+	return Policy{
+		RoleCustomer: {ScopeOrderWrite},
+		RoleStaff:    staff,
+		RoleAdmin:    append(slices.Clone(staff), ScopeProductAdmin, ScopePromoAdmin),
+	}
+}
+
+// Grants reports whether any of roles grants perm. Unknown roles grant nothing.
+func (p Policy) Grants(roles []string, perm APIScope) bool {
+	for _, r := range roles {
+		if slices.Contains(p[Role(r)], perm) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCustomerID    = errors.New("customer ID must be non-empty")
+	ErrInvalidCustomerName  = errors.New("customer name must be non-empty")
+	ErrInvalidCustomerEmail = errors.New("customer email must be a valid address")
+	ErrInvalidCustomerPhone = errors.New("customer phone must be 8-15 digits, optionally starting with +")
+	ErrCustomerNotFound     = errors.New("customer not found")
+	ErrCustomerEmailTaken   = errors.New("customer email already registered")
+)
+
+type CustomerID string
+
+// Customer is a registered customer who can place orders.
+type Customer struct {
+	ID        CustomerID
+	Name      string
+	Email     string
+	Phone     string // optional
+	CreatedAt time.Time
+}
+
+// NewCustomer builds a valid Customer, normalising the email to lower case.
+func NewCustomer(id CustomerID, name, email, phone string, createdAt time.Time) (*Customer, error) {
+	if id == "" {
+		return nil, ErrInvalidCustomerID
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidCustomerName
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return nil, ErrInvalidCustomerEmail
+	}
+
+	phone = strings.TrimSpace(phone)
+	if phone != "" && !isValidPhone(phone) {
+		return nil, ErrInvalidCustomerPhone
+	}
+
+	return &Customer{
+		ID:        id,
+		Name:      name,
+		Email:     email,
+		Phone:     phone,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+func isValidPhone(s string) bool {
+	digits := 0
+	for i, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '+' && i == 0, r == ' ':
+		default:
+			return false
+		}
+	}
+	return digits >= 8 && digits <= 15
+}
+
+// CustomerRepository is the port for storing customers.
+type CustomerRepository interface {
+	// Create stores a new customer.
+	//
+	// domain.ErrCustomerEmailTaken should be returned when the email is already registered.
+	Create(ctx context.Context, customer *Customer) error
+
+	// GetByID returns the customer with the given ID.
+	//
+	// domain.ErrCustomerNotFound should be returned when no customer can be found.
+	GetByID(ctx context.Context, id CustomerID) (*Customer, error)
+}
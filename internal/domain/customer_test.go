@@ -0,0 +1,49 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+func TestNewCustomer(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		id      domain.CustomerID
+		cName   string
+		email   string
+		phone   string
+		wantErr error
+	}{
+		{name: "valid", id: "c1", cName: "Ada", email: "ada@example.com", phone: "0400 000 000"},
+		{name: "valid without phone", id: "c1", cName: "Ada", email: "ada@example.com"},
+		{name: "missing id", cName: "Ada", email: "ada@example.com", wantErr: domain.ErrInvalidCustomerID},
+		{name: "blank name", id: "c1", cName: "  ", email: "ada@example.com", wantErr: domain.ErrInvalidCustomerName},
+		{name: "bad email", id: "c1", cName: "Ada", email: "ada", wantErr: domain.ErrInvalidCustomerEmail},
+		{name: "display-name email", id: "c1", cName: "Ada", email: "Ada <ada@example.com>", wantErr: domain.ErrInvalidCustomerEmail},
+		{name: "short phone", id: "c1", cName: "Ada", email: "ada@example.com", phone: "123", wantErr: domain.ErrInvalidCustomerPhone},
+		{name: "letters in phone", id: "c1", cName: "Ada", email: "ada@example.com", phone: "0400 ABC 000", wantErr: domain.ErrInvalidCustomerPhone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := domain.NewCustomer(tt.id, tt.cName, tt.email, tt.phone, now)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("NewCustomer() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewCustomer() error = %v, want nil", err)
+			}
+			if c.ID != tt.id || c.Email != tt.email || !c.CreatedAt.Equal(now) {
+				t.Fatalf("NewCustomer() = %+v, want fields copied", c)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidFulfilmentType     = errors.New("fulfilment type must be one of dine_in, takeaway, delivery")
+	ErrInvalidTableNumber        = errors.New("dine-in orders need a table number >= 1")
+	ErrUnexpectedTableNumber     = errors.New("table number is only allowed for dine-in orders")
+	ErrInvalidDeliveryAddress    = errors.New("delivery orders need a complete delivery address")
+	ErrUnexpectedDeliveryAddress = errors.New("delivery address is only allowed for delivery orders")
+	ErrInvalidPostcode           = errors.New("postcode must be 4 digits")
+	ErrDeliveryZoneNotFound      = errors.New("no delivery zone covers this postcode")
+)
+
+// FulfilmentType is how an order gets to the customer.
+type FulfilmentType string
+
+const (
+	FulfilmentDineIn   FulfilmentType = "dine_in"
+	FulfilmentTakeaway FulfilmentType = "takeaway"
+	FulfilmentDelivery FulfilmentType = "delivery"
+)
+
+// DeliveryAddress is a structured Australian street address.
+type DeliveryAddress struct {
+	Line1    string
+	Line2    string // optional
+	Suburb   string
+	State    string
+	Postcode string
+}
+
+// Validate checks that the mandatory address parts are present.
+func (a DeliveryAddress) Validate() error {
+	if strings.TrimSpace(a.Line1) == "" ||
+		strings.TrimSpace(a.Suburb) == "" ||
+		strings.TrimSpace(a.State) == "" {
+		return ErrInvalidDeliveryAddress
+	}
+	if !IsValidPostcode(a.Postcode) {
+		return ErrInvalidPostcode
+	}
+	return nil
+}
+
+// IsValidPostcode reports whether s looks like an Australian postcode.
+func IsValidPostcode(s string) bool {
+	if len(s) != 4 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Fulfilment describes how an order is handed over.
+//
+// TableNumber is only set for dine-in and Address only for delivery.
+type Fulfilment struct {
+	Type        FulfilmentType
+	TableNumber int
+	Address     *DeliveryAddress
+}
+
+// Validate enforces the per-type requirements.
+func (f Fulfilment) Validate() error {
+	switch f.Type {
+	case FulfilmentDineIn:
+		if f.TableNumber < 1 {
+			return ErrInvalidTableNumber
+		}
+		if f.Address != nil {
+			return ErrUnexpectedDeliveryAddress
+		}
+	case FulfilmentTakeaway:
+		if f.TableNumber != 0 {
+			return ErrUnexpectedTableNumber
+		}
+		if f.Address != nil {
+			return ErrUnexpectedDeliveryAddress
+		}
+	case FulfilmentDelivery:
+		if f.TableNumber != 0 {
+			return ErrUnexpectedTableNumber
+		}
+		if f.Address == nil {
+			return ErrInvalidDeliveryAddress
+		}
+		return f.Address.Validate()
+	default:
+		return ErrInvalidFulfilmentType
+	}
+	return nil
+}
+
+// DeliveryZone groups postcodes a store delivers to for a flat fee.
+type DeliveryZone struct {
+	ID   string
+	Name string
+	Fee  Money
+}
+
+// DeliveryZoneRepository is the port for looking up delivery zones.
+type DeliveryZoneRepository interface {
+	// FindZoneForPostcode returns the zone of the given store covering postcode.
+	//
+	// domain.ErrDeliveryZoneNotFound should be returned when the store does not deliver there.
+	FindZoneForPostcode(ctx context.Context, storeID StoreID, postcode string) (*DeliveryZone, error)
+}
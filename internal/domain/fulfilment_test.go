@@ -0,0 +1,133 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+func TestFulfilment_Validate(t *testing.T) {
+	addr := &domain.DeliveryAddress{
+		Line1:    "1 George St",
+		Suburb:   "Sydney",
+		State:    "NSW",
+		Postcode: "2000",
+	}
+
+	tests := []struct {
+		name    string
+		f       domain.Fulfilment
+		wantErr error
+	}{
+		{
+			name: "takeaway",
+			f:    domain.Fulfilment{Type: domain.FulfilmentTakeaway},
+		},
+		{
+			name: "dine-in with table",
+			f:    domain.Fulfilment{Type: domain.FulfilmentDineIn, TableNumber: 4},
+		},
+		{
+			name: "delivery with address",
+			f:    domain.Fulfilment{Type: domain.FulfilmentDelivery, Address: addr},
+		},
+		{
+			name:    "unknown type",
+			f:       domain.Fulfilment{Type: "drone"},
+			wantErr: domain.ErrInvalidFulfilmentType,
+		},
+		{
+			name:    "dine-in without table",
+			f:       domain.Fulfilment{Type: domain.FulfilmentDineIn},
+			wantErr: domain.ErrInvalidTableNumber,
+		},
+		{
+			name:    "takeaway with table",
+			f:       domain.Fulfilment{Type: domain.FulfilmentTakeaway, TableNumber: 2},
+			wantErr: domain.ErrUnexpectedTableNumber,
+		},
+		{
+			name:    "takeaway with address",
+			f:       domain.Fulfilment{Type: domain.FulfilmentTakeaway, Address: addr},
+			wantErr: domain.ErrUnexpectedDeliveryAddress,
+		},
+		{
+			name:    "delivery without address",
+			f:       domain.Fulfilment{Type: domain.FulfilmentDelivery},
+			wantErr: domain.ErrInvalidDeliveryAddress,
+		},
+		{
+			name: "delivery with incomplete address",
+			f: domain.Fulfilment{Type: domain.FulfilmentDelivery, Address: &domain.DeliveryAddress{
+				Line1: "1 George St", Postcode: "2000",
+			}},
+			wantErr: domain.ErrInvalidDeliveryAddress,
+		},
+		{
+			name: "delivery with bad postcode",
+			f: domain.Fulfilment{Type: domain.FulfilmentDelivery, Address: &domain.DeliveryAddress{
+				Line1: "1 George St", Suburb: "Sydney", State: "NSW", Postcode: "20OO",
+			}},
+			wantErr: domain.ErrInvalidPostcode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.f.Validate()
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Validate() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewOrder_CopiesDeliveryAddress(t *testing.T) {
+	addr := &domain.DeliveryAddress{Line1: "1 George St", Suburb: "Sydney", State: "NSW", Postcode: "2000"}
+	items := []domain.OrderItem{{ProductID: "p1", Quantity: 1}}
+
+	order, err := domain.NewOrder("order-1", domain.DefaultStoreID, items, nil,
+		domain.Fulfilment{Type: domain.FulfilmentDelivery, Address: addr})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	addr.Postcode = "9999"
+	if order.Fulfilment.Address.Postcode != "2000" {
+		t.Fatalf("order delivery address was mutated after NewOrder; expected defensive copy")
+	}
+}
+
+func TestPriceOrder(t *testing.T) {
+	products := map[domain.ProductID]domain.Product{
+		"1": {ID: "1", Price: domain.NewMoneyFromFloat(6.5)},
+		"2": {ID: "2", Price: domain.NewMoneyFromFloat(7.0)},
+	}
+	items := []domain.OrderItem{
+		{ProductID: "1", Quantity: 2},
+		{ProductID: "2", Quantity: 1},
+	}
+
+	got, err := domain.PriceOrder(items, products, domain.NewMoneyFromFloat(5))
+	if err != nil {
+		t.Fatalf("PriceOrder() error = %v, want nil", err)
+	}
+
+	want := domain.OrderPricing{
+		Subtotal:    domain.Money(2000),
+		DeliveryFee: domain.Money(500),
+		Total:       domain.Money(2500),
+	}
+	if got != want {
+		t.Fatalf("PriceOrder() = %+v, want %+v", got, want)
+	}
+
+	_, err = domain.PriceOrder([]domain.OrderItem{{ProductID: "3", Quantity: 1}}, products, 0)
+	if !errors.Is(err, domain.ErrProductNotFound) {
+		t.Fatalf("PriceOrder() error = %v, want %v", err, domain.ErrProductNotFound)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"strings"
+	"time"
 )
 
 var (
@@ -15,6 +17,9 @@ var (
 	ErrInvalidCouponCode = errors.New("coupon code cannot be empty string") // if present
 
 	ErrProductNotFound = errors.New("product not found")
+
+	ErrOrderNotFound         = errors.New("order not found")
+	ErrOrderAlreadyCancelled = errors.New("order already cancelled")
 )
 
 // Strongly typed IDs for clarity and type safety.
@@ -49,20 +54,42 @@ type OrderItem struct {
 	Quantity  int
 }
 
+// OrderStatus is where an order is in its lifecycle.
+type OrderStatus string
+
+const (
+	OrderPlaced    OrderStatus = "placed"
+	OrderCancelled OrderStatus = "cancelled"
+)
+
 // Order is a domain aggregate for a placed order.
 type Order struct {
 	ID         OrderID
+	StoreID    StoreID
+	CustomerID *CustomerID // optional, nil for guest orders
 	Items      []OrderItem
 	CouponCode *string // optional
+	Fulfilment Fulfilment
+	Pricing    OrderPricing
+	Status     OrderStatus
+	CreatedAt  time.Time
+	// CancelledAt is set once the order is cancelled.
+	CancelledAt *time.Time
 }
 
 // NewOrder builds a valid Order and enforces basic invariants.
 //
-// It defensively copies the items slice so callers cannot mutate internal state.
-func NewOrder(id OrderID, items []OrderItem, couponCode *string) (*Order, error) {
+// A zero Fulfilment means takeaway. The coupon code, if present, is trimmed of
+// surrounding whitespace, so it is checked and redeemed as the code itself.
+// It defensively copies the items slice and delivery address so callers cannot
+// mutate internal state.
+func NewOrder(id OrderID, storeID StoreID, items []OrderItem, couponCode *string, fulfilment Fulfilment) (*Order, error) {
 	if id == "" {
 		return nil, ErrInvalidOrderID
 	}
+	if storeID == "" {
+		return nil, ErrInvalidStoreID
+	}
 	if len(items) == 0 {
 		return nil, ErrEmptyOrderItems
 	}
@@ -76,13 +103,36 @@ func NewOrder(id OrderID, items []OrderItem, couponCode *string) (*Order, error)
 		}
 	}
 
+	if couponCode != nil {
+		code := strings.TrimSpace(*couponCode)
+		if code == "" {
+			return nil, ErrInvalidCouponCode
+		}
+		couponCode = &code
+	}
+
+	// Orders placed before fulfilment types existed were all takeaway.
+	if fulfilment.Type == "" {
+		fulfilment.Type = FulfilmentTakeaway
+	}
+	if err := fulfilment.Validate(); err != nil {
+		return nil, fmt.Errorf("fulfilment: %w", err)
+	}
+	if fulfilment.Address != nil {
+		addr := *fulfilment.Address
+		fulfilment.Address = &addr
+	}
+
 	itemsCopy := make([]OrderItem, len(items))
 	copy(itemsCopy, items)
 
 	return &Order{
 		ID:         id,
+		StoreID:    storeID,
 		Items:      itemsCopy,
 		CouponCode: couponCode,
+		Fulfilment: fulfilment,
+		Status:     OrderPlaced,
 	}, nil
 }
 
@@ -98,8 +148,32 @@ type ProductRepository interface {
 	// based on the given ID
 	GetProductByID(ctx context.Context, id ProductID) (*Product, error)
 	GetProductByIDs(ctx context.Context, ids []ProductID) (map[ProductID]Product, error)
+
+	// ListProductsByStore returns the products available at the given store,
+	// with the store's price overrides applied.
+	ListProductsByStore(ctx context.Context, storeID StoreID) ([]Product, error)
+	// GetProductByIDsForStore is the store-scoped variant of GetProductByIDs.
+	//
+	// Products that are unavailable at the store are omitted from the result.
+	GetProductByIDsForStore(ctx context.Context, storeID StoreID, ids []ProductID) (map[ProductID]Product, error)
 }
 
 type OrderRepository interface {
+	// Save stores a new order and redeems its coupon code, if any, in the
+	// same transaction.
+	//
+	// domain.ErrCouponExhausted, domain.ErrCouponCustomerLimit or
+	// domain.ErrCouponCustomerRequired should be returned when the coupon's
+	// redemption caps do not allow the order; nothing is stored then.
 	Save(ctx context.Context, order *Order) error
+
+	// Cancel marks the order cancelled at the given time and releases its
+	// coupon redemption, so the code can be used again.
+	//
+	// domain.ErrOrderNotFound should be returned when no order can be found,
+	// and domain.ErrOrderAlreadyCancelled when it was cancelled before.
+	Cancel(ctx context.Context, id OrderID, at time.Time) error
+
+	// ListByCustomer returns the customer's orders, newest first.
+	ListByCustomer(ctx context.Context, customerID CustomerID) ([]Order, error)
 }
@@ -56,7 +56,7 @@ func TestNewOrder_Success(t *testing.T) {
 	}
 	coupon := "PROMO10"
 
-	order, err := domain.NewOrder(orderID, items, &coupon)
+	order, err := domain.NewOrder(orderID, domain.DefaultStoreID, items, &coupon, domain.Fulfilment{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -64,12 +64,18 @@ func TestNewOrder_Success(t *testing.T) {
 	if order.ID != orderID {
 		t.Fatalf("order.ID = %s, want %s", order.ID, orderID)
 	}
+	if order.StoreID != domain.DefaultStoreID {
+		t.Fatalf("order.StoreID = %s, want %s", order.StoreID, domain.DefaultStoreID)
+	}
 	if len(order.Items) != len(items) {
 		t.Fatalf("order.Items len = %d, want %d", len(order.Items), len(items))
 	}
 	if order.CouponCode == nil || *order.CouponCode != coupon {
 		t.Fatalf("order.CouponCode = %v, want %s", order.CouponCode, coupon)
 	}
+	if order.Fulfilment.Type != domain.FulfilmentTakeaway {
+		t.Fatalf("order.Fulfilment.Type = %s, want default %s", order.Fulfilment.Type, domain.FulfilmentTakeaway)
+	}
 
 	// Ensure NewOrder defensively copies the items slice.
 	items[0].Quantity = 999
@@ -78,13 +84,29 @@ func TestNewOrder_Success(t *testing.T) {
 	}
 }
 
+func TestNewOrder_TrimsCouponCode(t *testing.T) {
+	items := []domain.OrderItem{{ProductID: "p1", Quantity: 1}}
+	coupon := " BIRTHDAY\t"
+
+	order, err := domain.NewOrder("order-1", domain.DefaultStoreID, items, &coupon, domain.Fulfilment{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if order.CouponCode == nil || *order.CouponCode != "BIRTHDAY" {
+		t.Fatalf("order.CouponCode = %v, want BIRTHDAY", order.CouponCode)
+	}
+}
+
 func TestNewOrder_ValidationErrors(t *testing.T) {
+	blankCoupon := " \t"
 	tests := []struct {
-		name      string
-		orderID   domain.OrderID
-		items     []domain.OrderItem
-		coupon    *string
-		wantError bool
+		name       string
+		orderID    domain.OrderID
+		storeID    domain.StoreID
+		items      []domain.OrderItem
+		coupon     *string
+		fulfilment domain.Fulfilment
+		wantError  bool
 	}{
 		{
 			name:    "empty order id",
@@ -94,15 +116,26 @@ func TestNewOrder_ValidationErrors(t *testing.T) {
 			},
 			wantError: true,
 		},
+		{
+			name:    "empty store id",
+			orderID: "order-1",
+			storeID: "",
+			items: []domain.OrderItem{
+				{ProductID: "p1", Quantity: 1},
+			},
+			wantError: true,
+		},
 		{
 			name:      "no items",
 			orderID:   "order-1",
+			storeID:   domain.DefaultStoreID,
 			items:     nil,
 			wantError: true,
 		},
 		{
 			name:    "item with empty product id",
 			orderID: "order-1",
+			storeID: domain.DefaultStoreID,
 			items: []domain.OrderItem{
 				{ProductID: "", Quantity: 1},
 			},
@@ -111,14 +144,36 @@ func TestNewOrder_ValidationErrors(t *testing.T) {
 		{
 			name:    "item with invalid quantity",
 			orderID: "order-1",
+			storeID: domain.DefaultStoreID,
 			items: []domain.OrderItem{
 				{ProductID: "p1", Quantity: 0},
 			},
 			wantError: true,
 		},
+		{
+			name:    "dine-in without table",
+			orderID: "order-2",
+			storeID: domain.DefaultStoreID,
+			items: []domain.OrderItem{
+				{ProductID: "p1", Quantity: 1},
+			},
+			fulfilment: domain.Fulfilment{Type: domain.FulfilmentDineIn},
+			wantError:  true,
+		},
+		{
+			name:    "blank coupon",
+			orderID: "order-2",
+			storeID: domain.DefaultStoreID,
+			items: []domain.OrderItem{
+				{ProductID: "p1", Quantity: 1},
+			},
+			coupon:    &blankCoupon,
+			wantError: true,
+		},
 		{
 			name:    "valid no coupon",
 			orderID: "order-3",
+			storeID: domain.DefaultStoreID,
 			items: []domain.OrderItem{
 				{ProductID: "p5", Quantity: 5},
 			},
@@ -132,7 +187,7 @@ func TestNewOrder_ValidationErrors(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			_, err := domain.NewOrder(tt.orderID, tt.items, tt.coupon)
+			_, err := domain.NewOrder(tt.orderID, tt.storeID, tt.items, tt.coupon, tt.fulfilment)
 			if (err != nil) != tt.wantError {
 				t.Fatalf("NewOrder() error = %v, wantError = %v", err, tt.wantError)
 			}
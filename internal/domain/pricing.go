@@ -0,0 +1,31 @@
+package domain
+
+import "fmt"
+
+// OrderPricing is the price breakdown of an order.
+type OrderPricing struct {
+	Subtotal    Money
+	DeliveryFee Money
+	Total       Money
+}
+
+// PriceOrder computes the price breakdown for items using the given products
+// (already resolved with any store-specific price) plus a delivery fee.
+//
+// domain.ErrProductNotFound is returned if an item has no matching product.
+func PriceOrder(items []OrderItem, products map[ProductID]Product, deliveryFee Money) (OrderPricing, error) {
+	var subtotal Money
+	for _, item := range items {
+		p, ok := products[item.ProductID]
+		if !ok {
+			return OrderPricing{}, fmt.Errorf("price product %s: %w", item.ProductID, ErrProductNotFound)
+		}
+		subtotal += p.Price * Money(item.Quantity)
+	}
+
+	return OrderPricing{
+		Subtotal:    subtotal,
+		DeliveryFee: deliveryFee,
+		Total:       subtotal + deliveryFee,
+	}, nil
+}
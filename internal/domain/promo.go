@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode"
+)
+
+var (
+	ErrUnknownCouponCode = errors.New("coupon code is not recognised")
+	ErrCouponLockedOut   = errors.New("too many invalid coupon attempts")
+
+	ErrCouponExhausted        = errors.New("coupon code has reached its redemption limit")
+	ErrCouponCustomerLimit    = errors.New("customer has reached the redemption limit for this coupon code")
+	ErrCouponCustomerRequired = errors.New("coupon code can only be redeemed by registered customers")
+
+	ErrNoActivePromoCodes = errors.New("no active promo code set")
+	// ErrMalformedPromoCodes is returned for code sets that cannot be the
+	// output of cmd/promo-loader, such as empty sets or lines that are not
+	// codes.
+	ErrMalformedPromoCodes = errors.New("malformed promo codes file")
+)
+
+// IsPromoCode reports whether s can be a code of some campaign: printable
+// characters other than spaces. Lengths and alphabets are up to the rules
+// cmd/promo-loader ran with.
+func IsPromoCode(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// CouponLockoutError reports that coupon attempts are refused until Until.
+// It matches ErrCouponLockedOut with errors.Is.
+type CouponLockoutError struct {
+	Until time.Time
+}
+
+func (e *CouponLockoutError) Error() string {
+	return fmt.Sprintf("%s, locked until %s", ErrCouponLockedOut, e.Until.UTC().Format(time.RFC3339))
+}
+
+func (e *CouponLockoutError) Unwrap() error {
+	return ErrCouponLockedOut
+}
+
+// PromoCodeValidator is the port for checking promo codes.
+type PromoCodeValidator interface {
+	// IsValid reports whether code is a known promo code. An unknown code is
+	// not an error.
+	IsValid(ctx context.Context, code string) (bool, error)
+}
+
+// CouponAttemptTracker is the port for counting failed coupon attempts. Keys
+// identify who made the attempt, such as an API client or an IP address.
+type CouponAttemptTracker interface {
+	// LockedUntil returns when the lockout on key ends, or the zero time
+	// when key is not locked out.
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+
+	// RecordFailure counts a failed attempt for key. When it pushes key over
+	// the threshold, it returns when the new lockout ends; otherwise the zero
+	// time.
+	RecordFailure(ctx context.Context, key string) (time.Time, error)
+}
+
+// PromoCodeVersion describes one imported version of the promo code set.
+type PromoCodeVersion struct {
+	Version     int64
+	Source      string // where the codes came from, e.g. the loader output path
+	CodeCount   int64
+	ImportedAt  time.Time
+	ActivatedAt *time.Time // nil until the version is made active
+}
+
+// PromoCodeSetStatus describes the promo code set the API validates against.
+type PromoCodeSetStatus struct {
+	Source    string // "file", "index" or "postgres"
+	Location  string // file path or import source
+	Version   string // content checksum for files and indexes, version number in Postgres
+	CodeCount int64
+	LoadedAt  time.Time
+	// LastError is why the latest reload was rejected; empty when it succeeded.
+	LastError     string
+	LastCheckedAt time.Time
+}
+
+// PromoCodeSetReporter is implemented by promo code validators that can
+// describe the set they serve.
+type PromoCodeSetReporter interface {
+	// CodeSetStatus returns the status of the set in use.
+	//
+	// domain.ErrNoActivePromoCodes should be returned when there is none.
+	CodeSetStatus(ctx context.Context) (*PromoCodeSetStatus, error)
+}
@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidStoreID        = errors.New("store ID must be non-empty")
+	ErrStoreNotFound         = errors.New("store not found")
+	ErrStoreClosed           = errors.New("store is not accepting orders")
+	ErrStoreScheduleNotFound = errors.New("store schedule not found")
+)
+
+// StoreID identifies a physical shop / location.
+type StoreID string
+
+// DefaultStoreID is used when a client does not specify a store, which keeps
+// single-location clients working unchanged.
+const DefaultStoreID StoreID = "default"
+
+// Store is a location with its own menu, prices and opening hours.
+type Store struct {
+	ID   StoreID
+	Name string
+}
+
+// StoreStatusReason explains why the store is (or is not) accepting orders.
+type StoreStatusReason string
+
+const (
+	StoreOpen                StoreStatusReason = "open"
+	StoreOutsideOpeningHours StoreStatusReason = "outside_opening_hours"
+	StoreHolidayClosure      StoreStatusReason = "holiday_closure"
+	StoreBlackout            StoreStatusReason = "blackout"
+	StoreOrderingPaused      StoreStatusReason = "ordering_paused"
+)
+
+// OpeningPeriod is a single opening window on a given weekday.
+//
+// Opens and Closes are offsets from local midnight. A period whose Closes is
+// not after Opens runs past midnight into the following day.
+type OpeningPeriod struct {
+	Weekday time.Weekday
+	Opens   time.Duration
+	Closes  time.Duration
+}
+
+// Closure is a full-day closure (e.g. a public holiday) in the store's timezone.
+type Closure struct {
+	Date   string // YYYY-MM-DD
+	Reason string
+}
+
+// Blackout is an absolute time window during which ordering is disabled.
+type Blackout struct {
+	Start  time.Time
+	End    time.Time
+	Reason string
+}
+
+// StoreSchedule holds everything needed to decide whether orders are accepted.
+type StoreSchedule struct {
+	Location       *time.Location
+	OpeningHours   []OpeningPeriod
+	Closures       []Closure
+	Blackouts      []Blackout
+	OrderingPaused bool
+	PauseReason    string
+}
+
+// StoreStatus is the outcome of evaluating a StoreSchedule at a point in time.
+type StoreStatus struct {
+	AcceptingOrders bool
+	Reason          StoreStatusReason
+	Message         string
+	LocalTime       time.Time
+}
+
+// StatusAt evaluates the schedule at t.
+//
+// Checks are applied from most to least specific: a manual pause wins over
+// blackouts, which win over holiday closures and regular opening hours.
+func (s StoreSchedule) StatusAt(t time.Time) StoreStatus {
+	loc := s.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+
+	closed := func(reason StoreStatusReason, msg string) StoreStatus {
+		return StoreStatus{Reason: reason, Message: msg, LocalTime: local}
+	}
+
+	if s.OrderingPaused {
+		return closed(StoreOrderingPaused, s.PauseReason)
+	}
+
+	for _, b := range s.Blackouts {
+		if !t.Before(b.Start) && t.Before(b.End) {
+			return closed(StoreBlackout, b.Reason)
+		}
+	}
+
+	today := local.Format(time.DateOnly)
+	for _, c := range s.Closures {
+		if c.Date == today {
+			return closed(StoreHolidayClosure, c.Reason)
+		}
+	}
+
+	if !s.withinOpeningHours(local) {
+		return closed(StoreOutsideOpeningHours, "")
+	}
+
+	return StoreStatus{AcceptingOrders: true, Reason: StoreOpen, LocalTime: local}
+}
+
+func (s StoreSchedule) withinOpeningHours(local time.Time) bool {
+	// Wall-clock offset rather than local.Sub(midnight) so DST changes don't shift hours.
+	sinceMidnight := time.Duration(local.Hour())*time.Hour +
+		time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second
+	yesterday := (local.Weekday() + 6) % 7
+
+	for _, p := range s.OpeningHours {
+		overnight := p.Closes <= p.Opens
+
+		switch p.Weekday {
+		case local.Weekday():
+			if sinceMidnight >= p.Opens && (overnight || sinceMidnight < p.Closes) {
+				return true
+			}
+		case yesterday:
+			// Tail end of a period that started yesterday and runs past midnight.
+			if overnight && sinceMidnight < p.Closes {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// StoreRepository is the port for reading stores and their schedules.
+type StoreRepository interface {
+	// GetStore returns the store with the given ID.
+	//
+	// domain.ErrStoreNotFound should be returned when no store can be found.
+	GetStore(ctx context.Context, id StoreID) (*Store, error)
+
+	// GetSchedule returns the schedule of the given store.
+	//
+	// domain.ErrStoreScheduleNotFound should be returned when no schedule is configured.
+	GetSchedule(ctx context.Context, id StoreID) (*StoreSchedule, error)
+	SetOrderingPaused(ctx context.Context, id StoreID, paused bool, reason string) error
+}
@@ -0,0 +1,137 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+func TestStoreSchedule_StatusAt(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Skipf("tzdata not available: %v", err)
+	}
+
+	// 2025-01-15 is a Wednesday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 1, day, hour, minute, 0, 0, sydney)
+	}
+
+	base := domain.StoreSchedule{
+		Location: sydney,
+		OpeningHours: []domain.OpeningPeriod{
+			{Weekday: time.Wednesday, Opens: 9 * time.Hour, Closes: 17 * time.Hour},
+			// Friday late night trading until 2am Saturday
+			{Weekday: time.Friday, Opens: 18 * time.Hour, Closes: 2 * time.Hour},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		mutate     func(s *domain.StoreSchedule)
+		at         time.Time
+		wantOpen   bool
+		wantReason domain.StoreStatusReason
+	}{
+		{
+			name:       "within opening hours",
+			at:         at(15, 12, 0),
+			wantOpen:   true,
+			wantReason: domain.StoreOpen,
+		},
+		{
+			name:       "before opening",
+			at:         at(15, 8, 59),
+			wantReason: domain.StoreOutsideOpeningHours,
+		},
+		{
+			name:       "closing time is exclusive",
+			at:         at(15, 17, 0),
+			wantReason: domain.StoreOutsideOpeningHours,
+		},
+		{
+			name:       "no hours on this weekday",
+			at:         at(16, 12, 0),
+			wantReason: domain.StoreOutsideOpeningHours,
+		},
+		{
+			name:       "overnight period before midnight",
+			at:         at(17, 23, 0),
+			wantOpen:   true,
+			wantReason: domain.StoreOpen,
+		},
+		{
+			name:       "overnight period after midnight",
+			at:         at(18, 1, 30),
+			wantOpen:   true,
+			wantReason: domain.StoreOpen,
+		},
+		{
+			name:       "overnight period closed",
+			at:         at(18, 2, 0),
+			wantReason: domain.StoreOutsideOpeningHours,
+		},
+		{
+			name:       "evaluated in store timezone",
+			at:         time.Date(2025, 1, 15, 1, 0, 0, 0, time.UTC), // 12:00 in Sydney (AEDT)
+			wantOpen:   true,
+			wantReason: domain.StoreOpen,
+		},
+		{
+			name: "holiday closure",
+			mutate: func(s *domain.StoreSchedule) {
+				s.Closures = []domain.Closure{{Date: "2025-01-15", Reason: "Staff day"}}
+			},
+			at:         at(15, 12, 0),
+			wantReason: domain.StoreHolidayClosure,
+		},
+		{
+			name: "blackout window",
+			mutate: func(s *domain.StoreSchedule) {
+				s.Blackouts = []domain.Blackout{{Start: at(15, 11, 0), End: at(15, 13, 0)}}
+			},
+			at:         at(15, 12, 0),
+			wantReason: domain.StoreBlackout,
+		},
+		{
+			name: "blackout end is exclusive",
+			mutate: func(s *domain.StoreSchedule) {
+				s.Blackouts = []domain.Blackout{{Start: at(15, 11, 0), End: at(15, 12, 0)}}
+			},
+			at:         at(15, 12, 0),
+			wantOpen:   true,
+			wantReason: domain.StoreOpen,
+		},
+		{
+			name: "paused wins over everything",
+			mutate: func(s *domain.StoreSchedule) {
+				s.OrderingPaused = true
+				s.PauseReason = "kitchen overloaded"
+				s.Closures = []domain.Closure{{Date: "2025-01-15"}}
+			},
+			at:         at(15, 12, 0),
+			wantReason: domain.StoreOrderingPaused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := base
+			if tt.mutate != nil {
+				tt.mutate(&s)
+			}
+
+			got := s.StatusAt(tt.at)
+			if got.AcceptingOrders != tt.wantOpen {
+				t.Errorf("AcceptingOrders = %v, want %v", got.AcceptingOrders, tt.wantOpen)
+			}
+			if got.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", got.Reason, tt.wantReason)
+			}
+			if got.LocalTime.Location() != sydney {
+				t.Errorf("LocalTime location = %v, want %v", got.LocalTime.Location(), sydney)
+			}
+		})
+	}
+}
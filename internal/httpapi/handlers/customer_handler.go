@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/M-Arthur/order-food-api/internal/api"
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/shared"
+	"github.com/M-Arthur/order-food-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// CustomerHandler is the HTTP adapter for customers and their order history.
+type CustomerHandler struct {
+	customerSvc service.CustomerService
+}
+
+func NewCustomerHandler(customerSvc service.CustomerService) *CustomerHandler {
+	return &CustomerHandler{
+		customerSvc: customerSvc,
+	}
+}
+
+// CreateCustomer handles POST /customer.
+//
+// @Summary Register a customer
+// @Description Creates a customer that orders can be linked to
+// @Tags customer
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param customer body api.CustomerReqDTO true "Customer details"
+// @Success 201 {object} api.CustomerDTO
+// @Failure 400 {object} shared.ErrorResponse
+// @Failure 403 {object} shared.Problem
+// @Failure 409 {object} shared.ErrorResponse
+// @Failure 422 {object} shared.ErrorResponse
+// @Failure 500 {object} shared.ErrorResponse
+// @Router /customer [post]
+func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := zerolog.Ctx(ctx)
+
+	var req api.CustomerReqDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn().Err(err).Msg("invalid JSON for customer request")
+		shared.WriteJSONError(w, r, http.StatusBadRequest, "Invalid input")
+		return
+	}
+
+	customer, err := h.customerSvc.CreateCustomer(ctx, service.CreateCustomerInput{
+		Name:  req.Name,
+		Email: req.Email,
+		Phone: req.Phone,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidCustomerName),
+			errors.Is(err, domain.ErrInvalidCustomerEmail),
+			errors.Is(err, domain.ErrInvalidCustomerPhone):
+			logger.Warn().Err(err).Msg("customer validation error")
+			shared.WriteJSONError(w, r, http.StatusUnprocessableEntity, err.Error())
+		case errors.Is(err, domain.ErrCustomerEmailTaken):
+			logger.Info().Err(err).Msg("customer email already registered")
+			shared.WriteJSONError(w, r, http.StatusConflict, "email already registered")
+		default:
+			logger.Error().Err(err).Msg("failed to create customer")
+			shared.WriteJSONError(w, r, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	shared.WriteJSON(w, r, http.StatusCreated, api.MapDomainCustomerToDTO(customer))
+}
+
+// GetCustomer handles GET /customer/{customerId}.
+//
+// @Summary Find customer by ID
+// @Tags customer
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param customerId path string true "ID of the customer"
+// @Success 200 {object} api.CustomerDTO
+// @Failure 403 {object} shared.Problem
+// @Failure 404 {object} shared.ErrorResponse
+// @Failure 500 {object} shared.ErrorResponse
+// @Router /customer/{customerId} [get]
+func (h *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := zerolog.Ctx(ctx)
+	customerID := domain.CustomerID(chi.URLParam(r, "customerId"))
+
+	customer, err := h.customerSvc.GetCustomer(ctx, customerID)
+	if errors.Is(err, domain.ErrCustomerNotFound) {
+		logger.Warn().Str("customerId", string(customerID)).Msg("customer not found")
+		shared.WriteJSONError(w, r, http.StatusNotFound, "Customer not found")
+		return
+	}
+	if err != nil {
+		logger.Error().Str("customerId", string(customerID)).Err(err).Msg("failed to load customer")
+		shared.WriteJSONError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	shared.WriteJSON(w, r, http.StatusOK, api.MapDomainCustomerToDTO(customer))
+}
+
+// ListOrders handles GET /customer/{customerId}/order.
+//
+// @Summary Customer order history
+// @Description Lists the customer's past orders, newest first
+// @Tags customer
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param customerId path string true "ID of the customer"
+// @Success 200 {array} api.OrderDTO
+// @Failure 403 {object} shared.Problem
+// @Failure 404 {object} shared.ErrorResponse
+// @Failure 500 {object} shared.ErrorResponse
+// @Router /customer/{customerId}/order [get]
+func (h *CustomerHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := zerolog.Ctx(ctx)
+	customerID := domain.CustomerID(chi.URLParam(r, "customerId"))
+
+	orders, products, err := h.customerSvc.ListOrders(ctx, customerID)
+	if errors.Is(err, domain.ErrCustomerNotFound) {
+		logger.Warn().Str("customerId", string(customerID)).Msg("customer not found")
+		shared.WriteJSONError(w, r, http.StatusNotFound, "Customer not found")
+		return
+	}
+	if err != nil {
+		logger.Error().Str("customerId", string(customerID)).Err(err).Msg("failed to list customer orders")
+		shared.WriteJSONError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	shared.WriteJSON(w, r, http.StatusOK, api.MapDomainOrdersToDTO(orders, products))
+}
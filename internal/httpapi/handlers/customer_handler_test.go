@@ -0,0 +1,179 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/api"
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/handlers"
+	"github.com/M-Arthur/order-food-api/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// stubCustomerService implements service.CustomerService for tests
+type stubCustomerService struct {
+	customer *domain.Customer
+	orders   []domain.Order
+	products map[domain.StoreID]map[domain.ProductID]domain.Product
+	err      error
+
+	gotID    domain.CustomerID
+	gotInput service.CreateCustomerInput
+}
+
+func (s *stubCustomerService) CreateCustomer(_ context.Context, in service.CreateCustomerInput) (*domain.Customer, error) {
+	s.gotInput = in
+	return s.customer, s.err
+}
+
+func (s *stubCustomerService) GetCustomer(_ context.Context, id domain.CustomerID) (*domain.Customer, error) {
+	s.gotID = id
+	return s.customer, s.err
+}
+
+func (s *stubCustomerService) ListOrders(_ context.Context, id domain.CustomerID) ([]domain.Order, map[domain.StoreID]map[domain.ProductID]domain.Product, error) {
+	s.gotID = id
+	return s.orders, s.products, s.err
+}
+
+// complie-time safety
+var _ service.CustomerService = (*stubCustomerService)(nil)
+
+func withCustomerID(req *http.Request, id string) *http.Request {
+	// Simulate chi param extraction
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("customerId", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestCustomerHandler_CreateCustomer(t *testing.T) {
+	tests := []struct {
+		name       string
+		svcErr     error
+		wantStatus int
+	}{
+		{name: "created", wantStatus: http.StatusCreated},
+		{name: "invalid email", svcErr: domain.ErrInvalidCustomerEmail, wantStatus: http.StatusUnprocessableEntity},
+		{name: "email taken", svcErr: domain.ErrCustomerEmailTaken, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &stubCustomerService{
+				customer: &domain.Customer{ID: "c1", Name: "Ada", Email: "ada@example.com"},
+				err:      tt.svcErr,
+			}
+			h := handlers.NewCustomerHandler(svc)
+
+			body, err := json.Marshal(api.CustomerReqDTO{Name: "Ada", Email: "ada@example.com"})
+			if err != nil {
+				t.Fatalf("failed to marshal request dto: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/customer", bytes.NewReader(body))
+			rr := httptest.NewRecorder()
+
+			h.CreateCustomer(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body=%q", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if svc.gotInput.Email != "ada@example.com" {
+				t.Fatalf("CreateCustomer() got email %q, want %q", svc.gotInput.Email, "ada@example.com")
+			}
+		})
+	}
+}
+
+func TestCustomerHandler_GetCustomer_NotFound(t *testing.T) {
+	h := handlers.NewCustomerHandler(&stubCustomerService{err: domain.ErrCustomerNotFound})
+
+	req := withCustomerID(httptest.NewRequest(http.MethodGet, "/customer/ghost", nil), "ghost")
+	rr := httptest.NewRecorder()
+
+	h.GetCustomer(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestCustomerHandler_ListOrders(t *testing.T) {
+	customerID := domain.CustomerID("c1")
+	svc := &stubCustomerService{
+		orders: []domain.Order{
+			{
+				ID:         "o2",
+				StoreID:    domain.DefaultStoreID,
+				CustomerID: &customerID,
+				Items:      []domain.OrderItem{{ProductID: "10", Quantity: 1}},
+				CreatedAt:  time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC),
+			},
+			{
+				ID:         "o1",
+				StoreID:    "cbd",
+				CustomerID: &customerID,
+				Items:      []domain.OrderItem{{ProductID: "11", Quantity: 2}},
+				CreatedAt:  time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		products: map[domain.StoreID]map[domain.ProductID]domain.Product{
+			domain.DefaultStoreID: {
+				"10": {ID: "10", Name: "Chicken Waffle", Price: domain.NewMoneyFromFloat(6.5)},
+			},
+			"cbd": {
+				"10": {ID: "10", Name: "Chicken Waffle", Price: domain.NewMoneyFromFloat(7.5)},
+				"11": {ID: "11", Name: "Fries", Price: domain.NewMoneyFromFloat(4)},
+			},
+		},
+	}
+	h := handlers.NewCustomerHandler(svc)
+
+	req := withCustomerID(httptest.NewRequest(http.MethodGet, "/customer/c1/order", nil), "c1")
+	rr := httptest.NewRecorder()
+
+	h.ListOrders(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body=%q", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if svc.gotID != customerID {
+		t.Fatalf("ListOrders() called for %q, want %q", svc.gotID, customerID)
+	}
+
+	var got []api.OrderDTO
+	if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "o2" || got[1].ID != "o1" {
+		t.Fatalf("got %+v, want orders o2, o1", got)
+	}
+	if got[0].CustomerID != "c1" || got[0].CreatedAt != "2025-03-02T12:00:00Z" {
+		t.Errorf("got[0] = %+v, want customerId c1 and createdAt set", got[0])
+	}
+	if len(got[0].Products) != 1 || got[0].Products[0].ID != "10" || got[0].Products[0].Price != 6.5 {
+		t.Errorf("got[0].Products = %+v, want only product 10 at the default store's price", got[0].Products)
+	}
+	if len(got[1].Products) != 1 || got[1].Products[0].ID != "11" {
+		t.Errorf("got[1].Products = %+v, want only product 11", got[1].Products)
+	}
+}
+
+func TestCustomerHandler_ListOrders_NotFound(t *testing.T) {
+	h := handlers.NewCustomerHandler(&stubCustomerService{err: domain.ErrCustomerNotFound})
+
+	req := withCustomerID(httptest.NewRequest(http.MethodGet, "/customer/ghost/order", nil), "ghost")
+	rr := httptest.NewRecorder()
+
+	h.ListOrders(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
@@ -3,15 +3,25 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/M-Arthur/order-food-api/internal/api"
 	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/middleware"
 	"github.com/M-Arthur/order-food-api/internal/httpapi/shared"
 	"github.com/M-Arthur/order-food-api/internal/service"
+	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog"
 )
 
+// CouponLockedOutProblem is the problem type returned while a caller is
+// locked out of coupons, so clients and support can tell it apart from
+// ordinary rate limiting.
+const CouponLockedOutProblem = "urn:order-food-api:problem:coupon-locked-out"
+
 type OrderHandler struct {
 	orderSvc service.OrderService
 }
@@ -24,8 +34,9 @@ func NewOrderHandler(orderSvc service.OrderService) *OrderHandler {
 
 // PlaceOrder handles POST /order.
 //
-// Promo code validation will be added later; for now, we accept couponCode
-// but only validate shape / items.
+// An unknown couponCode is rejected with 422. Callers that enter too many
+// unknown codes are locked out of coupons for a while and get 429 for any
+// order with a coupon until the lockout ends.
 //
 //	@Summary		Place an order
 //	@Description	Place a new order in the store
@@ -33,10 +44,14 @@ func NewOrderHandler(orderSvc service.OrderService) *OrderHandler {
 //	@Accept		json
 //	@Produce		json
 //	@Security		ApiKeyAuth
+//	@Security		BearerAuth
 //	@Param order body api.OrderReqDTO true "Order request"
 //	@Success		200 {object} api.OrderDTO
 //	@Failure		400 {object} shared.ErrorResponse
+//	@Failure		403 {object} shared.Problem
+//	@Failure		409 {object} shared.ErrorResponse
 //	@Failure		422 {object} shared.ErrorResponse
+//	@Failure		429 {object} shared.Problem
 //	@Router		 /order [post]
 func (h *OrderHandler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -64,13 +79,89 @@ func (h *OrderHandler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orders, products, err := h.orderSvc.CreateOrder(ctx, payload.Items, payload.CouponCode)
+	// Customers order for themselves; only staff, admins and API clients
+	// may name the customer of an order.
+	if p, ok := middleware.PrincipalFromContext(ctx); ok && p.IsCustomer() {
+		if payload.CustomerID != nil && string(*payload.CustomerID) != p.ID {
+			logger.Warn().Str("customer_id", string(*payload.CustomerID)).Str("principal_id", p.ID).
+				Msg("order for another customer")
+			shared.WriteProblem(w, r, http.StatusForbidden, "", "customerId does not match the authenticated customer")
+			return
+		}
+		id := domain.CustomerID(p.ID)
+		payload.CustomerID = &id
+	}
+
+	orders, products, err := h.orderSvc.CreateOrder(ctx, service.CreateOrderInput{
+		StoreID:    payload.StoreID,
+		CustomerID: payload.CustomerID,
+		Items:      payload.Items,
+		CouponCode: payload.CouponCode,
+		Fulfilment: payload.Fulfilment,
+		AttemptKey: middleware.ClientOrIPKey(r),
+	})
 	if err != nil {
+		if errors.Is(err, domain.ErrStoreNotFound) {
+			logger.Warn().Err(err).Str("storeId", string(payload.StoreID)).Msg("unknown store in order")
+			shared.WriteJSONError(w, r, http.StatusBadRequest, "invalid store")
+			return
+		}
+		if errors.Is(err, domain.ErrCustomerNotFound) {
+			logger.Warn().Err(err).Msg("unknown customer in order")
+			shared.WriteJSONError(w, r, http.StatusBadRequest, "invalid customer")
+			return
+		}
 		if errors.Is(err, domain.ErrProductNotFound) {
 			logger.Warn().Err(err).Msg("unknown product in order items")
 			shared.WriteJSONError(w, r, http.StatusBadRequest, "invalid product in items")
 			return
 		}
+		var lockout *domain.CouponLockoutError
+		if errors.As(err, &lockout) {
+			logger.Warn().Err(err).
+				Str("attempt_key", middleware.ClientOrIPKey(r)).
+				Time("locked_until", lockout.Until).
+				Msg("coupon attempts locked out")
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(lockout.Until)))
+			shared.WriteProblem(w, r, http.StatusTooManyRequests, CouponLockedOutProblem,
+				"too many invalid coupon attempts, coupons are locked until "+lockout.Until.UTC().Format(time.RFC3339))
+			return
+		}
+		if errors.Is(err, domain.ErrCouponExhausted) {
+			logger.Info().Err(err).Msg("coupon redemption limit reached")
+			shared.WriteJSONError(w, r, http.StatusConflict, domain.ErrCouponExhausted.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrCouponCustomerLimit) {
+			logger.Info().Err(err).Msg("customer coupon redemption limit reached")
+			shared.WriteJSONError(w, r, http.StatusConflict, domain.ErrCouponCustomerLimit.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrCouponCustomerRequired) {
+			logger.Info().Err(err).Msg("guest order with customer-only coupon")
+			shared.WriteJSONError(w, r, http.StatusUnprocessableEntity, domain.ErrCouponCustomerRequired.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidCouponCode) {
+			logger.Info().Err(err).Msg("blank coupon code in order")
+			shared.WriteJSONError(w, r, http.StatusUnprocessableEntity, domain.ErrInvalidCouponCode.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrUnknownCouponCode) {
+			logger.Warn().Err(err).Str("attempt_key", middleware.ClientOrIPKey(r)).Msg("invalid coupon attempt")
+			shared.WriteJSONError(w, r, http.StatusUnprocessableEntity, "invalid coupon code")
+			return
+		}
+		if errors.Is(err, domain.ErrDeliveryZoneNotFound) {
+			logger.Info().Err(err).Msg("delivery postcode outside delivery zones")
+			shared.WriteJSONError(w, r, http.StatusUnprocessableEntity, "delivery is not available for this postcode")
+			return
+		}
+		if errors.Is(err, domain.ErrStoreClosed) {
+			logger.Info().Err(err).Msg("order rejected, store not accepting orders")
+			shared.WriteJSONError(w, r, http.StatusConflict, "store is not accepting orders")
+			return
+		}
 
 		logger.Error().Err(err).Msg("internal server eror")
 		shared.WriteJSONError(w, r, http.StatusInternalServerError, "internal server error")
@@ -80,3 +171,56 @@ func (h *OrderHandler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 	resp := api.MapDomainOrderToDTO(orders, products)
 	shared.WriteJSON(w, r, http.StatusOK, resp)
 }
+
+// CancelOrder handles POST /order/{orderId}/cancel.
+//
+//	@Summary		Cancel an order
+//	@Description	Cancels a placed order and releases its coupon redemption
+//	@Tags			order
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Param			orderId path string true "ID of the order"
+//	@Success		200 {object} api.OrderStatusDTO
+//	@Failure		403 {object} shared.Problem
+//	@Failure		404 {object} shared.ErrorResponse
+//	@Failure		409 {object} shared.ErrorResponse
+//	@Failure		500 {object} shared.ErrorResponse
+//	@Router		 /order/{orderId}/cancel [post]
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := zerolog.Ctx(ctx)
+	orderID := domain.OrderID(chi.URLParam(r, "orderId"))
+
+	err := h.orderSvc.CancelOrder(ctx, orderID)
+	if errors.Is(err, domain.ErrOrderNotFound) {
+		logger.Warn().Str("orderId", string(orderID)).Msg("order not found")
+		shared.WriteJSONError(w, r, http.StatusNotFound, "Order not found")
+		return
+	}
+	if errors.Is(err, domain.ErrOrderAlreadyCancelled) {
+		logger.Info().Str("orderId", string(orderID)).Msg("order already cancelled")
+		shared.WriteJSONError(w, r, http.StatusConflict, "order already cancelled")
+		return
+	}
+	if err != nil {
+		logger.Error().Str("orderId", string(orderID)).Err(err).Msg("failed to cancel order")
+		shared.WriteJSONError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	logger.Info().Str("orderId", string(orderID)).Msg("order cancelled")
+	shared.WriteJSON(w, r, http.StatusOK, api.OrderStatusDTO{
+		ID:     string(orderID),
+		Status: string(domain.OrderCancelled),
+	})
+}
+
+// retryAfterSeconds returns the whole seconds until t, at least 1.
+func retryAfterSeconds(t time.Time) int {
+	secs := int(math.Ceil(time.Until(t).Seconds()))
+	if secs < 1 {
+		return 1
+	}
+	return secs
+}
@@ -5,24 +5,36 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/M-Arthur/order-food-api/internal/api"
 	"github.com/M-Arthur/order-food-api/internal/domain"
 	"github.com/M-Arthur/order-food-api/internal/httpapi/handlers"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/middleware"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/shared"
 	"github.com/M-Arthur/order-food-api/internal/service"
+	"github.com/go-chi/chi/v5"
 )
 
 type stubOrderService struct {
 	order    *domain.Order
 	products []domain.Product
 	err      error
+
+	gotInput service.CreateOrderInput
+
+	cancelErr   error
+	cancelledID domain.OrderID
 }
 
-func (s *stubOrderService) CreateOrder(_ context.Context, _ []domain.OrderItem, _ *string) (*domain.Order, []domain.Product, error) {
+func (s *stubOrderService) CreateOrder(_ context.Context, in service.CreateOrderInput) (*domain.Order, []domain.Product, error) {
+	s.gotInput = in
 	if s.err != nil {
 		return nil, nil, s.err
 	}
@@ -30,13 +42,19 @@ func (s *stubOrderService) CreateOrder(_ context.Context, _ []domain.OrderItem,
 	return s.order, s.products, nil
 }
 
+func (s *stubOrderService) CancelOrder(_ context.Context, id domain.OrderID) error {
+	s.cancelledID = id
+	return s.cancelErr
+}
+
 // complie-time safety
 var _ service.OrderService = (*stubOrderService)(nil)
 
 func TestOrderHandler_PlaceOrder_Success(t *testing.T) {
 	// The order + products the service will return
 	order := &domain.Order{
-		ID: "order-123",
+		ID:      "order-123",
+		StoreID: "cbd",
 		Items: []domain.OrderItem{
 			{ProductID: "10", Quantity: 2},
 			{ProductID: "11", Quantity: 3},
@@ -54,6 +72,7 @@ func TestOrderHandler_PlaceOrder_Success(t *testing.T) {
 	h := handlers.NewOrderHandler(svc)
 
 	reqDTO := api.OrderReqDTO{
+		StoreID:    "cbd",
 		CouponCode: ptr("PROMO10"),
 		Items: []api.OrderItemDTO{
 			{ProductID: "10", Quantity: 2},
@@ -88,6 +107,12 @@ func TestOrderHandler_PlaceOrder_Success(t *testing.T) {
 	if got.ID != string(order.ID) {
 		t.Fatalf("order.ID =%s, want %s", got.ID, order.ID)
 	}
+	if got.StoreID != "cbd" {
+		t.Fatalf("order.StoreID = %s, want %s", got.StoreID, "cbd")
+	}
+	if svc.gotInput.StoreID != "cbd" {
+		t.Fatalf("CreateOrder() called with StoreID %q, want %q", svc.gotInput.StoreID, "cbd")
+	}
 
 	if len(got.Items) != len(order.Items) {
 		t.Fatalf("len(got.Items) = %d, want %d", len(got.Items), len(order.Items))
@@ -109,6 +134,72 @@ func TestOrderHandler_PlaceOrder_Success(t *testing.T) {
 	}
 }
 
+func TestOrderHandler_PlaceOrder_CustomerPrincipal(t *testing.T) {
+	customer := &middleware.Principal{ID: "cust-1", Method: middleware.AuthMethodJWT, Roles: []string{string(domain.RoleCustomer)}}
+	staff := &middleware.Principal{ID: "staff-1", Method: middleware.AuthMethodJWT, Roles: []string{string(domain.RoleStaff)}}
+	apiKey := &middleware.Principal{ID: "pos", Method: middleware.AuthMethodAPIKey, Scopes: []domain.APIScope{domain.ScopeOrderWrite}}
+
+	tests := []struct {
+		name         string
+		principal    *middleware.Principal
+		customerID   string
+		wantStatus   int
+		wantCustomer string // "" for a guest order
+	}{
+		{name: "customer without customerId", principal: customer, wantStatus: http.StatusOK, wantCustomer: "cust-1"},
+		{name: "customer with own customerId", principal: customer, customerID: "cust-1", wantStatus: http.StatusOK, wantCustomer: "cust-1"},
+		{name: "customer with another customerId", principal: customer, customerID: "cust-2", wantStatus: http.StatusForbidden},
+		{name: "staff for a customer", principal: staff, customerID: "cust-2", wantStatus: http.StatusOK, wantCustomer: "cust-2"},
+		{name: "api key for a customer", principal: apiKey, customerID: "cust-2", wantStatus: http.StatusOK, wantCustomer: "cust-2"},
+		{name: "api key guest order", principal: apiKey, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &stubOrderService{order: &domain.Order{ID: "order-1", StoreID: domain.DefaultStoreID}}
+			h := handlers.NewOrderHandler(svc)
+
+			body, err := json.Marshal(api.OrderReqDTO{
+				CustomerID: tt.customerID,
+				Items:      []api.OrderItemDTO{{ProductID: "10", Quantity: 1}},
+			})
+			if err != nil {
+				t.Fatalf("failed to marshal request dto: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body))
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), tt.principal))
+			rr := httptest.NewRecorder()
+
+			h.PlaceOrder(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body=%q", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if svc.gotInput.Items != nil {
+					t.Errorf("CreateOrder() called for a rejected order")
+				}
+				var problem shared.Problem
+				if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+					t.Fatalf("failed to decode problem: %v", err)
+				}
+				if problem.Status != tt.wantStatus {
+					t.Errorf("problem.Status = %d, want %d", problem.Status, tt.wantStatus)
+				}
+				return
+			}
+
+			got := ""
+			if svc.gotInput.CustomerID != nil {
+				got = string(*svc.gotInput.CustomerID)
+			}
+			if got != tt.wantCustomer {
+				t.Errorf("CreateOrder() called with CustomerID %q, want %q", got, tt.wantCustomer)
+			}
+		})
+	}
+}
+
 func TestOrderHandler_PlaceOrder_InvalidJSON(t *testing.T) {
 	svc := &stubOrderService{}
 	h := handlers.NewOrderHandler(svc)
@@ -224,6 +315,278 @@ func TestOrderHandler_PlaceOrder_InternalError(t *testing.T) {
 	}
 }
 
+func TestOrderHandler_PlaceOrder_StoreNotFound(t *testing.T) {
+	svc := &stubOrderService{
+		err: domain.ErrStoreNotFound,
+	}
+	h := handlers.NewOrderHandler(svc)
+
+	reqDTO := api.OrderReqDTO{
+		StoreID: "nowhere",
+		Items: []api.OrderItemDTO{
+			{ProductID: "10", Quantity: 1},
+		},
+	}
+	body, err := json.Marshal(reqDTO)
+	if err != nil {
+		t.Fatalf("failed to marshal request dto: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	h.PlaceOrder(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d, body=%q", rr.Code, http.StatusBadRequest, rr.Body.String())
+	}
+}
+
+func TestOrderHandler_PlaceOrder_CustomerNotFound(t *testing.T) {
+	svc := &stubOrderService{
+		err: domain.ErrCustomerNotFound,
+	}
+	h := handlers.NewOrderHandler(svc)
+
+	reqDTO := api.OrderReqDTO{
+		CustomerID: "ghost",
+		Items: []api.OrderItemDTO{
+			{ProductID: "10", Quantity: 1},
+		},
+	}
+	body, err := json.Marshal(reqDTO)
+	if err != nil {
+		t.Fatalf("failed to marshal request dto: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	h.PlaceOrder(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d, body=%q", rr.Code, http.StatusBadRequest, rr.Body.String())
+	}
+	if svc.gotInput.CustomerID == nil || *svc.gotInput.CustomerID != "ghost" {
+		t.Fatalf("CreateOrder() got customer %v, want ghost", svc.gotInput.CustomerID)
+	}
+}
+
+func TestOrderHandler_PlaceOrder_PostcodeNotDeliverable(t *testing.T) {
+	svc := &stubOrderService{
+		err: fmt.Errorf("lookup delivery zone: %w", domain.ErrDeliveryZoneNotFound),
+	}
+	h := handlers.NewOrderHandler(svc)
+
+	reqDTO := api.OrderReqDTO{
+		Items: []api.OrderItemDTO{
+			{ProductID: "10", Quantity: 1},
+		},
+		FulfilmentType: "delivery",
+		DeliveryAddress: &api.DeliveryAddressDTO{
+			Line1: "1 Hay St", Suburb: "Perth", State: "WA", Postcode: "6000",
+		},
+	}
+	body, err := json.Marshal(reqDTO)
+	if err != nil {
+		t.Fatalf("failed to marshal request dto: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	h.PlaceOrder(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d, body=%q", rr.Code, http.StatusUnprocessableEntity, rr.Body.String())
+	}
+	if svc.gotInput.Fulfilment.Address == nil || svc.gotInput.Fulfilment.Address.Postcode != "6000" {
+		t.Fatalf("CreateOrder() got fulfilment %+v, want delivery to 6000", svc.gotInput.Fulfilment)
+	}
+}
+
+func TestOrderHandler_PlaceOrder_StoreClosed(t *testing.T) {
+	svc := &stubOrderService{
+		err: fmt.Errorf("%w (%s)", domain.ErrStoreClosed, domain.StoreOutsideOpeningHours),
+	}
+	h := handlers.NewOrderHandler(svc)
+
+	reqDTO := api.OrderReqDTO{
+		Items: []api.OrderItemDTO{
+			{ProductID: "10", Quantity: 1},
+		},
+	}
+	body, err := json.Marshal(reqDTO)
+	if err != nil {
+		t.Fatalf("failed to marshal request dto: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	h.PlaceOrder(rr, req)
+
+	res := rr.Result()
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode != http.StatusConflict {
+		b, _ := io.ReadAll(res.Body)
+		t.Fatalf("status = %d, want %d, body=%q", res.StatusCode, http.StatusConflict, string(b))
+	}
+}
+
+func TestOrderHandler_PlaceOrder_UnknownCoupon(t *testing.T) {
+	svc := &stubOrderService{
+		err: fmt.Errorf("check coupon: %w", domain.ErrUnknownCouponCode),
+	}
+	h := handlers.NewOrderHandler(svc)
+
+	reqDTO := api.OrderReqDTO{
+		CouponCode: ptr("GUESS123"),
+		Items: []api.OrderItemDTO{
+			{ProductID: "10", Quantity: 1},
+		},
+	}
+	body, err := json.Marshal(reqDTO)
+	if err != nil {
+		t.Fatalf("failed to marshal request dto: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body))
+	req.RemoteAddr = "203.0.113.7:4321"
+	rr := httptest.NewRecorder()
+
+	h.PlaceOrder(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d, body=%q", rr.Code, http.StatusUnprocessableEntity, rr.Body.String())
+	}
+	if svc.gotInput.AttemptKey != "ip:203.0.113.7" {
+		t.Fatalf("CreateOrder() got attempt key %q, want %q", svc.gotInput.AttemptKey, "ip:203.0.113.7")
+	}
+}
+
+func TestOrderHandler_PlaceOrder_CouponLockedOut(t *testing.T) {
+	svc := &stubOrderService{
+		err: fmt.Errorf("check coupon: %w", &domain.CouponLockoutError{Until: time.Now().Add(10 * time.Minute)}),
+	}
+	h := handlers.NewOrderHandler(svc)
+
+	reqDTO := api.OrderReqDTO{
+		CouponCode: ptr("FIFTYOFF"),
+		Items: []api.OrderItemDTO{
+			{ProductID: "10", Quantity: 1},
+		},
+	}
+	body, err := json.Marshal(reqDTO)
+	if err != nil {
+		t.Fatalf("failed to marshal request dto: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	h.PlaceOrder(rr, req)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d, body=%q", rr.Code, http.StatusTooManyRequests, rr.Body.String())
+	}
+	if secs, err := strconv.Atoi(rr.Header().Get("Retry-After")); err != nil || secs < 599 || secs > 600 {
+		t.Fatalf("Retry-After = %q, want about 600", rr.Header().Get("Retry-After"))
+	}
+
+	var problem shared.Problem
+	if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+	if problem.Type != handlers.CouponLockedOutProblem {
+		t.Fatalf("problem.Type = %q, want %q", problem.Type, handlers.CouponLockedOutProblem)
+	}
+}
+
+func TestOrderHandler_PlaceOrder_CouponRedemptionErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "exhausted", err: domain.ErrCouponExhausted, wantStatus: http.StatusConflict},
+		{name: "customer limit", err: domain.ErrCouponCustomerLimit, wantStatus: http.StatusConflict},
+		{name: "guest order", err: domain.ErrCouponCustomerRequired, wantStatus: http.StatusUnprocessableEntity},
+		{name: "blank code", err: domain.ErrInvalidCouponCode, wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &stubOrderService{err: fmt.Errorf("persist order: %w", tt.err)}
+			h := handlers.NewOrderHandler(svc)
+
+			body, err := json.Marshal(api.OrderReqDTO{
+				CouponCode: ptr("BIRTHDAY"),
+				Items:      []api.OrderItemDTO{{ProductID: "10", Quantity: 1}},
+			})
+			if err != nil {
+				t.Fatalf("failed to marshal request dto: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader(body))
+			rr := httptest.NewRecorder()
+
+			h.PlaceOrder(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body=%q", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestOrderHandler_CancelOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "cancelled", wantStatus: http.StatusOK},
+		{name: "unknown order", err: domain.ErrOrderNotFound, wantStatus: http.StatusNotFound},
+		{name: "already cancelled", err: domain.ErrOrderAlreadyCancelled, wantStatus: http.StatusConflict},
+		{name: "store failure", err: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &stubOrderService{cancelErr: tt.err}
+			h := handlers.NewOrderHandler(svc)
+
+			req := httptest.NewRequest(http.MethodPost, "/order/order-1/cancel", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("orderId", "order-1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rr := httptest.NewRecorder()
+
+			h.CancelOrder(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body=%q", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if svc.cancelledID != "order-1" {
+				t.Fatalf("CancelOrder() called with %q, want order-1", svc.cancelledID)
+			}
+			if tt.err == nil {
+				var got api.OrderStatusDTO
+				if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+					t.Fatalf("failed to decode response body: %v", err)
+				}
+				if got.Status != string(domain.OrderCancelled) {
+					t.Fatalf("status = %q, want %q", got.Status, domain.OrderCancelled)
+				}
+			}
+		})
+	}
+}
+
 func ptr[T any](v T) *T {
 	return &v
 }
@@ -91,3 +91,39 @@ func (h *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request)
 	dto := api.MapDomainProductToDTO(*product)
 	shared.WriteJSON(w, r, http.StatusOK, dto)
 }
+
+// ListStoreProducts handles GET /store/{storeId}/product.
+//
+// @Summary List products for a store
+// @Description Get the products available at a store, with store-specific prices
+// @Tags product
+// @Produce json
+// @Param storeId path string true "ID of the store"
+// @Success 200 {array} api.ProductDTO
+// @Failure 404 {object} shared.ErrorResponse
+// @Router /store/{storeId}/product [get]
+func (h *ProductHandler) ListStoreProducts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := zerolog.Ctx(ctx)
+
+	storeID := chi.URLParam(r, "storeId")
+	if storeID == "" {
+		shared.WriteJSONError(w, r, http.StatusBadRequest, "invalid store ID supplied")
+		return
+	}
+
+	products, err := h.productSvc.ListStoreProducts(ctx, domain.StoreID(storeID))
+	if errors.Is(err, domain.ErrStoreNotFound) {
+		logger.Warn().Str("storeId", storeID).Msg("store not found")
+		shared.WriteJSONError(w, r, http.StatusNotFound, "Store not found")
+		return
+	}
+	if err != nil {
+		logger.Error().Str("storeId", storeID).Err(err).Msg("failed to list store products")
+		shared.WriteJSONError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	dto := api.MapDomainProductsToDTO(products)
+	shared.WriteJSON(w, r, http.StatusOK, dto)
+}
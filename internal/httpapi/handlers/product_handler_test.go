@@ -49,6 +49,13 @@ func (s *stubProductService) GetProduct(_ context.Context, id domain.ProductID)
 	return &p, s.err
 }
 
+func (s *stubProductService) ListStoreProducts(_ context.Context, storeID domain.StoreID) ([]domain.Product, error) {
+	if storeID != domain.DefaultStoreID {
+		return nil, domain.ErrStoreNotFound
+	}
+	return s.seed, s.err
+}
+
 // Ensure stub implements the interface at complie time
 var _ service.ProductService = (*stubProductService)(nil)
 
@@ -203,3 +210,39 @@ func TestProductHandler_GetProduct_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestProductHandler_ListStoreProducts(t *testing.T) {
+	seed := []domain.Product{
+		{ID: domain.ProductID("10"), Name: "Chicken Waffle", Price: domain.NewMoneyFromFloat(12.5), Category: "Waffle"},
+	}
+
+	svc := newStubProductService(seed, nil)
+	h := handlers.NewProductHandler(svc)
+
+	tests := []struct {
+		name    string
+		storeID string
+		code    int
+	}{
+		{name: "known store", storeID: string(domain.DefaultStoreID), code: http.StatusOK},
+		{name: "unknown store", storeID: "nowhere", code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/store/"+tt.storeID+"/product", nil)
+			rr := httptest.NewRecorder()
+
+			// Simulate chi param extraction
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("storeId", tt.storeID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			h.ListStoreProducts(rr, req)
+
+			if rr.Code != tt.code {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/M-Arthur/order-food-api/internal/api"
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/shared"
+	"github.com/M-Arthur/order-food-api/internal/service"
+	"github.com/rs/zerolog"
+)
+
+type PromoHandler struct {
+	promoSvc service.PromoService
+}
+
+func NewPromoHandler(promoSvc service.PromoService) *PromoHandler {
+	return &PromoHandler{
+		promoSvc: promoSvc,
+	}
+}
+
+// GetCodeSet handles GET /admin/promo-codes.
+//
+// @Summary Promo code set status
+// @Description Shows the version and size of the promo code set used to validate coupons, and whether the latest reload was rejected
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Success 200 {object} api.PromoCodeSetDTO
+// @Failure 403 {object} shared.Problem
+// @Failure 404 {object} shared.ErrorResponse
+// @Failure 500 {object} shared.ErrorResponse
+// @Router /admin/promo-codes [get]
+func (h *PromoHandler) GetCodeSet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := zerolog.Ctx(ctx)
+
+	st, err := h.promoSvc.CodeSetStatus(ctx)
+	if errors.Is(err, domain.ErrNoActivePromoCodes) {
+		logger.Warn().Msg("no active promo code set")
+		shared.WriteJSONError(w, r, http.StatusNotFound, "No active promo code set")
+		return
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to load promo code set status")
+		shared.WriteJSONError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	shared.WriteJSON(w, r, http.StatusOK, api.MapDomainPromoCodeSetStatusToDTO(st))
+}
@@ -0,0 +1,79 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/api"
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/handlers"
+	"github.com/M-Arthur/order-food-api/internal/service"
+)
+
+type stubPromoService struct {
+	status *domain.PromoCodeSetStatus
+	err    error
+}
+
+func (s *stubPromoService) CodeSetStatus(context.Context) (*domain.PromoCodeSetStatus, error) {
+	return s.status, s.err
+}
+
+// complie-time safety
+var _ service.PromoService = (*stubPromoService)(nil)
+
+func TestPromoHandler_GetCodeSet(t *testing.T) {
+	loadedAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		svc        *stubPromoService
+		wantStatus int
+	}{
+		{
+			name: "loaded",
+			svc: &stubPromoService{status: &domain.PromoCodeSetStatus{
+				Source: "file", Location: "valid_promo_codes.txt", Version: "abc123", CodeCount: 8, LoadedAt: loadedAt,
+				LastError: "malformed promo codes file", LastCheckedAt: loadedAt.Add(time.Minute),
+			}},
+			wantStatus: http.StatusOK,
+		},
+		{name: "nothing active", svc: &stubPromoService{err: domain.ErrNoActivePromoCodes}, wantStatus: http.StatusNotFound},
+		{name: "store failure", svc: &stubPromoService{err: errors.New("db down")}, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handlers.NewPromoHandler(tt.svc)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/promo-codes", nil)
+			rr := httptest.NewRecorder()
+
+			h.GetCodeSet(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body=%q", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got api.PromoCodeSetDTO
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode response body: %v", err)
+			}
+			want := api.PromoCodeSetDTO{
+				Source: "file", Location: "valid_promo_codes.txt", Version: "abc123", CodeCount: 8,
+				LoadedAt: "2025-06-01T12:00:00Z", LastError: "malformed promo codes file", LastCheckedAt: "2025-06-01T12:01:00Z",
+			}
+			if got != want {
+				t.Fatalf("body = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/M-Arthur/order-food-api/internal/api"
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/shared"
+	"github.com/M-Arthur/order-food-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// StoreHandler is the HTTP adapter for store opening status.
+type StoreHandler struct {
+	storeSvc service.StoreService
+}
+
+func NewStoreHandler(storeSvc service.StoreService) *StoreHandler {
+	return &StoreHandler{
+		storeSvc: storeSvc,
+	}
+}
+
+// GetStatus handles GET /store/status and GET /store/{storeId}/status.
+//
+// Clients use this to decide whether to enable the checkout button.
+// Without a storeId the main store is assumed.
+//
+// @Summary Store status
+// @Description Returns whether the store is currently accepting orders
+// @Tags store
+// @Produce json
+// @Param storeId path string true "ID of the store"
+// @Success 200 {object} api.StoreStatusDTO
+// @Failure 404 {object} shared.ErrorResponse
+// @Failure 500 {object} shared.ErrorResponse
+// @Router /store/{storeId}/status [get]
+func (h *StoreHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := zerolog.Ctx(ctx)
+	storeID := storeIDParam(r)
+
+	status, err := h.storeSvc.Status(ctx, storeID)
+	if errors.Is(err, domain.ErrStoreNotFound) {
+		logger.Warn().Str("storeId", string(storeID)).Msg("store not found")
+		shared.WriteJSONError(w, r, http.StatusNotFound, "Store not found")
+		return
+	}
+	if err != nil {
+		logger.Error().Str("storeId", string(storeID)).Err(err).Msg("failed to load store status")
+		shared.WriteJSONError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	shared.WriteJSON(w, r, http.StatusOK, api.MapDomainStoreStatusToDTO(status))
+}
+
+// SetPause handles PUT /store/pause and PUT /store/{storeId}/pause.
+//
+// @Summary Pause or resume ordering
+// @Description Toggles the manual "pause ordering" switch of a store
+// @Tags store
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param storeId path string true "ID of the store"
+// @Param pause body api.StorePauseReqDTO true "Pause request"
+// @Success 200 {object} api.StoreStatusDTO
+// @Failure 400 {object} shared.ErrorResponse
+// @Failure 403 {object} shared.Problem
+// @Failure 404 {object} shared.ErrorResponse
+// @Failure 500 {object} shared.ErrorResponse
+// @Router /store/{storeId}/pause [put]
+func (h *StoreHandler) SetPause(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := zerolog.Ctx(ctx)
+	storeID := storeIDParam(r)
+
+	var req api.StorePauseReqDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn().Err(err).Msg("invalid JSON for store pause request")
+		shared.WriteJSONError(w, r, http.StatusBadRequest, "Invalid input")
+		return
+	}
+
+	if err := h.storeSvc.SetOrderingPaused(ctx, storeID, req.Paused, req.Reason); err != nil {
+		if errors.Is(err, domain.ErrStoreNotFound) {
+			logger.Warn().Str("storeId", string(storeID)).Msg("store not found")
+			shared.WriteJSONError(w, r, http.StatusNotFound, "Store not found")
+			return
+		}
+		logger.Error().Str("storeId", string(storeID)).Err(err).Msg("failed to update ordering pause")
+		shared.WriteJSONError(w, r, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	logger.Info().
+		Str("storeId", string(storeID)).
+		Bool("paused", req.Paused).
+		Str("reason", req.Reason).
+		Msg("ordering pause updated")
+
+	h.GetStatus(w, r)
+}
+
+// storeIDParam returns the {storeId} path parameter, falling back to the main store.
+func storeIDParam(r *http.Request) domain.StoreID {
+	if id := chi.URLParam(r, "storeId"); id != "" {
+		return domain.StoreID(id)
+	}
+	return domain.DefaultStoreID
+}
@@ -0,0 +1,186 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/api"
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/handlers"
+	"github.com/M-Arthur/order-food-api/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// stubStoreService implements service.StoreService for tests
+type stubStoreService struct {
+	status domain.StoreStatus
+	err    error
+
+	gotStoreID  domain.StoreID
+	paused      bool
+	pauseReason string
+}
+
+func (s *stubStoreService) GetStore(_ context.Context, id domain.StoreID) (*domain.Store, error) {
+	return &domain.Store{ID: id}, s.err
+}
+
+func (s *stubStoreService) Status(_ context.Context, id domain.StoreID) (domain.StoreStatus, error) {
+	s.gotStoreID = id
+	return s.status, s.err
+}
+
+func (s *stubStoreService) EnsureAcceptingOrders(_ context.Context, _ domain.StoreID) error {
+	return s.err
+}
+
+func (s *stubStoreService) SetOrderingPaused(_ context.Context, id domain.StoreID, paused bool, reason string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.gotStoreID = id
+	s.paused = paused
+	s.pauseReason = reason
+	s.status = domain.StoreStatus{Reason: domain.StoreOrderingPaused, Message: reason}
+	return nil
+}
+
+// complie-time safety
+var _ service.StoreService = (*stubStoreService)(nil)
+
+func TestStoreHandler_GetStatus_Success(t *testing.T) {
+	svc := &stubStoreService{
+		status: domain.StoreStatus{
+			AcceptingOrders: true,
+			Reason:          domain.StoreOpen,
+			LocalTime:       time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	h := handlers.NewStoreHandler(svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/store/status", nil)
+	rr := httptest.NewRecorder()
+
+	h.GetStatus(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body=%q", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	var got api.StoreStatusDTO
+	if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %v", err)
+	}
+
+	if !got.AcceptingOrders {
+		t.Errorf("AcceptingOrders = false, want true")
+	}
+	if got.Status != string(domain.StoreOpen) {
+		t.Errorf("Status = %q, want %q", got.Status, domain.StoreOpen)
+	}
+	if got.LocalTime != "2025-01-15T12:00:00Z" {
+		t.Errorf("LocalTime = %q, want %q", got.LocalTime, "2025-01-15T12:00:00Z")
+	}
+	if svc.gotStoreID != domain.DefaultStoreID {
+		t.Errorf("Status() called for store %q, want %q", svc.gotStoreID, domain.DefaultStoreID)
+	}
+}
+
+func TestStoreHandler_GetStatus_ByStoreID(t *testing.T) {
+	svc := &stubStoreService{}
+	h := handlers.NewStoreHandler(svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/store/cbd/status", nil)
+	rr := httptest.NewRecorder()
+
+	// Simulate chi param extraction
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("storeId", "cbd")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	h.GetStatus(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if svc.gotStoreID != "cbd" {
+		t.Fatalf("Status() called for store %q, want %q", svc.gotStoreID, "cbd")
+	}
+}
+
+func TestStoreHandler_GetStatus_StoreNotFound(t *testing.T) {
+	svc := &stubStoreService{err: domain.ErrStoreNotFound}
+	h := handlers.NewStoreHandler(svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/store/nowhere/status", nil)
+	rr := httptest.NewRecorder()
+
+	h.GetStatus(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestStoreHandler_GetStatus_Error(t *testing.T) {
+	svc := &stubStoreService{err: errors.New("db down")}
+	h := handlers.NewStoreHandler(svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/store/status", nil)
+	rr := httptest.NewRecorder()
+
+	h.GetStatus(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestStoreHandler_SetPause(t *testing.T) {
+	svc := &stubStoreService{}
+	h := handlers.NewStoreHandler(svc)
+
+	body, err := json.Marshal(api.StorePauseReqDTO{Paused: true, Reason: "kitchen overloaded"})
+	if err != nil {
+		t.Fatalf("failed to marshal request dto: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/store/pause", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	h.SetPause(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d, body=%q", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if !svc.paused || svc.pauseReason != "kitchen overloaded" {
+		t.Fatalf("paused = %v (%q), want true (%q)", svc.paused, svc.pauseReason, "kitchen overloaded")
+	}
+
+	var got api.StoreStatusDTO
+	if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %v", err)
+	}
+	if got.AcceptingOrders || got.Status != string(domain.StoreOrderingPaused) {
+		t.Fatalf("got %+v, want paused status", got)
+	}
+}
+
+func TestStoreHandler_SetPause_InvalidJSON(t *testing.T) {
+	h := handlers.NewStoreHandler(&stubStoreService{})
+
+	req := httptest.NewRequest(http.MethodPut, "/store/pause", bytes.NewBufferString("{invalid-json"))
+	rr := httptest.NewRecorder()
+
+	h.SetPause(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/M-Arthur/order-food-api/internal/httpapi/shared"
+	"github.com/rs/zerolog"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request carries
+// no credentials of the kind it understands, so the next one can be tried.
+var ErrNoCredentials = errors.New("no credentials")
+
+// CredentialError rejects a request with 401 and a client-facing message.
+type CredentialError struct {
+	Message string
+	Err     error
+}
+
+func (e *CredentialError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *CredentialError) Unwrap() error {
+	return e.Err
+}
+
+// Authenticator resolves the credentials of a request to a Principal.
+type Authenticator interface {
+	// Authenticate returns ErrNoCredentials when the request has no
+	// credentials for this authenticator and a *CredentialError when they
+	// are present but unacceptable. Other errors are treated as internal.
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc adapts a function to Authenticator.
+type AuthenticatorFunc func(r *http.Request) (*Principal, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) {
+	return f(r)
+}
+
+// AnyOf tries each authenticator in order and uses the first one that finds
+// credentials in the request.
+func AnyOf(auths ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		for _, a := range auths {
+			if a == nil {
+				continue
+			}
+			p, err := a.Authenticate(r)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+			return p, err
+		}
+		return nil, ErrNoCredentials
+	})
+}
+
+// RequireAuth returns a middleware that authenticates the request with auth.
+// The principal is stored in the request context and added to the request
+// logger; use RequirePermission to authorise it.
+func RequireAuth(auth Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			logger := zerolog.Ctx(ctx)
+
+			p, err := auth.Authenticate(r)
+			if err != nil {
+				var ce *CredentialError
+				switch {
+				case errors.Is(err, ErrNoCredentials):
+					shared.WriteJSONError(w, r, http.StatusUnauthorized, "missing credentials")
+				case errors.As(err, &ce):
+					logger.Warn().Err(err).Msg("rejected credentials")
+					shared.WriteJSONError(w, r, http.StatusUnauthorized, ce.Message)
+				default:
+					logger.Error().Err(err).Msg("failed to authenticate request")
+					shared.WriteJSONError(w, r, http.StatusInternalServerError, "internal server error")
+				}
+				return
+			}
+
+			l := logger.With().
+				Str("client_id", p.ID).
+				Str("auth_method", string(p.Method)).
+				Logger()
+
+			ctx = WithPrincipal(l.WithContext(ctx), p)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
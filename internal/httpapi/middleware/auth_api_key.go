@@ -1,23 +1,51 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
-	"github.com/M-Arthur/order-food-api/internal/httpapi/shared"
+	"github.com/M-Arthur/order-food-api/internal/domain"
 )
 
-// APIKeyAuth returns a middleware that enforces the expected API key
-// in the `api_key` header, as defined in the OpenAPI spec.
-func APIKeyAuth(expectedKey string) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			apiKey := r.Header.Get("api_key")
-			if apiKey == "" || apiKey != expectedKey {
-				shared.WriteJSONError(w, r, http.StatusUnauthorized, "invalid or missing API key")
-				return
-			}
+// APIKeyAuthenticator resolves a raw API key to its client.
+// It is satisfied by service.APIKeyService.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, rawKey string) (*domain.APIClient, error)
+}
+
+// APIKeys authenticates the key in the `api_key` header, as defined in the
+// OpenAPI spec.
+func APIKeys(auth APIKeyAuthenticator) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		raw := r.Header.Get("api_key")
+		if raw == "" {
+			return nil, ErrNoCredentials
+		}
+
+		client, err := auth.Authenticate(r.Context(), raw)
+		switch {
+		case errors.Is(err, domain.ErrAPIKeyExpired):
+			return nil, &CredentialError{Message: "API key expired", Err: err}
+		case errors.Is(err, domain.ErrAPIKeyRevoked):
+			return nil, &CredentialError{Message: "API key revoked", Err: err}
+		case errors.Is(err, domain.ErrAPIKeyNotFound):
+			return nil, &CredentialError{Message: "invalid or missing API key", Err: err}
+		case err != nil:
+			return nil, err
+		}
+
+		return &Principal{
+			ID:     client.ID,
+			Name:   client.Name,
+			Method: AuthMethodAPIKey,
+			Scopes: client.Scopes,
+		}, nil
+	})
+}
 
-			next.ServeHTTP(w, r)
-		})
-	}
+// APIKeyAuth returns a middleware that only accepts API keys and requires
+// every listed scope.
+func APIKeyAuth(auth APIKeyAuthenticator, scopes ...domain.APIScope) func(http.Handler) http.Handler {
+	return requireScopes(APIKeys(auth), scopes)
 }
@@ -1,6 +1,8 @@
 package middleware_test
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -8,22 +10,54 @@ import (
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 
+	"github.com/M-Arthur/order-food-api/internal/domain"
 	"github.com/M-Arthur/order-food-api/internal/httpapi/middleware"
 	"github.com/M-Arthur/order-food-api/internal/httpapi/shared"
 )
 
-func TestAPIKeyAuth_ValidKey_AllowsRequest(t *testing.T) {
-	const expectedKey = "apitest"
+// stubAuthenticator implements middleware.APIKeyAuthenticator for tests
+type stubAuthenticator struct {
+	clients map[string]domain.APIClient
+	err     error
+}
+
+func (s *stubAuthenticator) Authenticate(_ context.Context, rawKey string) (*domain.APIClient, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	c, ok := s.clients[rawKey]
+	if !ok {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	return &c, nil
+}
+
+// complie-time safety
+var _ middleware.APIKeyAuthenticator = (*stubAuthenticator)(nil)
+
+func newAuthRouter(auth middleware.APIKeyAuthenticator, scopes ...domain.APIScope) (http.Handler, *middleware.Principal) {
+	var got middleware.Principal
 
 	r := chi.NewRouter()
 	// chi RequestID so we can inspect it if needed
 	r.Use(chimiddleware.RequestID)
-	r.With(middleware.APIKeyAuth(expectedKey)).Post("/order", func(w http.ResponseWriter, r *http.Request) {
+	r.With(middleware.APIKeyAuth(auth, scopes...)).Post("/order", func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := middleware.PrincipalFromContext(r.Context()); ok {
+			got = *p
+		}
 		shared.WriteJSON(w, r, http.StatusOK, map[string]string{"status": "ok"})
 	})
+	return r, &got
+}
+
+func TestAPIKeyAuth_ValidKey_AllowsRequest(t *testing.T) {
+	auth := &stubAuthenticator{clients: map[string]domain.APIClient{
+		"apitest": {ID: "dev", Name: "Development client", Scopes: []domain.APIScope{domain.ScopeOrderWrite}},
+	}}
+	r, principal := newAuthRouter(auth, domain.ScopeOrderWrite)
 
 	req := httptest.NewRequest(http.MethodPost, "/order", nil)
-	req.Header.Set("api_key", expectedKey)
+	req.Header.Set("api_key", "apitest")
 
 	rr := httptest.NewRecorder()
 	r.ServeHTTP(rr, req)
@@ -36,26 +70,33 @@ func TestAPIKeyAuth_ValidKey_AllowsRequest(t *testing.T) {
 	if res.StatusCode != http.StatusOK {
 		t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusOK)
 	}
+	if principal.ID != "dev" || principal.Method != middleware.AuthMethodAPIKey {
+		t.Fatalf("principal = %+v, want dev client authenticated by api key", principal)
+	}
 }
 
-func TestAPIKeyAuth_MissingOrInvalidKey_Returns401(t *testing.T) {
-	const expectedKey = "apitest"
+func TestAPIKeyAuth_Rejections(t *testing.T) {
+	clients := map[string]domain.APIClient{
+		"readonly": {ID: "ro", Scopes: []domain.APIScope{domain.ScopeCustomerRead}},
+	}
 
 	tests := []struct {
-		name      string
-		headerKey string
+		name       string
+		headerKey  string
+		authErr    error
+		wantStatus int
 	}{
-		{name: "missing", headerKey: ""},
-		{name: "invalid", headerKey: "wrong"},
+		{name: "missing", headerKey: "", wantStatus: http.StatusUnauthorized},
+		{name: "invalid", headerKey: "wrong", wantStatus: http.StatusUnauthorized},
+		{name: "expired", headerKey: "readonly", authErr: domain.ErrAPIKeyExpired, wantStatus: http.StatusUnauthorized},
+		{name: "revoked", headerKey: "readonly", authErr: domain.ErrAPIKeyRevoked, wantStatus: http.StatusUnauthorized},
+		{name: "missing scope", headerKey: "readonly", wantStatus: http.StatusForbidden},
+		{name: "store failure", headerKey: "readonly", authErr: errors.New("db down"), wantStatus: http.StatusInternalServerError},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := chi.NewRouter()
-			r.Use(chimiddleware.RequestID)
-			r.With(middleware.APIKeyAuth(expectedKey)).Post("/order", func(w http.ResponseWriter, r *http.Request) {
-				shared.WriteJSON(w, r, http.StatusOK, map[string]string{"status": "ok"})
-			})
+			r, _ := newAuthRouter(&stubAuthenticator{clients: clients, err: tt.authErr}, domain.ScopeOrderWrite)
 
 			req := httptest.NewRequest(http.MethodPost, "/order", nil)
 			if tt.headerKey != "" {
@@ -70,8 +111,8 @@ func TestAPIKeyAuth_MissingOrInvalidKey_Returns401(t *testing.T) {
 				_ = res.Body.Close()
 			}()
 
-			if res.StatusCode != http.StatusUnauthorized {
-				t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusUnauthorized)
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", res.StatusCode, tt.wantStatus)
 			}
 		})
 	}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig configures bearer token validation. At least one of HMACSecret
+// or RSAKeys must be set.
+type JWTConfig struct {
+	// HMACSecret verifies HS256 tokens.
+	HMACSecret []byte
+	// RSAKeys verifies RS256 tokens, keyed by the token's "kid" header.
+	// A key stored under "" is used for tokens without a kid.
+	RSAKeys  map[string]*rsa.PublicKey
+	Issuer   string        // required "iss" when set
+	Audience string        // required "aud" when set
+	Leeway   time.Duration // clock skew allowed for exp/nbf/iat
+}
+
+// JWTVerifier validates signed bearer tokens.
+type JWTVerifier struct {
+	cfg    JWTConfig
+	parser *jwt.Parser
+}
+
+// jwtClaims are the claims we read from a token. Roles and scope are custom
+// claims issued by our identity provider.
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Name  string   `json:"name,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+	Scope string   `json:"scope,omitempty"` // space-separated, as in OAuth 2
+}
+
+// NewJWTVerifier builds a JWTVerifier accepting the algorithms for which keys are configured.
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	var methods []string
+	if len(cfg.HMACSecret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if len(cfg.RSAKeys) > 0 {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("jwt: no HMAC secret or RSA keys configured")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &JWTVerifier{
+		cfg:    cfg,
+		parser: jwt.NewParser(opts...),
+	}, nil
+}
+
+// Verify validates raw and returns its subject as a Principal.
+func (v *JWTVerifier) Verify(raw string) (*Principal, error) {
+	var claims jwtClaims
+	if _, err := v.parser.ParseWithClaims(raw, &claims, v.key); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	var scopes []domain.APIScope
+	for _, s := range strings.Fields(claims.Scope) {
+		scopes = append(scopes, domain.APIScope(s))
+	}
+
+	return &Principal{
+		ID:     claims.Subject,
+		Name:   claims.Name,
+		Method: AuthMethodJWT,
+		Scopes: scopes,
+		Roles:  claims.Roles,
+	}, nil
+}
+
+// key picks the verification key for a token; the parser has already
+// restricted the algorithm to one we have keys for.
+func (v *JWTVerifier) key(t *jwt.Token) (any, error) {
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return v.cfg.HMACSecret, nil
+	case *jwt.SigningMethodRSA:
+		kid, _ := t.Header["kid"].(string)
+		key, ok := v.cfg.RSAKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+	}
+}
+
+// BearerJWT authenticates an `Authorization: Bearer <token>` header.
+func BearerJWT(v *JWTVerifier) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		header := r.Header.Get("Authorization")
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return nil, ErrNoCredentials
+		}
+
+		p, err := v.Verify(strings.TrimSpace(token))
+		if err != nil {
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				return nil, &CredentialError{Message: "token expired", Err: err}
+			}
+			return nil, &CredentialError{Message: "invalid bearer token", Err: err}
+		}
+		return p, nil
+	})
+}
+
+// JWTAuth returns a middleware that only accepts bearer tokens and requires
+// every listed scope.
+func JWTAuth(v *JWTVerifier, scopes ...domain.APIScope) func(http.Handler) http.Handler {
+	return requireScopes(BearerJWT(v), scopes)
+}
@@ -0,0 +1,242 @@
+package middleware_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/middleware"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/shared"
+)
+
+var hmacSecret = []byte("test-secret")
+
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(hmacSecret)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return s
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "user-42",
+		"iss":   "https://id.example.com",
+		"aud":   "order-food-api",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"customer"},
+		"scope": "order:write customer:read",
+	}
+}
+
+func newJWTVerifier(t *testing.T, cfg middleware.JWTConfig) *middleware.JWTVerifier {
+	t.Helper()
+	cfg.Issuer = "https://id.example.com"
+	cfg.Audience = "order-food-api"
+	v, err := middleware.NewJWTVerifier(cfg)
+	if err != nil {
+		t.Fatalf("NewJWTVerifier() error = %v", err)
+	}
+	return v
+}
+
+func serveWithAuth(mw func(http.Handler) http.Handler, req *http.Request) (*httptest.ResponseRecorder, *middleware.Principal) {
+	var got *middleware.Principal
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = middleware.PrincipalFromContext(r.Context())
+		shared.WriteJSON(w, r, http.StatusOK, map[string]string{"status": "ok"})
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr, got
+}
+
+func TestJWTAuth_HS256(t *testing.T) {
+	v := newJWTVerifier(t, middleware.JWTConfig{HMACSecret: hmacSecret})
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongAud := validClaims()
+	wrongAud["aud"] = "someone-else"
+	wrongIss := validClaims()
+	wrongIss["iss"] = "https://evil.example.com"
+	noExp := validClaims()
+	delete(noExp, "exp")
+	noSub := validClaims()
+	delete(noSub, "sub")
+
+	badSig, err := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims()).SignedString([]byte("other-secret"))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		header     string
+		scopes     []domain.APIScope
+		wantStatus int
+	}{
+		{name: "valid", header: "Bearer " + signHS256(t, validClaims()), scopes: []domain.APIScope{domain.ScopeOrderWrite}, wantStatus: http.StatusOK},
+		{name: "lowercase scheme", header: "bearer " + signHS256(t, validClaims()), wantStatus: http.StatusOK},
+		{name: "missing header", header: "", wantStatus: http.StatusUnauthorized},
+		{name: "basic auth", header: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized},
+		{name: "expired", header: "Bearer " + signHS256(t, expired), wantStatus: http.StatusUnauthorized},
+		{name: "wrong audience", header: "Bearer " + signHS256(t, wrongAud), wantStatus: http.StatusUnauthorized},
+		{name: "wrong issuer", header: "Bearer " + signHS256(t, wrongIss), wantStatus: http.StatusUnauthorized},
+		{name: "no expiry", header: "Bearer " + signHS256(t, noExp), wantStatus: http.StatusUnauthorized},
+		{name: "no subject", header: "Bearer " + signHS256(t, noSub), wantStatus: http.StatusUnauthorized},
+		{name: "bad signature", header: "Bearer " + badSig, wantStatus: http.StatusUnauthorized},
+		{name: "alg none", header: "Bearer " + unsigned, wantStatus: http.StatusUnauthorized},
+		{name: "missing scope", header: "Bearer " + signHS256(t, validClaims()), scopes: []domain.APIScope{domain.ScopeStoreAdmin}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/order", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			rr, p := serveWithAuth(middleware.JWTAuth(v, tt.scopes...), req)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body=%q", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if p == nil || p.ID != "user-42" || p.Method != middleware.AuthMethodJWT {
+				t.Fatalf("principal = %+v, want user-42 via jwt", p)
+			}
+			if len(p.Roles) != 1 || p.Roles[0] != "customer" {
+				t.Fatalf("principal.Roles = %v, want [customer]", p.Roles)
+			}
+		})
+	}
+}
+
+func TestJWTAuth_RS256WithJWKSFile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+
+	set := map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "k1",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("marshal jwks: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+
+	keys, err := middleware.LoadJWKSFile(path)
+	if err != nil {
+		t.Fatalf("LoadJWKSFile() error = %v", err)
+	}
+	v := newJWTVerifier(t, middleware.JWTConfig{RSAKeys: keys})
+
+	sign := func(kid string) string {
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims())
+		tok.Header["kid"] = kid
+		s, err := tok.SignedString(key)
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "known kid", token: sign("k1"), wantStatus: http.StatusOK},
+		{name: "unknown kid", token: sign("k2"), wantStatus: http.StatusUnauthorized},
+		// HS256 must not be accepted when only RSA keys are configured
+		{name: "hmac token", token: signHS256(t, validClaims()), wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/order", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			rr, _ := serveWithAuth(middleware.JWTAuth(v), req)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body=%q", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewJWTVerifier_RequiresKeys(t *testing.T) {
+	if _, err := middleware.NewJWTVerifier(middleware.JWTConfig{}); err == nil {
+		t.Fatalf("NewJWTVerifier() error = nil, want error without keys")
+	}
+}
+
+func TestAnyOf_APIKeyOrJWT(t *testing.T) {
+	keys := &stubAuthenticator{clients: map[string]domain.APIClient{
+		"apitest": {ID: "dev", Scopes: []domain.APIScope{domain.ScopeOrderWrite}},
+	}}
+	v := newJWTVerifier(t, middleware.JWTConfig{HMACSecret: hmacSecret})
+	mw := middleware.RequireAuth(middleware.AnyOf(middleware.APIKeys(keys), middleware.BearerJWT(v)))
+
+	tests := []struct {
+		name       string
+		apiKey     string
+		bearer     string
+		wantStatus int
+		wantMethod middleware.AuthMethod
+	}{
+		{name: "api key", apiKey: "apitest", wantStatus: http.StatusOK, wantMethod: middleware.AuthMethodAPIKey},
+		{name: "bearer token", bearer: signHS256(t, validClaims()), wantStatus: http.StatusOK, wantMethod: middleware.AuthMethodJWT},
+		{name: "bad api key is not rescued by token", apiKey: "wrong", bearer: signHS256(t, validClaims()), wantStatus: http.StatusUnauthorized},
+		{name: "neither", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/order", nil)
+			if tt.apiKey != "" {
+				req.Header.Set("api_key", tt.apiKey)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+
+			rr, p := serveWithAuth(mw, req)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body=%q", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && p.Method != tt.wantMethod {
+				t.Fatalf("principal.Method = %q, want %q", p.Method, tt.wantMethod)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/shared"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// RequirePermission returns a middleware that only lets principals holding
+// perm through, either as a scope or through one of their roles under policy.
+// It must run after RequireAuth; other principals get 403 with a problem body.
+func RequirePermission(policy domain.Policy, perm domain.APIScope) func(http.Handler) http.Handler {
+	return requirePermission(policy, perm, "")
+}
+
+// RequirePermissionOrSelf is RequirePermission that also admits customers
+// acting on themselves, i.e. when the selfParam URL parameter equals the
+// principal's ID (e.g. a token's subject reading /customer/{customerId}).
+func RequirePermissionOrSelf(policy domain.Policy, perm domain.APIScope, selfParam string) func(http.Handler) http.Handler {
+	return requirePermission(policy, perm, selfParam)
+}
+
+func requirePermission(policy domain.Policy, perm domain.APIScope, selfParam string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				shared.WriteProblem(w, r, http.StatusUnauthorized, "", "authentication required")
+				return
+			}
+
+			if p.Can(policy, perm) || (selfParam != "" && p.IsSelf(chi.URLParam(r, selfParam))) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			zerolog.Ctx(r.Context()).Warn().
+				Str("permission", string(perm)).
+				Strs("roles", p.Roles).
+				Msg("principal lacks required permission")
+			shared.WriteProblem(w, r, http.StatusForbidden, "", "missing permission "+string(perm))
+		})
+	}
+}
+
+// chain composes middlewares so the first one runs outermost.
+func chain(mws ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			next = mws[i](next)
+		}
+		return next
+	}
+}
+
+// requireScopes authenticates with auth and requires every scope, without roles.
+func requireScopes(auth Authenticator, scopes []domain.APIScope) func(http.Handler) http.Handler {
+	mws := []func(http.Handler) http.Handler{RequireAuth(auth)}
+	for _, s := range scopes {
+		mws = append(mws, RequirePermission(nil, s))
+	}
+	return chain(mws...)
+}
@@ -0,0 +1,176 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/middleware"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/shared"
+)
+
+func TestRequirePermission(t *testing.T) {
+	policy := domain.DefaultPolicy()
+
+	tests := []struct {
+		name       string
+		principal  *middleware.Principal
+		perm       domain.APIScope
+		wantStatus int
+	}{
+		{
+			name:       "no principal",
+			perm:       domain.ScopeStoreAdmin,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "api key scope",
+			principal:  &middleware.Principal{ID: "dev", Scopes: []domain.APIScope{domain.ScopeStoreAdmin}},
+			perm:       domain.ScopeStoreAdmin,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "staff role",
+			principal:  &middleware.Principal{ID: "u1", Roles: []string{"staff"}},
+			perm:       domain.ScopeStoreAdmin,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "customer role cannot pause the store",
+			principal:  &middleware.Principal{ID: "u1", Roles: []string{"customer"}},
+			perm:       domain.ScopeStoreAdmin,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "staff role cannot manage products",
+			principal:  &middleware.Principal{ID: "u1", Roles: []string{"staff"}},
+			perm:       domain.ScopeProductAdmin,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "admin role",
+			principal:  &middleware.Principal{ID: "u1", Roles: []string{"admin"}},
+			perm:       domain.ScopeProductAdmin,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unknown role",
+			principal:  &middleware.Principal{ID: "u1", Roles: []string{"root"}},
+			perm:       domain.ScopeOrderWrite,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := middleware.RequirePermission(policy, tt.perm)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				shared.WriteJSON(w, r, http.StatusOK, map[string]string{"status": "ok"})
+			}))
+
+			req := httptest.NewRequest(http.MethodPut, "/store/pause", nil)
+			if tt.principal != nil {
+				req = req.WithContext(middleware.WithPrincipal(req.Context(), tt.principal))
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body=%q", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequirePermission_ProblemBody(t *testing.T) {
+	h := middleware.RequirePermission(domain.DefaultPolicy(), domain.ScopeStoreAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("handler should not be called")
+	}))
+
+	req := httptest.NewRequest(http.MethodPut, "/store/pause", nil)
+	req = req.WithContext(middleware.WithPrincipal(req.Context(), &middleware.Principal{ID: "u1", Roles: []string{"customer"}}))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("Content-Type = %q, want application/problem+json", ct)
+	}
+
+	var got shared.Problem
+	if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode problem body: %v", err)
+	}
+	want := shared.Problem{
+		Type:     "about:blank",
+		Title:    "Forbidden",
+		Status:   http.StatusForbidden,
+		Detail:   "missing permission store:admin",
+		Instance: "/store/pause",
+	}
+	if got != want {
+		t.Fatalf("problem = %+v, want %+v", got, want)
+	}
+}
+
+func TestRequirePermissionOrSelf(t *testing.T) {
+	policy := domain.DefaultPolicy()
+
+	tests := []struct {
+		name       string
+		principal  *middleware.Principal
+		customerID string
+		wantStatus int
+	}{
+		{
+			name:       "customer reading own history",
+			principal:  &middleware.Principal{ID: "c1", Roles: []string{"customer"}},
+			customerID: "c1",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "customer reading someone else's history",
+			principal:  &middleware.Principal{ID: "c1", Roles: []string{"customer"}},
+			customerID: "c2",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "api client whose id matches is not a customer",
+			principal:  &middleware.Principal{ID: "c2"},
+			customerID: "c2",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "staff reading any history",
+			principal:  &middleware.Principal{ID: "s1", Roles: []string{"staff"}},
+			customerID: "c2",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := middleware.RequirePermissionOrSelf(policy, domain.ScopeCustomerRead, "customerId")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				shared.WriteJSON(w, r, http.StatusOK, map[string]string{"status": "ok"})
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/customer/"+tt.customerID+"/order", nil)
+
+			// Simulate chi param extraction
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("customerId", tt.customerID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(middleware.WithPrincipal(ctx, tt.principal))
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body=%q", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
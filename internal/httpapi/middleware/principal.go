@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+// AuthMethod records how a principal authenticated.
+type AuthMethod string
+
+const (
+	AuthMethodAPIKey AuthMethod = "api_key"
+	AuthMethodJWT    AuthMethod = "jwt"
+)
+
+// Principal is the authenticated caller of a request: an API client or,
+// for bearer tokens, the token's subject.
+type Principal struct {
+	ID     string
+	Name   string
+	Method AuthMethod
+	Scopes []domain.APIScope
+	Roles  []string // only set for bearer tokens
+}
+
+// HasScope reports whether the principal was granted scope.
+func (p *Principal) HasScope(scope domain.APIScope) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Can reports whether the principal holds perm directly or through one of its roles.
+func (p *Principal) Can(policy domain.Policy, perm domain.APIScope) bool {
+	return p.HasScope(perm) || policy.Grants(p.Roles, perm)
+}
+
+// IsSelf reports whether id names a customer principal itself.
+func (p *Principal) IsSelf(id string) bool {
+	return id != "" && p.ID == id && slices.Contains(p.Roles, string(domain.RoleCustomer))
+}
+
+// IsCustomer reports whether the principal is a customer acting for itself:
+// a bearer token with the customer role and neither the staff nor the admin
+// role.
+func (p *Principal) IsCustomer() bool {
+	return slices.Contains(p.Roles, string(domain.RoleCustomer)) &&
+		!slices.Contains(p.Roles, string(domain.RoleStaff)) &&
+		!slices.Contains(p.Roles, string(domain.RoleAdmin))
+}
+
+type principalCtxKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, p)
+}
+
+// PrincipalFromContext returns the authenticated principal, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalCtxKey{}).(*Principal)
+	return p, ok && p != nil
+}
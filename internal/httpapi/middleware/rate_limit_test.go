@@ -0,0 +1,128 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/httpapi/middleware"
+	"github.com/M-Arthur/order-food-api/internal/httpapi/shared"
+	"github.com/M-Arthur/order-food-api/internal/ratelimit"
+)
+
+// failingStore implements ratelimit.Store and always fails
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("db down")
+}
+
+// complie-time safety
+var _ ratelimit.Store = failingStore{}
+
+func newRateLimited(store ratelimit.Store, limit ratelimit.Limit) http.Handler {
+	return middleware.RateLimit(store, "test", limit, middleware.ClientOrIPKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		shared.WriteJSON(w, r, http.StatusOK, map[string]string{"status": "ok"})
+	}))
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := ratelimit.NewMemoryStore(func() time.Time { return now })
+	h := newRateLimited(store, ratelimit.PerMinute(6, 2)) // a token every 10s
+
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/product", nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do("10.0.0.1:1234")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if got := rr.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Errorf("RateLimit-Limit = %q, want 2", got)
+	}
+	if got := rr.Header().Get("RateLimit-Remaining"); got != "1" {
+		t.Errorf("RateLimit-Remaining = %q, want 1", got)
+	}
+	if got := rr.Header().Get("RateLimit-Reset"); got != "10" {
+		t.Errorf("RateLimit-Reset = %q, want 10", got)
+	}
+
+	// Same IP on another port shares the bucket
+	if rr := do("10.0.0.1:5678"); rr.Code != http.StatusOK {
+		t.Fatalf("second request status = %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	rr = do("10.0.0.1:1234")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request status = %d, want %d", rr.Code, http.StatusTooManyRequests)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("Retry-After = %q, want 10", got)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", ct)
+	}
+
+	// Another IP has its own bucket
+	if rr := do("10.0.0.2:1234"); rr.Code != http.StatusOK {
+		t.Fatalf("other IP status = %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	// Tokens come back over time
+	now = now.Add(10 * time.Second)
+	if rr := do("10.0.0.1:1234"); rr.Code != http.StatusOK {
+		t.Fatalf("after refill status = %d, want %d", rr.Code, http.StatusOK)
+	}
+}
+
+func TestClientOrIPKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/order", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	if got := middleware.ClientOrIPKey(req); got != "ip:10.0.0.1" {
+		t.Fatalf("ClientOrIPKey() = %q, want ip:10.0.0.1", got)
+	}
+
+	// chimiddleware.RealIP sets RemoteAddr without a port
+	req.RemoteAddr = "203.0.113.7"
+	if got := middleware.ClientOrIPKey(req); got != "ip:203.0.113.7" {
+		t.Fatalf("ClientOrIPKey() = %q, want ip:203.0.113.7", got)
+	}
+
+	req = req.WithContext(middleware.WithPrincipal(req.Context(), &middleware.Principal{ID: "dev"}))
+	if got := middleware.ClientOrIPKey(req); got != "client:dev" {
+		t.Fatalf("ClientOrIPKey() = %q, want client:dev", got)
+	}
+}
+
+func TestRateLimit_DisabledAndStoreFailure(t *testing.T) {
+	tests := []struct {
+		name  string
+		store ratelimit.Store
+		limit ratelimit.Limit
+	}{
+		{name: "disabled limit", store: failingStore{}, limit: ratelimit.Limit{}},
+		{name: "store failure fails open", store: failingStore{}, limit: ratelimit.PerMinute(1, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newRateLimited(tt.store, tt.limit)
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/product", nil))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+			}
+		})
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"net/http"
 
 	"github.com/M-Arthur/order-food-api/internal/bootstrap"
+	"github.com/M-Arthur/order-food-api/internal/domain"
 	"github.com/M-Arthur/order-food-api/internal/httpapi/handlers"
 	"github.com/M-Arthur/order-food-api/internal/httpapi/middleware"
+	"github.com/M-Arthur/order-food-api/internal/ratelimit"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog"
@@ -16,14 +18,57 @@ import (
 
 // RouterConfig centralises configuration for the router
 type RouterConfig struct {
-	Logger zerolog.Logger
-	Deps   *bootstrap.Dependencies
-	APIKey string
+	Logger     zerolog.Logger
+	Deps       *bootstrap.Dependencies
+	RateLimits RateLimits
+}
+
+// RateLimits are the request limits per route group. Zero limits disable
+// limiting for that group.
+type RateLimits struct {
+	// Public covers unauthenticated reads, counted per client IP.
+	Public ratelimit.Limit
+	// Order covers POST /order, counted per client, to slow down abuse and
+	// coupon guessing.
+	Order ratelimit.Limit
+	// Authenticated covers the other protected routes, counted per client.
+	Authenticated ratelimit.Limit
+	// Auth covers every protected route before authentication, counted per
+	// client IP, so callers with bad or missing credentials are throttled.
+	Auth ratelimit.Limit
 }
 
 // NewRouter builds the HTTP router with middleware and routes
 func NewRouter(cfg RouterConfig) http.Handler {
 	r := chi.NewRouter()
+	// Write routes accept an API key or, when configured, a bearer token
+	authn := middleware.APIKeys(cfg.Deps.Services.APIKey)
+	if cfg.Deps.Infra.JWT != nil {
+		authn = middleware.AnyOf(authn, middleware.BearerJWT(cfg.Deps.Infra.JWT))
+	}
+	requireAuth := middleware.RequireAuth(authn)
+
+	// Each protected route declares the permission it needs; API keys hold
+	// permissions as scopes, bearer tokens get them from their roles.
+	policy := domain.DefaultPolicy()
+	can := func(perm domain.APIScope) func(http.Handler) http.Handler {
+		return middleware.RequirePermission(policy, perm)
+	}
+	// Customers may read their own record and history
+	canOrSelf := func(perm domain.APIScope) func(http.Handler) http.Handler {
+		return middleware.RequirePermissionOrSelf(policy, perm, "customerId")
+	}
+
+	// Protected routes are limited per IP before authentication, so failed
+	// attempts count too, and per client after it
+	limitStore := cfg.Deps.Infra.RateLimit
+	if limitStore == nil {
+		limitStore = ratelimit.NewMemoryStore(nil)
+	}
+	authnLimit := middleware.RateLimit(limitStore, "auth", cfg.RateLimits.Auth, middleware.ClientOrIPKey)
+	publicLimit := middleware.RateLimit(limitStore, "public", cfg.RateLimits.Public, middleware.ClientOrIPKey)
+	orderLimit := middleware.RateLimit(limitStore, "order", cfg.RateLimits.Order, middleware.ClientOrIPKey)
+	authLimit := middleware.RateLimit(limitStore, "authenticated", cfg.RateLimits.Authenticated, middleware.ClientOrIPKey)
 
 	// Swagger UI route
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
@@ -49,11 +94,37 @@ func NewRouter(cfg RouterConfig) http.Handler {
 			middleware.RequestLogger,
 		)
 		// swagger:route GET /product product listProducts
-		api.Get("/product", cfg.Deps.Handlers.Product.ListProducts)
+		api.With(publicLimit).Get("/product", cfg.Deps.Handlers.Product.ListProducts)
 		// swagger:route GET /product/{productId} product getProduct
-		api.Get("/product/{productId}", cfg.Deps.Handlers.Product.GetProductByID)
+		api.With(publicLimit).Get("/product/{productId}", cfg.Deps.Handlers.Product.GetProductByID)
 		// swagger:route POST /order order placeOrder
-		api.With(middleware.APIKeyAuth(cfg.APIKey)).Post("/order", cfg.Deps.Handlers.Order.PlaceOrder)
+		api.With(authnLimit, requireAuth, orderLimit, can(domain.ScopeOrderWrite)).Post("/order", cfg.Deps.Handlers.Order.PlaceOrder)
+		// swagger:route POST /order/{orderId}/cancel order cancelOrder
+		api.With(authnLimit, requireAuth, authLimit, can(domain.ScopeOrderManage)).Post("/order/{orderId}/cancel", cfg.Deps.Handlers.Order.CancelOrder)
+		// Store-less routes act on the main store
+		api.With(publicLimit).Get("/store/status", cfg.Deps.Handlers.Store.GetStatus)
+		api.With(authnLimit, requireAuth, authLimit, can(domain.ScopeStoreAdmin)).Put("/store/pause", cfg.Deps.Handlers.Store.SetPause)
+
+		// swagger:route GET /admin/promo-codes admin getPromoCodeSet
+		api.With(authnLimit, requireAuth, authLimit, can(domain.ScopePromoAdmin)).Get("/admin/promo-codes", cfg.Deps.Handlers.Promo.GetCodeSet)
+
+		api.Route("/customer", func(customer chi.Router) {
+			// swagger:route POST /customer customer createCustomer
+			customer.With(authnLimit, requireAuth, authLimit, can(domain.ScopeCustomerWrite)).Post("/", cfg.Deps.Handlers.Customer.CreateCustomer)
+			// swagger:route GET /customer/{customerId} customer getCustomer
+			customer.With(authnLimit, requireAuth, authLimit, canOrSelf(domain.ScopeCustomerRead)).Get("/{customerId}", cfg.Deps.Handlers.Customer.GetCustomer)
+			// swagger:route GET /customer/{customerId}/order customer listCustomerOrders
+			customer.With(authnLimit, requireAuth, authLimit, canOrSelf(domain.ScopeCustomerRead)).Get("/{customerId}/order", cfg.Deps.Handlers.Customer.ListOrders)
+		})
+
+		api.Route("/store/{storeId}", func(store chi.Router) {
+			// swagger:route GET /store/{storeId}/product product listStoreProducts
+			store.With(publicLimit).Get("/product", cfg.Deps.Handlers.Product.ListStoreProducts)
+			// swagger:route GET /store/{storeId}/status store getStoreStatus
+			store.With(publicLimit).Get("/status", cfg.Deps.Handlers.Store.GetStatus)
+			// swagger:route PUT /store/{storeId}/pause store setStorePause
+			store.With(authnLimit, requireAuth, authLimit, can(domain.ScopeStoreAdmin)).Put("/pause", cfg.Deps.Handlers.Store.SetPause)
+		})
 	})
 
 	// Serve Swagger UI at /swagger/*, pointing to /swagger/doc.json
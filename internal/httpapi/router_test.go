@@ -0,0 +1,61 @@
+package httpapi_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/M-Arthur/order-food-api/internal/bootstrap"
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/httpapi"
+	"github.com/M-Arthur/order-food-api/internal/ratelimit"
+	"github.com/M-Arthur/order-food-api/internal/service"
+	"github.com/rs/zerolog"
+)
+
+// unknownKeys implements service.APIKeyService and knows no key
+type unknownKeys struct{}
+
+func (unknownKeys) Authenticate(context.Context, string) (*domain.APIClient, error) {
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+// complie-time safety
+var _ service.APIKeyService = unknownKeys{}
+
+func TestRouter_BadCredentialsAreRateLimited(t *testing.T) {
+	deps := &bootstrap.Dependencies{
+		Infra:    bootstrap.Infra{RateLimit: ratelimit.NewMemoryStore(nil)},
+		Services: bootstrap.Services{APIKey: unknownKeys{}},
+	}
+	r := httpapi.NewRouter(httpapi.RouterConfig{
+		Logger:     zerolog.New(io.Discard),
+		Deps:       deps,
+		RateLimits: httpapi.RateLimits{Auth: ratelimit.PerMinute(6, 3)},
+	})
+
+	do := func(remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/order", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("api_key", "wrong-key")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	for i := range 3 {
+		if got := do("10.0.0.1:1234"); got != http.StatusUnauthorized {
+			t.Fatalf("attempt %d status = %d, want %d", i+1, got, http.StatusUnauthorized)
+		}
+	}
+	if got := do("10.0.0.1:1234"); got != http.StatusTooManyRequests {
+		t.Fatalf("attempt after the burst status = %d, want %d", got, http.StatusTooManyRequests)
+	}
+
+	// Other IPs are not affected
+	if got := do("10.0.0.2:1234"); got != http.StatusUnauthorized {
+		t.Fatalf("other IP status = %d, want %d", got, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,29 @@
+package shared
+
+import "net/http"
+
+// Problem is an RFC 9457 problem details body.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// WriteProblem writes an application/problem+json response. problemType
+// should be a stable URI identifying the kind of problem; "about:blank"
+// is used when empty.
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, problemType, detail string) {
+	if problemType == "" {
+		problemType = "about:blank"
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	writeJSONBody(w, r, status, Problem{
+		Type:     problemType,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
@@ -10,6 +10,10 @@ import (
 // Helper to write JSON response
 func WriteJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writeJSONBody(w, r, status, v)
+}
+
+func writeJSONBody(w http.ResponseWriter, r *http.Request, status int, v any) {
 	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(v); err != nil {
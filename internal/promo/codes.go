@@ -0,0 +1,94 @@
+// Package promo validates promo codes against code sets loaded from files or
+// indexes. It also holds the pipeline of cmd/promo-loader (see Extract),
+// which extracts the valid codes from large input files.
+package promo
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+// ErrMalformedCodeFile is returned for code files that cannot be the output
+// of cmd/promo-loader, such as empty files or files with lines that are
+// not codes. It is domain.ErrMalformedPromoCodes, which cmd/promo-import
+// returns for the same input.
+var ErrMalformedCodeFile = domain.ErrMalformedPromoCodes
+
+// CodeSet is an in-memory set of valid promo codes. Codes are matched
+// exactly, ignoring surrounding whitespace.
+type CodeSet struct {
+	codes map[string]struct{}
+}
+
+// NewCodeSet builds a CodeSet from codes; blank codes are skipped.
+func NewCodeSet(codes ...string) *CodeSet {
+	s := &CodeSet{codes: make(map[string]struct{}, len(codes))}
+	for _, c := range codes {
+		if c = strings.TrimSpace(c); c != "" {
+			s.codes[c] = struct{}{}
+		}
+	}
+	return s
+}
+
+// LoadCodeFile reads a file with one code per line, as written by
+// cmd/promo-loader. Blank lines are skipped; a file without codes, or with
+// a line that cannot be a code, fails with ErrMalformedCodeFile.
+func LoadCodeFile(path string) (*CodeSet, error) {
+	s, _, err := readCodeFile(path)
+	return s, err
+}
+
+// readCodeFile is LoadCodeFile that also returns the hex SHA-256 of the
+// file, computed in the same pass.
+func readCodeFile(path string) (*CodeSet, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("open promo codes file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	h := sha256.New()
+	s := NewCodeSet()
+	scanner := bufio.NewScanner(io.TeeReader(f, h))
+	line := 0
+	for scanner.Scan() {
+		line++
+		c := strings.TrimSpace(scanner.Text())
+		if c == "" {
+			continue
+		}
+		if !domain.IsPromoCode(c) {
+			return nil, "", fmt.Errorf("%w: %s line %d: %q is not a promo code", ErrMalformedCodeFile, path, line, c)
+		}
+		s.codes[c] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", fmt.Errorf("read promo codes file %s: %w", path, err)
+	}
+	if s.Len() == 0 {
+		return nil, "", fmt.Errorf("%w: %s has no codes", ErrMalformedCodeFile, path)
+	}
+
+	return s, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (s *CodeSet) IsValid(_ context.Context, code string) (bool, error) {
+	_, ok := s.codes[strings.TrimSpace(code)]
+	return ok, nil
+}
+
+// Len returns the number of codes in the set.
+func (s *CodeSet) Len() int {
+	return len(s.codes)
+}
@@ -0,0 +1,187 @@
+package promo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrNotSorted is returned by Diff for inputs that are not sorted byte-wise
+// with unique lines, as Extract writes them.
+var ErrNotSorted = errors.New("input is not sorted")
+
+// DiffOptions configure Diff. Old and New are required.
+type DiffOptions struct {
+	// Old and New are outputs of Extract, typically of two runs a week
+	// apart.
+	Old string
+	New string
+	// AddedOutput and RemovedOutput, if set, get the codes only in New and
+	// only in Old, one per line, in byte order.
+	AddedOutput   string
+	RemovedOutput string
+	// Changes, if set, gets "+code" for each added and "-code" for each
+	// removed code, in byte order, as they are found.
+	Changes io.Writer
+
+	FS  FS               // nil → OSFS
+	Now func() time.Time // nil → time.Now
+}
+
+// DiffReport counts the codes of a Diff.
+type DiffReport struct {
+	Old        string    `json:"old"`
+	New        string    `json:"new"`
+	Added      int64     `json:"added"`
+	Removed    int64     `json:"removed"`
+	Unchanged  int64     `json:"unchanged"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// Diff compares two sorted code files in one pass, merging them as the sort
+// strategy merges filtered files, so neither is loaded in memory. Progress
+// is logged with the logger of ctx.
+func Diff(ctx context.Context, opts DiffOptions) (*DiffReport, error) {
+	if opts.Old == "" || opts.New == "" {
+		return nil, fmt.Errorf("old and new files are required")
+	}
+	l := newLoader(opts.FS, nil, opts.Now)
+	r := &DiffReport{Old: opts.Old, New: opts.New, StartedAt: l.now().UTC()}
+	if err := l.diff(ctx, opts, r); err != nil {
+		return nil, err
+	}
+	r.FinishedAt = l.now().UTC()
+	return r, nil
+}
+
+// Indexes of the diffed files in the merge.
+const (
+	diffOld = iota
+	diffNew
+)
+
+func (l *loader) diff(ctx context.Context, opts DiffOptions, r *DiffReport) error {
+	inputs := []string{diffOld: opts.Old, diffNew: opts.New}
+	h, closeRuns, err := l.openRuns(inputs)
+	if err != nil {
+		return err
+	}
+	defer closeRuns()
+
+	added, err := l.createOptionalSink(opts.AddedOutput)
+	if err != nil {
+		return err
+	}
+	defer added.discard()
+	removed, err := l.createOptionalSink(opts.RemovedOutput)
+	if err != nil {
+		return err
+	}
+	defer removed.discard()
+	var changes *bufio.Writer
+	if opts.Changes != nil {
+		changes = bufio.NewWriterSize(opts.Changes, buf1MB)
+	}
+
+	p := l.startProgress(ctx, "diff", "", l.totalSize(inputs))
+	var (
+		last      [2]string
+		processed int64
+		bytes     int64
+	)
+	for h.Len() > 0 {
+		code := h[0].line
+
+		var in [2]bool
+		for h.Len() > 0 && h[0].line == code {
+			c := h[0]
+			// A line out of order surfaces after a larger one of the same
+			// file, since the heap always yields the smallest line.
+			if in[c.file] || (last[c.file] != "" && code <= last[c.file]) {
+				return fmt.Errorf("%w: %s: %q after %q", ErrNotSorted, inputs[c.file], code, last[c.file])
+			}
+			in[c.file] = true
+			last[c.file] = code
+			if err := h.advance(); err != nil {
+				return err
+			}
+
+			processed++
+			bytes += int64(len(code)) + 1
+			if processed%ctxCheckEvery == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				p.update(processed, r.Added+r.Removed, bytes)
+			}
+		}
+
+		var (
+			sink codeSink
+			mark byte
+		)
+		switch {
+		case in[diffOld] && in[diffNew]:
+			r.Unchanged++
+			continue
+		case in[diffNew]:
+			r.Added++
+			sink, mark = added, '+'
+		default:
+			r.Removed++
+			sink, mark = removed, '-'
+		}
+		if err := sink.WriteCode(code); err != nil {
+			return fmt.Errorf("write diff: %w", err)
+		}
+		if changes != nil {
+			if err := changes.WriteByte(mark); err != nil {
+				return fmt.Errorf("write changes: %w", err)
+			}
+			if _, err := changes.WriteString(code + "\n"); err != nil {
+				return fmt.Errorf("write changes: %w", err)
+			}
+		}
+	}
+
+	if changes != nil {
+		if err := changes.Flush(); err != nil {
+			return fmt.Errorf("write changes: %w", err)
+		}
+	}
+	if err := added.Close(); err != nil {
+		return fmt.Errorf("write added: %w", err)
+	}
+	if err := removed.Close(); err != nil {
+		return fmt.Errorf("write removed: %w", err)
+	}
+
+	p.finish(processed, r.Added+r.Removed, bytes)
+	return nil
+}
+
+// createOptionalSink is createFileSink for an output that may not be
+// wanted: for an empty path, codes are dropped.
+func (l *loader) createOptionalSink(path string) (codeSink, error) {
+	if path == "" {
+		return nopSink{}, nil
+	}
+	return l.createFileSink(path)
+}
+
+// nopSink drops the codes written to it.
+type nopSink struct{}
+
+func (nopSink) WriteCode(string) error { return nil }
+func (nopSink) Close() error           { return nil }
+func (nopSink) discard()               {}
+
+// WriteDiffReport writes r to path as indented JSON, through fsys
+// (nil → OSFS).
+func WriteDiffReport(fsys FS, path string, r *DiffReport) error {
+	return newLoader(fsys, nil, nil).writeJSON(path, r)
+}
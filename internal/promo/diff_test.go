@@ -0,0 +1,130 @@
+package promo_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/M-Arthur/order-food-api/internal/promo"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name        string
+		old, new    []string
+		want        promo.DiffReport
+		wantAdded   []string
+		wantRemoved []string
+		wantChanges string
+		wantErr     error
+	}{
+		{
+			name:        "added and removed",
+			old:         []string{"AAAAAAAA", "BBBBBBBB", "CCCCCCCC", "EEEEEEEE"},
+			new:         []string{"AAAAAAAA", "CCCCCCCC", "DDDDDDDD", "FFFFFFFF"},
+			want:        promo.DiffReport{Added: 2, Removed: 2, Unchanged: 2},
+			wantAdded:   []string{"DDDDDDDD", "FFFFFFFF"},
+			wantRemoved: []string{"BBBBBBBB", "EEEEEEEE"},
+			wantChanges: "-BBBBBBBB\n+DDDDDDDD\n-EEEEEEEE\n+FFFFFFFF\n",
+		},
+		{
+			name:        "identical",
+			old:         []string{"AAAAAAAA", "BBBBBBBB"},
+			new:         []string{"AAAAAAAA", "BBBBBBBB"},
+			want:        promo.DiffReport{Unchanged: 2},
+			wantChanges: "",
+		},
+		{
+			name:        "empty old",
+			old:         nil,
+			new:         []string{"AAAAAAAA", "BBBBBBBB"},
+			want:        promo.DiffReport{Added: 2},
+			wantAdded:   []string{"AAAAAAAA", "BBBBBBBB"},
+			wantChanges: "+AAAAAAAA\n+BBBBBBBB\n",
+		},
+		{
+			name:    "unsorted new",
+			old:     []string{"AAAAAAAA"},
+			new:     []string{"CCCCCCCC", "BBBBBBBB"},
+			wantErr: promo.ErrNotSorted,
+		},
+		{
+			name:    "duplicate in old",
+			old:     []string{"AAAAAAAA", "AAAAAAAA"},
+			new:     []string{"AAAAAAAA"},
+			wantErr: promo.ErrNotSorted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			write := func(name string, codes []string) string {
+				path := filepath.Join(dir, name)
+				content := ""
+				if len(codes) > 0 {
+					content = strings.Join(codes, "\n") + "\n"
+				}
+				if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+					t.Fatalf("write %s: %v", name, err)
+				}
+				return path
+			}
+			opts := promo.DiffOptions{
+				Old:           write("old.txt", tt.old),
+				New:           write("new.txt", tt.new),
+				AddedOutput:   filepath.Join(dir, "added.txt"),
+				RemovedOutput: filepath.Join(dir, "removed.txt"),
+			}
+			var changes strings.Builder
+			opts.Changes = &changes
+
+			got, err := promo.Diff(context.Background(), opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Diff() error = %v, want %v", err, tt.wantErr)
+				}
+				// No partial lists are left behind
+				for _, p := range []string{opts.AddedOutput, opts.RemovedOutput} {
+					if _, err := os.Stat(p); !errors.Is(err, os.ErrNotExist) {
+						t.Errorf("%s exists after a failed diff", filepath.Base(p))
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+
+			if got.Added != tt.want.Added || got.Removed != tt.want.Removed || got.Unchanged != tt.want.Unchanged {
+				t.Errorf("Diff() = %+v, want %+v", got, tt.want)
+			}
+			if added := promo.ReadLines(t, opts.AddedOutput); !slices.Equal(added, tt.wantAdded) {
+				t.Errorf("added = %q, want %q", added, tt.wantAdded)
+			}
+			if removed := promo.ReadLines(t, opts.RemovedOutput); !slices.Equal(removed, tt.wantRemoved) {
+				t.Errorf("removed = %q, want %q", removed, tt.wantRemoved)
+			}
+			if changes.String() != tt.wantChanges {
+				t.Errorf("changes = %q, want %q", changes.String(), tt.wantChanges)
+			}
+		})
+	}
+}
+
+func TestDiff_MissingFile(t *testing.T) {
+	dir := t.TempDir()
+	newPath := filepath.Join(dir, "new.txt")
+	if err := os.WriteFile(newPath, []byte("AAAAAAAA\n"), 0o600); err != nil {
+		t.Fatalf("write new: %v", err)
+	}
+
+	_, err := promo.Diff(context.Background(), promo.DiffOptions{Old: filepath.Join(dir, "missing.txt"), New: newPath})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Diff() error = %v, want %v", err, os.ErrNotExist)
+	}
+}
@@ -0,0 +1,17 @@
+package promo
+
+// The fixtures and naive reading of the rules of the internal tests, for the
+// tests of package promo_test.
+type FixtureSpec = fixtureSpec
+
+const (
+	FixturePlain = fixturePlain
+	FixtureGzip  = fixtureGzip
+	FixtureMixed = fixtureMixed
+)
+
+var (
+	WriteFixtures   = writeFixtures
+	NaiveValidCodes = naiveValidCodes
+	ReadLines       = readLines
+)
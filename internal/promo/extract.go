@@ -0,0 +1,524 @@
+package promo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
+)
+
+// Strategies accepted by Options.Strategy.
+const (
+	// StrategyHash partitions codes into buckets by hash and counts each
+	// bucket in memory.
+	StrategyHash = "hash"
+	// StrategySort sorts each filtered file on disk and merges the files.
+	StrategySort = "sort"
+)
+
+const (
+	// DefaultBuckets keeps the open bucket files per input well under common
+	// file descriptor limits.
+	DefaultBuckets = 64
+	// DefaultMemoryBudget is the memory for sorting a chunk or counting a
+	// bucket.
+	DefaultMemoryBudget = 256 << 20
+
+	buf1MB = 1 << 20
+)
+
+// Options configure Extract. Files, TmpDir, Output and Rules are required.
+type Options struct {
+	// Files are the input files, one code per line, plain or compressed
+	// (see openInput). StdinPath reads one of them from Stdin.
+	Files []string
+	// TmpDir holds intermediate files and the manifest that lets a re-run
+	// resume (see runManifest).
+	TmpDir string
+	Output string
+	// CountsOutput, if set, gets "code\tfiles\toccurrences" per valid code
+	// (see mergeSortedFiles).
+	CountsOutput string
+	Rules        Rules
+	// Strategy is StrategyHash (default) or StrategySort. Both write the
+	// same output.
+	Strategy string
+	// Buckets is the number of buckets of StrategyHash (0 → DefaultBuckets).
+	Buckets int
+	// MemoryBudget is the bytes for sorting a chunk or counting a bucket
+	// (0 → DefaultMemoryBudget).
+	MemoryBudget int64
+	// Parallelism is the number of files filtered at once (0 → 1).
+	Parallelism int
+
+	FS    FS               // nil → OSFS
+	Stdin io.Reader        // nil → os.Stdin
+	Now   func() time.Time // nil → time.Now
+}
+
+// Extract writes the codes of opts.Files that satisfy opts.Rules to
+// opts.Output, sorted byte-wise, and returns the report of the run. Stages
+// completed by an earlier run with the same options are reused; progress is
+// logged with the logger of ctx.
+func Extract(ctx context.Context, opts Options) (*Report, error) {
+	if len(opts.Files) == 0 {
+		return nil, fmt.Errorf("no input files provided")
+	}
+	if err := validateInputs(opts.Files); err != nil {
+		return nil, err
+	}
+	if err := opts.Rules.Validate(len(opts.Files)); err != nil {
+		return nil, fmt.Errorf("rules: %w", err)
+	}
+	if opts.TmpDir == "" {
+		return nil, fmt.Errorf("tmp dir is empty")
+	}
+	if opts.Output == "" {
+		return nil, fmt.Errorf("output is empty")
+	}
+	if opts.Strategy == "" {
+		opts.Strategy = StrategyHash
+	}
+	if opts.Buckets <= 0 {
+		opts.Buckets = DefaultBuckets
+	}
+	if opts.MemoryBudget <= 0 {
+		opts.MemoryBudget = DefaultMemoryBudget
+	}
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = 1
+	}
+
+	l := newLoader(opts.FS, opts.Stdin, opts.Now)
+	if err := l.fs.MkdirAll(opts.TmpDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create tmp dir: %w", err)
+	}
+
+	switch opts.Strategy {
+	case StrategyHash:
+		return l.extractHashed(ctx, opts)
+	case StrategySort:
+		return l.extractSorted(ctx, opts)
+	default:
+		return nil, fmt.Errorf("strategy must be hash or sort, got %q", opts.Strategy)
+	}
+}
+
+// loader runs the pipeline against a filesystem, stdin and clock.
+type loader struct {
+	fs    FS
+	stdin io.Reader
+	now   func() time.Time
+}
+
+func newLoader(fsys FS, stdin io.Reader, now func() time.Time) *loader {
+	if fsys == nil {
+		fsys = OSFS{}
+	}
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &loader{fs: fsys, stdin: stdin, now: now}
+}
+
+// extractSorted is Extract with StrategySort:
+//   - each input file is filtered into a raw file (per-line rules only)
+//   - each raw file is sorted byte-wise, spilling to TmpDir beyond MemoryBudget
+//   - the sorted files are merged, keeping codes found in enough of them
+func (l *loader) extractSorted(ctx context.Context, opts Options) (*Report, error) {
+	startedAt := l.now()
+	files, tmpDir := opts.Files, opts.TmpDir
+
+	log := zerolog.Ctx(ctx)
+	m := l.loadManifest(ctx, tmpDir, runParams(StrategySort, files, opts.Rules, 0), files)
+	merge := mergeStage(opts.Output, opts.CountsOutput)
+	if m.done(merge) {
+		log.Info().Str("stage", "resume").Str("output", opts.Output).Msg("output is up to date")
+		return l.reportOf(m, StrategySort, files, opts.Output, merge, startedAt)
+	}
+
+	rawPaths := make([]string, len(files))
+	sortedPaths := make([]string, len(files))
+	for i := range files {
+		rawPaths[i] = filepath.Join(tmpDir, fmt.Sprintf("file_%d.raw", i+1))
+		sortedPaths[i] = filepath.Join(tmpDir, fmt.Sprintf("file_%d.sorted", i+1))
+	}
+
+	// A file whose sorted output is intact needs neither stage again.
+	var toFilter, toSort []int
+	for i := range files {
+		if m.done(stageName("sort", i)) {
+			log.Info().Str("stage", "resume").Str("file", sortedPaths[i]).Msg("reusing sorted file")
+			continue
+		}
+		toSort = append(toSort, i)
+		if m.done(stageName("filter", i)) {
+			log.Info().Str("stage", "resume").Str("file", rawPaths[i]).Msg("reusing filtered file")
+			continue
+		}
+		toFilter = append(toFilter, i)
+	}
+
+	// 1) Filter each input file into tmp raw files (per-line rules only).
+	openRaw := func(i int) (codeSink, error) {
+		return l.createFileSink(rawPaths[i])
+	}
+	filtered := func(i int, stats FileStats) error {
+		return m.record(stageName("filter", i), []string{files[i]}, []string{rawPaths[i]}, stats)
+	}
+	if err := l.filterAllFiles(ctx, files, toFilter, openRaw, filtered, opts.Rules, opts.Parallelism); err != nil {
+		return nil, fmt.Errorf("filter stage: %w", err)
+	}
+
+	// 2) Sort each raw file byte-wise, spilling to tmpDir beyond the budget.
+	for _, i := range toSort {
+		if err := l.externalSort(ctx, rawPaths[i], sortedPaths[i], tmpDir, opts.MemoryBudget); err != nil {
+			return nil, fmt.Errorf("sort stage: %w", err)
+		}
+		if err := m.record(stageName("sort", i), []string{rawPaths[i]}, []string{sortedPaths[i]}, nil); err != nil {
+			return nil, fmt.Errorf("sort stage: %w", err)
+		}
+	}
+
+	// 3) Merge sorted files, keeping codes found in enough of them.
+	stats, err := l.mergeSortedFiles(ctx, sortedPaths, opts.Output, opts.CountsOutput, opts.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("merge stage: %w", err)
+	}
+	if err := m.record(merge, sortedPaths, outputsOf(opts.Output, opts.CountsOutput), stats); err != nil {
+		return nil, fmt.Errorf("merge stage: %w", err)
+	}
+
+	return l.reportOf(m, StrategySort, files, opts.Output, merge, startedAt)
+}
+
+// reportOf builds the report of a run whose last stage, merge, recorded its
+// mergeStats.
+func (l *loader) reportOf(m *runManifest, strategy string, files []string, output, merge string, startedAt time.Time) (*Report, error) {
+	var merged mergeStats
+	if err := m.stats(merge, &merged); err != nil {
+		return nil, fmt.Errorf("report: %w", err)
+	}
+	r, err := buildReport(m, strategy, files, output, merged, startedAt, l.now())
+	if err != nil {
+		return nil, fmt.Errorf("report: %w", err)
+	}
+	return r, nil
+}
+
+// codeSink receives the codes kept from one input file. Close commits them;
+// discard drops them after a failure.
+type codeSink interface {
+	WriteCode(code string) error
+	Close() error
+	discard()
+}
+
+// fileSink writes codes to a file, one per line. Codes go to a temporary
+// file next to the target path, which Close renames over it, so neither
+// readers nor a resumed run ever see a half-written file.
+type fileSink struct {
+	fs     FS
+	f      File
+	w      *bufio.Writer
+	final  string // the path f is renamed to on Close
+	sync   bool   // sync f before renaming it, for files served from disk
+	closed bool
+}
+
+func (l *loader) createFileSink(path string) (*fileSink, error) {
+	return l.createFileSinkSize(path, buf1MB)
+}
+
+func (l *loader) createFileSinkSize(path string, bufSize int) (*fileSink, error) {
+	if err := l.fs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
+	}
+	f, err := l.fs.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("create output: %w", err)
+	}
+	return &fileSink{fs: l.fs, f: f, w: bufio.NewWriterSize(f, bufSize), final: path}, nil
+}
+
+func (s *fileSink) WriteCode(code string) error {
+	if _, err := s.w.WriteString(code); err != nil {
+		return err
+	}
+	return s.w.WriteByte('\n')
+}
+
+func (s *fileSink) Close() error {
+	if err := s.w.Flush(); err != nil {
+		s.discard()
+		return err
+	}
+	if s.sync {
+		if err := s.f.Sync(); err != nil {
+			s.discard()
+			return err
+		}
+	}
+	if err := s.f.Close(); err != nil {
+		s.discard()
+		return err
+	}
+	s.closed = true
+	if err := s.fs.Chmod(s.f.Name(), 0o644); err != nil {
+		_ = s.fs.Remove(s.f.Name())
+		return err
+	}
+	if err := s.fs.Rename(s.f.Name(), s.final); err != nil {
+		_ = s.fs.Remove(s.f.Name())
+		return err
+	}
+	return nil
+}
+
+// discard closes a sink that was not closed and removes its temporary
+// file. It is a no-op after Close.
+func (s *fileSink) discard() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	_ = s.f.Close()
+	_ = s.fs.Remove(s.f.Name())
+}
+
+// filterAllFiles runs filterFile for inputs[i] for each i in indexes, at
+// most parallelism at a time, writing the codes kept to the sink returned
+// by open(i) and calling done(i) with the file's stats once they are all
+// written.
+//
+// The first failure cancels the other files and is returned once they have
+// stopped; the sinks of files that did not complete are discarded.
+func (l *loader) filterAllFiles(
+	ctx context.Context,
+	inputs []string,
+	indexes []int,
+	open func(i int) (codeSink, error),
+	done func(i int, stats FileStats) error,
+	rules Rules,
+	parallelism int,
+) error {
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(parallelism)
+
+	for _, i := range indexes {
+		// Go blocks while parallelism files are in progress, so stop
+		// queueing as soon as one of them fails.
+		if gctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			if err := l.filterOne(gctx, inputs[i], i, open, done, rules); err != nil {
+				return fmt.Errorf("filter %s: %w", inputs[i], err)
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	// Nothing was queued if ctx was done from the start
+	return ctx.Err()
+}
+
+func (l *loader) filterOne(
+	ctx context.Context,
+	input string,
+	i int,
+	open func(i int) (codeSink, error),
+	done func(i int, stats FileStats) error,
+	rules Rules,
+) error {
+	sink, err := open(i)
+	if err != nil {
+		return err
+	}
+	stats, err := l.filterFile(ctx, input, sink, rules)
+	if err != nil {
+		sink.discard()
+		return err
+	}
+	if err := sink.Close(); err != nil {
+		return fmt.Errorf("close output: %w", err)
+	}
+	return done(i, stats)
+}
+
+// filterFile reads an input file (see openInput), normalises lines and
+// writes those that can be codes under rules to sink. It returns the file's
+// stats but for Duplicates.
+func (l *loader) filterFile(ctx context.Context, inputPath string, sink codeSink, rules Rules) (FileStats, error) {
+	in, err := l.openInput(inputPath)
+	if err != nil {
+		return FileStats{}, err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	stats := newFileStats(inputPath, in.format)
+	p := l.startProgress(ctx, "filter", inputPath, in.size)
+	lines := newLineReader(in, buf1MB)
+
+	for {
+		line, overlong, err := lines.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return FileStats{}, fmt.Errorf("read: %w", err)
+		}
+		stats.Lines++
+
+		if stats.Lines%ctxCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return FileStats{}, err
+			}
+			p.update(stats.Lines, stats.Kept, in.bytesRead())
+		}
+
+		if overlong {
+			stats.reject(RejectOverlong, line)
+			continue
+		}
+		code, reason := rules.check(string(line))
+		if reason != "" {
+			stats.reject(reason, line)
+			continue
+		}
+		if err := sink.WriteCode(code); err != nil {
+			return FileStats{}, fmt.Errorf("write output: %w", err)
+		}
+		stats.Kept++
+	}
+
+	stats.Bytes = in.bytesRead()
+	p.finish(stats.Lines, stats.Kept, stats.Bytes)
+	return stats, nil
+}
+
+// mergeSortedFiles:
+//   - inputs: sorted files, one code per line, in the order of the input files
+//   - output: file with the codes whose files satisfy rules
+//   - countsOutput: if not empty, a file with "code\tfiles\toccurrences" per
+//     output code, where files is the number of distinct inputs holding it
+//     and occurrences its number of lines across all of them
+//
+// Inputs are merged with a min-heap, so each line costs O(log k) for k
+// inputs.
+func (l *loader) mergeSortedFiles(ctx context.Context, inputs []string, output, countsOutput string, rules Rules) (mergeStats, error) {
+	stats := newMergeStats(len(inputs))
+
+	h, closeRuns, err := l.openRuns(inputs)
+	if err != nil {
+		return stats, err
+	}
+	defer closeRuns()
+
+	w, err := l.createFileSink(output)
+	if err != nil {
+		return stats, err
+	}
+	defer w.discard()
+
+	var counts *fileSink
+	if countsOutput != "" {
+		if counts, err = l.createFileSink(countsOutput); err != nil {
+			return stats, err
+		}
+		defer counts.discard()
+	}
+
+	p := l.startProgress(ctx, "merge", "", l.totalSize(inputs))
+	requiredBits := rules.requiredBits(len(inputs))
+	var processed, bytes int64
+
+	for h.Len() > 0 {
+		code := h[0].line
+
+		// The heap orders equal codes by input, so each input's copies of
+		// code come out together.
+		var match fileMatch
+		occurrences := 0
+		lastFile := -1
+		for h.Len() > 0 && h[0].line == code {
+			c := h[0]
+			occurrences++
+			if c.file != lastFile {
+				lastFile = c.file
+				match.files++
+				match.required |= requiredBits[c.file]
+			} else {
+				stats.Duplicates[c.file]++
+			}
+			if err := h.advance(); err != nil {
+				return stats, err
+			}
+
+			processed++
+			bytes += int64(len(code)) + 1
+			if processed%ctxCheckEvery == 0 {
+				if err := ctx.Err(); err != nil {
+					return stats, err
+				}
+				p.update(processed, stats.Valid, bytes)
+			}
+		}
+
+		if !rules.keep(match) {
+			continue
+		}
+		stats.Valid++
+		if err := w.WriteCode(code); err != nil {
+			return stats, fmt.Errorf("write output: %w", err)
+		}
+		if counts != nil {
+			if err := counts.WriteCode(fmt.Sprintf("%s\t%d\t%d", code, match.files, occurrences)); err != nil {
+				return stats, fmt.Errorf("write counts: %w", err)
+			}
+		}
+	}
+
+	if counts != nil {
+		if err := counts.Close(); err != nil {
+			return stats, fmt.Errorf("write counts: %w", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return stats, fmt.Errorf("write output: %w", err)
+	}
+
+	stats.lines = processed
+	p.finish(processed, stats.Valid, bytes)
+	return stats, nil
+}
+
+// totalSize is the combined size of paths, skipping any that cannot be
+// read; it only feeds progress ETAs.
+func (l *loader) totalSize(paths []string) int64 {
+	var size int64
+	for _, path := range paths {
+		if info, err := l.fs.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
@@ -0,0 +1,333 @@
+package promo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// bucketBufSize is the write buffer per bucket file.
+	bucketBufSize = 64 << 10
+	// codeMapOverhead approximates the heap cost of a code in the bucket map
+	// beyond its bytes: the string header, the value and the map's slot.
+	codeMapOverhead = 64
+)
+
+// extractHashed is Extract with StrategyHash, which produces the same
+// output and report as StrategySort without sorting whole files:
+//   - each input file is filtered into Buckets part files by a hash of the code
+//   - each bucket's parts are counted in a map, keeping codes whose files satisfy rules
+//   - the sorted codes of every bucket are merged into Output, and their
+//     counts into CountsOutput when it is set
+//
+// A bucket too large for MemoryBudget falls back to sorting its parts.
+func (l *loader) extractHashed(ctx context.Context, opts Options) (*Report, error) {
+	startedAt := l.now()
+	files, tmpDir, output, countsOutput := opts.Files, opts.TmpDir, opts.Output, opts.CountsOutput
+	rules, buckets, memBudget := opts.Rules, opts.Buckets, opts.MemoryBudget
+
+	log := zerolog.Ctx(ctx)
+	m := l.loadManifest(ctx, tmpDir, runParams(StrategyHash, files, rules, buckets), files)
+	merge := mergeStage(output, countsOutput)
+	if m.done(merge) {
+		log.Info().Str("stage", "resume").Str("output", output).Msg("output is up to date")
+		return l.reportOf(m, StrategyHash, files, output, merge, startedAt)
+	}
+
+	results := make([]string, buckets)
+	countResults := make([]string, 0, buckets)
+	var toCount []int
+	for b := range results {
+		results[b] = filepath.Join(tmpDir, fmt.Sprintf("bucket_%d.valid", b))
+		if countsOutput != "" {
+			countResults = append(countResults, filepath.Join(tmpDir, fmt.Sprintf("bucket_%d.counts", b)))
+		}
+		if m.done(stageName("bucket", b)) {
+			continue
+		}
+		toCount = append(toCount, b)
+	}
+	if reused := buckets - len(toCount); reused > 0 {
+		log.Info().Str("stage", "resume").Int("reused", reused).Int("buckets", buckets).Msg("reusing buckets")
+	}
+
+	// Parts are only needed for buckets still to count.
+	var toFilter []int
+	if len(toCount) > 0 {
+		for i := range files {
+			if m.done(stageName("filter", i)) {
+				log.Info().Str("stage", "resume").Str("file", files[i]).Msg("reusing bucket parts")
+				continue
+			}
+			toFilter = append(toFilter, i)
+		}
+	}
+
+	// 1) Filter each input file into per-bucket part files.
+	openBuckets := func(i int) (codeSink, error) {
+		return l.createBucketSink(tmpDir, i, buckets)
+	}
+	filtered := func(i int, stats FileStats) error {
+		return m.record(stageName("filter", i), []string{files[i]}, bucketParts(tmpDir, i, buckets), stats)
+	}
+	if err := l.filterAllFiles(ctx, files, toFilter, openBuckets, filtered, rules, opts.Parallelism); err != nil {
+		return nil, fmt.Errorf("filter stage: %w", err)
+	}
+
+	// 2) Find the codes in enough files, one bucket at a time.
+	partsOf := func(b int) []string {
+		parts := make([]string, len(files))
+		for i := range files {
+			parts[i] = bucketPartPath(tmpDir, i, b)
+		}
+		return parts
+	}
+	var total int64
+	for _, b := range toCount {
+		total += l.totalSize(partsOf(b))
+	}
+	p := l.startProgress(ctx, "bucket", "", total)
+	var lines, valid, bytes int64
+	for _, b := range toCount {
+		parts := partsOf(b)
+		var size int64
+		for _, part := range parts {
+			info, err := l.fs.Stat(part)
+			if err != nil {
+				return nil, fmt.Errorf("bucket stage: %w", err)
+			}
+			size += info.Size()
+		}
+		// Every line is at least MinLength bytes and a newline
+		estimate := size / int64(rules.MinLength+1)
+
+		outputs := []string{results[b]}
+		var counts string
+		if countsOutput != "" {
+			counts = countResults[b]
+			outputs = append(outputs, counts)
+		}
+		var (
+			stats mergeStats
+			err   error
+		)
+		if size+estimate*codeMapOverhead <= memBudget {
+			stats, err = l.countBucket(ctx, parts, estimate, results[b], counts, rules)
+		} else {
+			log.Warn().Str("stage", "bucket").Int("bucket", b).Int64("memory_budget", memBudget).
+				Msg("bucket does not fit in memory, sorting it")
+			stats, err = l.sortBucket(ctx, parts, tmpDir, memBudget, results[b], counts, rules)
+		}
+		if err == nil {
+			err = m.record(stageName("bucket", b), parts, outputs, stats)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bucket stage: bucket %d: %w", b, err)
+		}
+
+		lines += stats.lines
+		valid += stats.Valid
+		bytes += size
+		p.update(lines, valid, bytes)
+	}
+	p.finish(lines, valid, bytes)
+
+	// 3) Buckets are sorted but interleaved; merge them into one sorted output.
+	merged := newMergeStats(len(files))
+	for b := range results {
+		var stats mergeStats
+		if err := m.stats(stageName("bucket", b), &stats); err != nil {
+			return nil, fmt.Errorf("merge stage: %w", err)
+		}
+		merged.add(stats)
+	}
+	p = l.startProgress(ctx, "merge", "", l.totalSize(results))
+	if err := l.mergeRuns(ctx, results, output); err != nil {
+		return nil, fmt.Errorf("merge stage: %w", err)
+	}
+	if countsOutput != "" {
+		if err := l.mergeRuns(ctx, countResults, countsOutput); err != nil {
+			return nil, fmt.Errorf("merge stage: counts: %w", err)
+		}
+	}
+	if err := m.record(merge, append(results, countResults...), outputsOf(output, countsOutput), merged); err != nil {
+		return nil, fmt.Errorf("merge stage: %w", err)
+	}
+	p.finish(merged.Valid, merged.Valid, l.totalSize(results))
+
+	// The parts are as large as the filtered input; drop them once done.
+	for i := range files {
+		for _, p := range bucketParts(tmpDir, i, buckets) {
+			_ = l.fs.Remove(p)
+		}
+	}
+	return l.reportOf(m, StrategyHash, files, output, merge, startedAt)
+}
+
+// bucketPartPath is where the codes of input file i for bucket b go.
+func bucketPartPath(tmpDir string, i, b int) string {
+	return filepath.Join(tmpDir, fmt.Sprintf("file_%d.bucket_%d", i+1, b))
+}
+
+func bucketParts(tmpDir string, i, buckets int) []string {
+	parts := make([]string, buckets)
+	for b := range parts {
+		parts[b] = bucketPartPath(tmpDir, i, b)
+	}
+	return parts
+}
+
+// bucketSink spreads the codes of one input file over bucket part files.
+type bucketSink struct {
+	files []*fileSink
+}
+
+func (l *loader) createBucketSink(tmpDir string, i, buckets int) (*bucketSink, error) {
+	s := &bucketSink{files: make([]*fileSink, buckets)}
+	for b := range s.files {
+		f, err := l.createFileSinkSize(bucketPartPath(tmpDir, i, b), bucketBufSize)
+		if err != nil {
+			s.discard()
+			return nil, err
+		}
+		s.files[b] = f
+	}
+	return s, nil
+}
+
+func (s *bucketSink) WriteCode(code string) error {
+	return s.files[bucketOf(code, len(s.files))].WriteCode(code)
+}
+
+func (s *bucketSink) Close() error {
+	var first error
+	for _, f := range s.files {
+		if f == nil {
+			continue
+		}
+		if err := f.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (s *bucketSink) discard() {
+	for _, f := range s.files {
+		if f != nil {
+			f.discard()
+		}
+	}
+}
+
+// bucketOf hashes code with 32-bit FNV-1a, inlined to avoid an allocation
+// per line.
+func bucketOf(code string, buckets int) int {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(code); i++ {
+		h ^= uint32(code[i])
+		h *= prime32
+	}
+	return int(h % uint32(buckets))
+}
+
+// bucketCode is where a code in a bucket has been seen so far.
+type bucketCode struct {
+	lastFile    int // index of the last file it was seen in, plus one
+	occurrences int
+	match       fileMatch
+}
+
+// countBucket writes, sorted, the codes of parts whose files satisfy rules,
+// and their counts to countsOutput when it is set. Each part holds one input
+// file's codes for the bucket, in file order; lines sizes the map.
+func (l *loader) countBucket(ctx context.Context, parts []string, lines int64, output, countsOutput string, rules Rules) (mergeStats, error) {
+	stats := newMergeStats(len(parts))
+	requiredBits := rules.requiredBits(len(parts))
+	seen := make(map[string]bucketCode, lines)
+
+	for i, path := range parts {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		f, err := l.fs.Open(path)
+		if err != nil {
+			return stats, fmt.Errorf("open bucket part: %w", err)
+		}
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 0, buf1MB), buf1MB)
+		for sc.Scan() {
+			code := sc.Text()
+			stats.lines++
+			c := seen[code]
+			c.occurrences++
+			if c.lastFile != i+1 {
+				c.lastFile = i + 1
+				c.match.files++
+				c.match.required |= requiredBits[i]
+			} else {
+				stats.Duplicates[i]++
+			}
+			seen[code] = c
+		}
+		err = sc.Err()
+		_ = f.Close()
+		if err != nil {
+			return stats, fmt.Errorf("scan bucket part: %w", err)
+		}
+	}
+
+	valid := make([]string, 0, len(seen)/4)
+	for code, c := range seen {
+		if rules.keep(c.match) {
+			valid = append(valid, code)
+		}
+	}
+	stats.Valid = int64(len(valid))
+	if err := l.writeSortedChunk(valid, output); err != nil {
+		return stats, err
+	}
+	if countsOutput == "" {
+		return stats, nil
+	}
+
+	counts, err := l.createFileSink(countsOutput)
+	if err != nil {
+		return stats, err
+	}
+	for _, code := range valid {
+		c := seen[code]
+		if err := counts.WriteCode(fmt.Sprintf("%s\t%d\t%d", code, c.match.files, c.occurrences)); err != nil {
+			counts.discard()
+			return stats, fmt.Errorf("write counts: %w", err)
+		}
+	}
+	return stats, counts.Close()
+}
+
+// sortBucket is countBucket for buckets that do not fit in memory: each part
+// is sorted on disk and the parts are merged as in the sort strategy.
+func (l *loader) sortBucket(ctx context.Context, parts []string, tmpDir string, memBudget int64, output, countsOutput string, rules Rules) (mergeStats, error) {
+	sorted := make([]string, len(parts))
+	for i, p := range parts {
+		sorted[i] = p + ".sorted"
+		if err := l.externalSort(ctx, p, sorted[i], tmpDir, memBudget); err != nil {
+			return mergeStats{}, err
+		}
+	}
+	defer func() {
+		for _, p := range sorted {
+			_ = l.fs.Remove(p)
+		}
+	}()
+	return l.mergeSortedFiles(ctx, sorted, output, countsOutput, rules)
+}
@@ -0,0 +1,372 @@
+package promo_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/promo"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// complie-time checks
+var (
+	_ promo.FS = promo.OSFS{}
+	_ promo.FS = (*faultFS)(nil)
+)
+
+var strategies = []string{promo.StrategyHash, promo.StrategySort}
+
+// goldenRules are exercised by the inputs in testdata/golden: every reject
+// reason, case folding, CRLF endings and duplicates within a file.
+func goldenRules(t *testing.T) promo.Rules {
+	t.Helper()
+	rules := promo.DefaultRules()
+	allowed, err := promo.CompileAllowedChars("[A-Z0-9]")
+	if err != nil {
+		t.Fatalf("CompileAllowedChars() error = %v", err)
+	}
+	rules.AllowedChars = allowed
+	rules.Case = promo.CaseUpper
+	return rules
+}
+
+func TestExtract_Golden(t *testing.T) {
+	golden := filepath.Join("testdata", "golden")
+	files := []string{
+		filepath.Join(golden, "input_1.txt"),
+		filepath.Join(golden, "input_2.txt.gz"),
+		filepath.Join(golden, "input_3.txt"),
+	}
+	clock := func() time.Time { return time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+	for _, strategy := range strategies {
+		t.Run(strategy, func(t *testing.T) {
+			dir := t.TempDir()
+			report, err := promo.Extract(context.Background(), promo.Options{
+				Files:        files,
+				TmpDir:       filepath.Join(dir, "tmp"),
+				Output:       filepath.Join(dir, "valid.txt"),
+				CountsOutput: filepath.Join(dir, "counts.tsv"),
+				Rules:        goldenRules(t),
+				Strategy:     strategy,
+				Buckets:      4,
+				Now:          clock,
+			})
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			// The output path is the only part of the report that
+			// depends on where the test runs
+			report.Output = "valid.txt"
+			if err := promo.WriteReport(nil, filepath.Join(dir, "report.json"), report); err != nil {
+				t.Fatalf("WriteReport() error = %v", err)
+			}
+			if err := promo.WriteRejects(nil, filepath.Join(dir, "rejects.json"), report); err != nil {
+				t.Fatalf("WriteRejects() error = %v", err)
+			}
+
+			for got, want := range map[string]string{
+				"valid.txt":    "valid.txt",
+				"counts.tsv":   "counts.tsv",
+				"rejects.json": "rejects.json",
+				"report.json":  "report_" + strategy + ".json",
+			} {
+				compareGolden(t, filepath.Join(dir, got), filepath.Join(golden, want))
+			}
+		})
+	}
+}
+
+// compareGolden compares the file at path with the golden file, or
+// overwrites the golden file with it under -update.
+func compareGolden(t *testing.T, path, golden string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatalf("update %s: %v", golden, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read %s: %v (run with -update to create it)", golden, err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s differs from %s:\n%s\nwant:\n%s", filepath.Base(path), golden, got, want)
+	}
+}
+
+// randomInputs writes 2 to 4 input files, plain or gzip, of lines drawn
+// from a small pool so that codes repeat within and across files, with
+// mixed case, blank lines, spaces and lines of invalid lengths.
+func randomInputs(t *testing.T, rng *rand.Rand, dir string) ([]string, [][]string) {
+	t.Helper()
+	return promo.WriteFixtures(t, rng, dir, promo.FixtureSpec{
+		Files:     2 + rng.Intn(3),
+		Lines:     rng.Intn(500),
+		Pool:      20 + rng.Intn(200),
+		Alphabet:  "ABCDabcd0123-_ ",
+		MinLength: 0,
+		MaxLength: 11,
+		Compress:  promo.FixtureMixed,
+	})
+}
+
+// randomRules returns valid rules for files input files.
+func randomRules(t *testing.T, rng *rand.Rand, files int) promo.Rules {
+	t.Helper()
+	r := promo.Rules{
+		MinLength: 1 + rng.Intn(6),
+		Case:      []string{promo.CaseKeep, promo.CaseUpper, promo.CaseLower}[rng.Intn(3)],
+		MinFiles:  1 + rng.Intn(files),
+	}
+	r.MaxLength = r.MinLength + rng.Intn(6)
+	if rng.Intn(2) == 0 {
+		allowed, err := promo.CompileAllowedChars([]string{"[A-Z0-9]", "[a-d]", "[^ ]"}[rng.Intn(3)])
+		if err != nil {
+			t.Fatalf("CompileAllowedChars() error = %v", err)
+		}
+		r.AllowedChars = allowed
+	}
+	for i := range files {
+		if rng.Intn(4) == 0 {
+			r.RequiredFiles = append(r.RequiredFiles, i)
+		}
+	}
+	return r
+}
+
+func TestExtract_MatchesNaive(t *testing.T) {
+	property := func(seed int64) bool {
+		rng := rand.New(rand.NewSource(seed))
+		dir := t.TempDir()
+		files, content := randomInputs(t, rng, dir)
+		rules := randomRules(t, rng, len(files))
+		want, wantCounts := promo.NaiveValidCodes(rules, content)
+
+		for _, strategy := range strategies {
+			opts := promo.Options{
+				Files:        files,
+				TmpDir:       filepath.Join(dir, strategy, "tmp"),
+				Output:       filepath.Join(dir, strategy, "valid.txt"),
+				CountsOutput: filepath.Join(dir, strategy, "counts.tsv"),
+				Rules:        rules,
+				Strategy:     strategy,
+				Buckets:      1 + rng.Intn(8),
+				Parallelism:  1 + rng.Intn(3),
+			}
+			// Small budgets spill sorted runs and sort buckets on disk
+			if rng.Intn(2) == 0 {
+				opts.MemoryBudget = 256
+			}
+			report, err := promo.Extract(context.Background(), opts)
+			if err != nil {
+				t.Logf("seed %d, %s: Extract() error = %v", seed, strategy, err)
+				return false
+			}
+			got, gotCounts := promo.ReadLines(t, opts.Output), promo.ReadLines(t, opts.CountsOutput)
+			if !slices.Equal(got, want) || !slices.Equal(gotCounts, wantCounts) || report.ValidCodes != int64(len(want)) {
+				t.Logf("seed %d, %s, rules %s: got %q, want %q", seed, strategy, rules, got, want)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 50}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var errInjected = errors.New("injected failure")
+
+// faultFS is the OS filesystem failing op on the files whose base name
+// contains match. Writes fail on the files created by CreateTemp.
+type faultFS struct {
+	promo.OSFS
+	op    string // "open", "write", "rename" or "stat"
+	match string
+}
+
+func (f *faultFS) fails(op, name string) bool {
+	return op == f.op && strings.Contains(filepath.Base(name), f.match)
+}
+
+func (f *faultFS) Open(name string) (promo.File, error) {
+	if f.fails("open", name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errInjected}
+	}
+	return f.OSFS.Open(name)
+}
+
+func (f *faultFS) CreateTemp(dir, pattern string) (promo.File, error) {
+	file, err := f.OSFS.CreateTemp(dir, pattern)
+	if err != nil || !f.fails("write", pattern) {
+		return file, err
+	}
+	return faultFile{file}, nil
+}
+
+func (f *faultFS) Rename(oldpath, newpath string) error {
+	if f.fails("rename", newpath) {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errInjected}
+	}
+	return f.OSFS.Rename(oldpath, newpath)
+}
+
+func (f *faultFS) Stat(name string) (fs.FileInfo, error) {
+	if f.fails("stat", name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: errInjected}
+	}
+	return f.OSFS.Stat(name)
+}
+
+type faultFile struct {
+	promo.File
+}
+
+func (faultFile) Write([]byte) (int, error) { return 0, errInjected }
+
+func TestExtract_FaultyFS(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		fsys     *faultFS
+	}{
+		{name: "input cannot be opened", strategy: promo.StrategyHash, fsys: &faultFS{op: "open", match: "input_2"}},
+		{name: "bucket part write fails", strategy: promo.StrategyHash, fsys: &faultFS{op: "write", match: ".bucket_1"}},
+		{name: "bucket part stat fails", strategy: promo.StrategyHash, fsys: &faultFS{op: "stat", match: ".bucket_2"}},
+		{name: "sorted file write fails", strategy: promo.StrategySort, fsys: &faultFS{op: "write", match: ".sorted"}},
+		{name: "manifest cannot be saved", strategy: promo.StrategySort, fsys: &faultFS{op: "rename", match: "manifest"}},
+		{name: "output write fails", strategy: promo.StrategySort, fsys: &faultFS{op: "write", match: "valid.txt"}},
+		{name: "output cannot be renamed", strategy: promo.StrategyHash, fsys: &faultFS{op: "rename", match: "valid.txt"}},
+		{name: "counts cannot be renamed", strategy: promo.StrategySort, fsys: &faultFS{op: "rename", match: "counts.tsv"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files, _ := randomInputs(t, rand.New(rand.NewSource(1)), dir)
+			outDir := filepath.Join(dir, "out")
+			output := filepath.Join(outDir, "valid.txt")
+
+			_, err := promo.Extract(context.Background(), promo.Options{
+				Files:        files,
+				TmpDir:       filepath.Join(dir, "tmp"),
+				Output:       output,
+				CountsOutput: filepath.Join(outDir, "counts.tsv"),
+				Rules:        promo.Rules{MinLength: 1, MaxLength: 12, Case: promo.CaseKeep, MinFiles: 1},
+				Strategy:     tt.strategy,
+				Buckets:      4,
+				Parallelism:  2,
+				FS:           tt.fsys,
+			})
+			if !errors.Is(err, errInjected) {
+				t.Fatalf("Extract() error = %v, want %v", err, errInjected)
+			}
+			if _, err := os.Stat(output); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("output exists after a failed run")
+			}
+			for _, d := range []string{outDir, filepath.Join(dir, "tmp")} {
+				if left, _ := filepath.Glob(filepath.Join(d, ".*.tmp")); len(left) > 0 {
+					t.Errorf("temporary files left behind: %q", left)
+				}
+			}
+
+			// The same run without the fault succeeds
+			_, err = promo.Extract(context.Background(), promo.Options{
+				Files:        files,
+				TmpDir:       filepath.Join(dir, "tmp"),
+				Output:       output,
+				CountsOutput: filepath.Join(outDir, "counts.tsv"),
+				Rules:        promo.Rules{MinLength: 1, MaxLength: 12, Case: promo.CaseKeep, MinFiles: 1},
+				Strategy:     tt.strategy,
+				Buckets:      4,
+				Parallelism:  2,
+			})
+			if err != nil {
+				t.Fatalf("Extract() without fault error = %v", err)
+			}
+		})
+	}
+}
+
+func TestWriteReport_FaultyFS(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.json")
+	if err := promo.WriteReport(nil, path, &promo.Report{ValidCodes: 1}); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+
+	for _, fsys := range []*faultFS{{op: "write", match: "report.json"}, {op: "rename", match: "report.json"}} {
+		if err := promo.WriteReport(fsys, path, &promo.Report{ValidCodes: 2}); !errors.Is(err, errInjected) {
+			t.Fatalf("WriteReport() with failing %s error = %v, want %v", fsys.op, err, errInjected)
+		}
+		if after, _ := os.ReadFile(path); string(after) != string(before) {
+			t.Errorf("report changed by a failing %s", fsys.op)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+			t.Errorf("WriteReport() with failing %s left %d files, want only the report", fsys.op, len(entries))
+		}
+	}
+}
+
+func BenchmarkExtract(b *testing.B) {
+	files, _ := promo.WriteFixtures(b, rand.New(rand.NewSource(1)), b.TempDir(), promo.FixtureSpec{
+		Files:     3,
+		Lines:     200_000,
+		Alphabet:  "0123456789ABCDEF",
+		MinLength: 8,
+		MaxLength: 10,
+		Compress:  promo.FixtureGzip,
+	})
+	var size int64
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			b.Fatal(err)
+		}
+		size += info.Size()
+	}
+
+	for _, strategy := range strategies {
+		for _, parallelism := range []int{1, 3} {
+			b.Run(fmt.Sprintf("%s/parallelism=%d", strategy, parallelism), func(b *testing.B) {
+				b.SetBytes(size)
+				for b.Loop() {
+					dir := b.TempDir()
+					_, err := promo.Extract(context.Background(), promo.Options{
+						Files:       files,
+						TmpDir:      filepath.Join(dir, "tmp"),
+						Output:      filepath.Join(dir, "valid.txt"),
+						Rules:       promo.DefaultRules(),
+						Strategy:    strategy,
+						Parallelism: parallelism,
+					})
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
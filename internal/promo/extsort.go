@@ -0,0 +1,248 @@
+package promo
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// lineOverhead approximates the heap cost of a line beyond its bytes:
+	// the string header in the chunk slice.
+	lineOverhead = 16
+	// ctxCheckEvery is how many lines are read between cancellation checks.
+	ctxCheckEvery = 1 << 16
+)
+
+// externalSort sorts the lines of inputPath byte-wise into outputPath.
+// Lines are sorted in memory in chunks of at most memBudget bytes; when the
+// input does not fit in one chunk, each chunk is spilled to a run file in
+// tmpDir and the runs are merged with a min-heap. Run files are removed
+// before returning.
+func (l *loader) externalSort(ctx context.Context, inputPath, outputPath, tmpDir string, memBudget int64) (err error) {
+	in, err := l.fs.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("open sort input: %w", err)
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	p := l.startProgress(ctx, "sort", inputPath, l.totalSize([]string{inputPath}))
+
+	var runs []string
+	defer func() {
+		for _, r := range runs {
+			_ = l.fs.Remove(r)
+		}
+	}()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, buf1MB), buf1MB)
+
+	var (
+		chunk []string
+		size  int64
+		lines int64
+		bytes int64
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lines++
+		bytes += int64(len(line)) + 1
+		if lines%ctxCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			p.update(lines, lines, bytes)
+		}
+
+		chunk = append(chunk, line)
+		size += int64(len(line)) + lineOverhead
+		if size < memBudget {
+			continue
+		}
+
+		run := filepath.Join(tmpDir, fmt.Sprintf("%s.run%d", filepath.Base(inputPath), len(runs)+1))
+		runs = append(runs, run)
+		if err := l.writeSortedChunk(chunk, run); err != nil {
+			return err
+		}
+		chunk, size = chunk[:0], 0
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scan sort input: %w", err)
+	}
+
+	// Everything fitted in memory, so there is nothing to merge
+	if len(runs) == 0 {
+		if err := l.writeSortedChunk(chunk, outputPath); err != nil {
+			return err
+		}
+		p.finish(lines, lines, bytes)
+		return nil
+	}
+
+	if len(chunk) > 0 {
+		run := filepath.Join(tmpDir, fmt.Sprintf("%s.run%d", filepath.Base(inputPath), len(runs)+1))
+		runs = append(runs, run)
+		if err := l.writeSortedChunk(chunk, run); err != nil {
+			return err
+		}
+	}
+	chunk = nil
+
+	zerolog.Ctx(ctx).Info().Str("stage", "sort").Str("file", inputPath).Int("runs", len(runs)).Msg("merging sorted runs")
+	if err := l.mergeRuns(ctx, runs, outputPath); err != nil {
+		return err
+	}
+
+	p.finish(lines, lines, bytes)
+	return nil
+}
+
+// writeSortedChunk sorts lines in place and writes them to path, one per line.
+func (l *loader) writeSortedChunk(lines []string, path string) error {
+	slices.Sort(lines)
+
+	out, err := l.createFileSink(path)
+	if err != nil {
+		return fmt.Errorf("create sort run: %w", err)
+	}
+	defer out.discard()
+
+	for _, line := range lines {
+		if err := out.WriteCode(line); err != nil {
+			return fmt.Errorf("write sort run: %w", err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("write sort run: %w", err)
+	}
+	return nil
+}
+
+// mergeRuns merges sorted run files into output with a k-way heap merge,
+// keeping duplicate lines.
+func (l *loader) mergeRuns(ctx context.Context, runs []string, output string) error {
+	h, closeRuns, err := l.openRuns(runs)
+	if err != nil {
+		return err
+	}
+	defer closeRuns()
+
+	out, err := l.createFileSink(output)
+	if err != nil {
+		return err
+	}
+	defer out.discard()
+
+	var lines int64
+	for h.Len() > 0 {
+		lines++
+		if lines%ctxCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		if err := out.WriteCode(h[0].line); err != nil {
+			return fmt.Errorf("write sort output: %w", err)
+		}
+		if err := h.advance(); err != nil {
+			return err
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("write sort output: %w", err)
+	}
+	return nil
+}
+
+// runScanBuffer is the initial line buffer per merged file. It grows up to
+// buf1MB for long lines; starting small keeps merges of hundreds of files
+// cheap.
+const runScanBuffer = 64 << 10
+
+// openRuns opens sorted files and returns a heap of their first lines. The
+// returned func closes the files.
+func (l *loader) openRuns(paths []string) (runHeap, func(), error) {
+	files := make([]File, 0, len(paths))
+	closeAll := func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}
+
+	h := make(runHeap, 0, len(paths))
+	for i, path := range paths {
+		f, err := l.fs.Open(path)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("open %s: %w", path, err)
+		}
+		files = append(files, f)
+
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 0, runScanBuffer), buf1MB)
+		if sc.Scan() {
+			h = append(h, &runCursor{line: sc.Text(), file: i, scanner: sc})
+		} else if err := sc.Err(); err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("scan %s: %w", path, err)
+		}
+	}
+	heap.Init(&h)
+	return h, closeAll, nil
+}
+
+// runCursor is the current line of one sorted file.
+type runCursor struct {
+	line    string
+	file    int // index of the file in the merge
+	scanner *bufio.Scanner
+}
+
+// runHeap orders cursors by their current line, byte-wise, then by file.
+type runHeap []*runCursor
+
+func (h runHeap) Len() int { return len(h) }
+func (h runHeap) Less(i, j int) bool {
+	if h[i].line != h[j].line {
+		return h[i].line < h[j].line
+	}
+	return h[i].file < h[j].file
+}
+func (h runHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *runHeap) Push(x any) {
+	*h = append(*h, x.(*runCursor))
+}
+
+func (h *runHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// advance moves the smallest cursor to its next line, dropping it at the
+// end of its file.
+func (h *runHeap) advance() error {
+	c := (*h)[0]
+	if c.scanner.Scan() {
+		c.line = c.scanner.Text()
+		heap.Fix(h, 0)
+		return nil
+	}
+	if err := c.scanner.Err(); err != nil {
+		return fmt.Errorf("scan: %w", err)
+	}
+	heap.Pop(h)
+	return nil
+}
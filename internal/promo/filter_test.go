@@ -0,0 +1,226 @@
+package promo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writePlainInputs writes n plain input files of lines valid codes each.
+func writePlainInputs(t *testing.T, dir string, n, lines int) []string {
+	t.Helper()
+	paths, _ := writeFixtures(t, rand.New(rand.NewSource(1)), dir, fixtureSpec{
+		Files:     n,
+		Lines:     lines,
+		Alphabet:  "ABCDEF0123456789",
+		MinLength: minCodeLength,
+		MaxLength: maxCodeLength,
+		Compress:  fixturePlain,
+	})
+	return paths
+}
+
+// runWithin fails the test if f does not return within a few seconds, as it
+// would when the worker pool deadlocks.
+func runWithin(t *testing.T, f func() error) error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(10 * time.Second):
+		t.Fatal("did not return; the worker pool is stuck")
+		return nil
+	}
+}
+
+// tmpFiles lists the temporary files of sinks left in dir.
+func tmpFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	return matches
+}
+
+func TestFilterAllFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		// inputs are rewritten by setup; a missing file fails to open
+		setup       func(t *testing.T, dir string) []string
+		ctx         func(t *testing.T) context.Context
+		parallelism int
+		// blocking sinks hold their first write until ctx is done, so that
+		// the scan loop must notice the cancellation itself
+		blocking bool
+		wantErr  error
+		wantFile string // in the error
+	}{
+		{
+			name: "failing input with one worker",
+			setup: func(t *testing.T, dir string) []string {
+				inputs := writePlainInputs(t, dir, 6, 10)
+				inputs[0] = filepath.Join(dir, "missing.txt")
+				return inputs
+			},
+			ctx:         func(t *testing.T) context.Context { return context.Background() },
+			parallelism: 1,
+			wantErr:     os.ErrNotExist,
+			wantFile:    "missing.txt",
+		},
+		{
+			name: "failing input among parallel workers",
+			setup: func(t *testing.T, dir string) []string {
+				inputs := writePlainInputs(t, dir, 20, 10)
+				inputs[7] = filepath.Join(dir, "missing.txt")
+				return inputs
+			},
+			ctx:         func(t *testing.T) context.Context { return context.Background() },
+			parallelism: 3,
+			wantErr:     os.ErrNotExist,
+			wantFile:    "missing.txt",
+		},
+		{
+			name: "cancelled before start",
+			setup: func(t *testing.T, dir string) []string {
+				return writePlainInputs(t, dir, 4, 10)
+			},
+			ctx: func(t *testing.T) context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			parallelism: 2,
+			wantErr:     context.Canceled,
+		},
+		{
+			name: "timeout while scanning",
+			setup: func(t *testing.T, dir string) []string {
+				return writePlainInputs(t, dir, 2, 2*ctxCheckEvery)
+			},
+			ctx: func(t *testing.T) context.Context {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				t.Cleanup(cancel)
+				return ctx
+			},
+			parallelism: 2,
+			blocking:    true,
+			wantErr:     context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			inputs := tt.setup(t, dir)
+			ctx := tt.ctx(t)
+			outDir := filepath.Join(dir, "out")
+
+			indexes := make([]int, len(inputs))
+			for i := range indexes {
+				indexes[i] = i
+			}
+			open := func(i int) (codeSink, error) {
+				if tt.blocking {
+					var once sync.Once
+					return &funcSink{write: func(string) error {
+						once.Do(func() { <-ctx.Done() })
+						return nil
+					}}, nil
+				}
+				return newLoader(nil, nil, nil).createFileSink(filepath.Join(outDir, fmt.Sprintf("file_%d.raw", i+1)))
+			}
+			var mu sync.Mutex
+			completed := 0
+			done := func(int, FileStats) error {
+				mu.Lock()
+				defer mu.Unlock()
+				completed++
+				return nil
+			}
+
+			err := runWithin(t, func() error {
+				return newLoader(nil, nil, nil).filterAllFiles(ctx, inputs, indexes, open, done, DefaultRules(), tt.parallelism)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("newLoader(nil, nil, nil).filterAllFiles() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantFile != "" && !strings.Contains(err.Error(), tt.wantFile) {
+				t.Errorf("error %q does not name %s", err, tt.wantFile)
+			}
+			if completed == len(inputs) {
+				t.Errorf("all %d files completed despite the failure", completed)
+			}
+			if left := tmpFiles(t, outDir); len(left) > 0 {
+				t.Errorf("temporary files left behind: %q", left)
+			}
+		})
+	}
+}
+
+// funcSink is a codeSink calling write for each code.
+type funcSink struct {
+	write func(code string) error
+}
+
+func (s *funcSink) WriteCode(code string) error { return s.write(code) }
+func (s *funcSink) Close() error                { return nil }
+func (s *funcSink) discard()                    {}
+
+func TestExtract_FailureLeavesNoPartialFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := writePlainInputs(t, dir, 4, 1000)
+	files[2] = filepath.Join(dir, "missing.txt")
+	tmpDir := filepath.Join(dir, "tmp")
+	output := filepath.Join(dir, "valid.txt")
+
+	strategies := map[string]func() error{
+		"sort": func() error {
+			_, err := Extract(context.Background(), Options{
+				Strategy:    StrategySort,
+				Files:       files,
+				TmpDir:      tmpDir,
+				Output:      output,
+				Rules:       DefaultRules(),
+				Parallelism: 2,
+			})
+			return err
+		},
+		"hash": func() error {
+			_, err := Extract(context.Background(), Options{
+				Strategy:    StrategyHash,
+				Files:       files,
+				TmpDir:      tmpDir,
+				Output:      output,
+				Rules:       DefaultRules(),
+				Buckets:     4,
+				Parallelism: 2,
+			})
+			return err
+		},
+	}
+	for name, run := range strategies {
+		t.Run(name, func(t *testing.T) {
+			if err := runWithin(t, run); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("run error = %v, want %v", err, os.ErrNotExist)
+			}
+			if _, err := os.Stat(output); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("output exists after a failed run")
+			}
+			if left := tmpFiles(t, tmpDir); len(left) > 0 {
+				t.Errorf("temporary files left behind: %q", left)
+			}
+		})
+	}
+}
@@ -0,0 +1,423 @@
+package promo
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+// A code index is a file of fixed-width, byte-wise sorted codes that is
+// memory-mapped and binary searched, so even very large code sets cost
+// almost no heap. Its layout is:
+//
+//	header   indexHeaderSize bytes, see indexHeader
+//	records  count records of width bytes, each code padded with zero bytes
+//	keys     the first record of every block of blockSize records
+//	bloom    bloomBits bits, absent when bloomBits is 0
+//
+// A lookup binary searches the keys for the block that could hold the code
+// and then that block only, so it touches a page or two of the file. The
+// optional Bloom filter answers most unknown codes, which is what guessing
+// clients send, without touching the records at all.
+const (
+	indexMagic      = "PROMOIX1"
+	indexHeaderSize = 96
+
+	// DefaultIndexBlockSize keeps a block of 10-byte codes within one page.
+	DefaultIndexBlockSize = 256
+	// DefaultIndexBloomBitsPerKey gives a false positive rate of about 1%.
+	DefaultIndexBloomBitsPerKey = 10
+)
+
+// ErrMalformedIndex is returned for files that are not complete code
+// indexes, such as files written by another tool or truncated copies.
+var ErrMalformedIndex = errors.New("malformed promo code index")
+
+// IndexOptions tunes the index written by WriteIndex.
+type IndexOptions struct {
+	// BlockSize is the number of codes per block; 0 means
+	// DefaultIndexBlockSize.
+	BlockSize int
+	// BloomBitsPerKey sizes the Bloom filter; 0 leaves it out.
+	BloomBitsPerKey int
+
+	FS FS // nil → OSFS
+}
+
+// indexHeader is the fixed part at the start of an index file. All
+// integers are little-endian.
+type indexHeader struct {
+	width       uint32
+	blockSize   uint32
+	count       uint64
+	bloomBits   uint64
+	bloomHashes uint32
+	checksum    [sha256.Size]byte // of the records section
+}
+
+func (h *indexHeader) blocks() uint64 {
+	return (h.count + uint64(h.blockSize) - 1) / uint64(h.blockSize)
+}
+
+// size returns the length of a file with this header.
+func (h *indexHeader) size() uint64 {
+	return indexHeaderSize + (h.count+h.blocks())*uint64(h.width) + h.bloomBits/8
+}
+
+func (h *indexHeader) marshal() []byte {
+	b := make([]byte, indexHeaderSize)
+	copy(b, indexMagic)
+	binary.LittleEndian.PutUint32(b[8:], h.width)
+	binary.LittleEndian.PutUint32(b[12:], h.blockSize)
+	binary.LittleEndian.PutUint64(b[16:], h.count)
+	binary.LittleEndian.PutUint64(b[24:], h.bloomBits)
+	binary.LittleEndian.PutUint32(b[32:], h.bloomHashes)
+	copy(b[40:], h.checksum[:])
+	return b
+}
+
+func (h *indexHeader) unmarshal(b []byte) error {
+	if len(b) < indexHeaderSize || string(b[:len(indexMagic)]) != indexMagic {
+		return fmt.Errorf("%w: bad header", ErrMalformedIndex)
+	}
+	h.width = binary.LittleEndian.Uint32(b[8:])
+	h.blockSize = binary.LittleEndian.Uint32(b[12:])
+	h.count = binary.LittleEndian.Uint64(b[16:])
+	h.bloomBits = binary.LittleEndian.Uint64(b[24:])
+	h.bloomHashes = binary.LittleEndian.Uint32(b[32:])
+	copy(h.checksum[:], b[40:])
+
+	if h.width == 0 || h.blockSize == 0 || h.count == 0 || h.bloomBits%8 != 0 ||
+		(h.bloomBits > 0) != (h.bloomHashes > 0) {
+		return fmt.Errorf("%w: bad header", ErrMalformedIndex)
+	}
+	return nil
+}
+
+// WriteIndex reads codes from r, one per line in byte-wise ascending order
+// as written by cmd/promo-loader, and writes a code index to path. The
+// index is written next to path and renamed into place, so a reloading
+// reader never sees half of it.
+func WriteIndex(path string, r io.Reader, opts IndexOptions) error {
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = DefaultIndexBlockSize
+	}
+	if opts.BloomBitsPerKey < 0 {
+		opts.BloomBitsPerKey = 0
+	}
+	return newLoader(opts.FS, nil, nil).writeIndex(path, r, opts)
+}
+
+func (l *loader) writeIndex(path string, r io.Reader, opts IndexOptions) error {
+	out, err := l.createFileSink(path)
+	if err != nil {
+		return fmt.Errorf("create index file: %w", err)
+	}
+	defer out.discard()
+	out.sync = true
+
+	// Records are as wide as the longest code, which is only known once
+	// every code is read, so the codes are checked and spooled first.
+	spool, err := l.fs.CreateTemp(filepath.Dir(path), ".promo-codes-*")
+	if err != nil {
+		return fmt.Errorf("create index spool: %w", err)
+	}
+	defer func() {
+		_ = l.fs.Remove(spool.Name())
+	}()
+	width, err := spoolCodes(spool, r)
+	if cerr := spool.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("write index spool: %w", cerr)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The header comes first but covers every record, so the spool is read
+	// twice: for the count, checksum and block keys, then to write.
+	h := indexHeader{width: uint32(width), blockSize: uint32(opts.BlockSize)}
+	sum := sha256.New()
+	var keys []byte
+	err = l.eachRecord(spool.Name(), width, func(rec []byte) error {
+		if h.count%uint64(h.blockSize) == 0 {
+			keys = append(keys, rec...)
+		}
+		sum.Write(rec)
+		h.count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	copy(h.checksum[:], sum.Sum(nil))
+
+	var bl bloom
+	if opts.BloomBitsPerKey > 0 {
+		bl = newBloom(h.count, opts.BloomBitsPerKey)
+		h.bloomBits, h.bloomHashes = uint64(len(bl.bits))*8, bl.hashes
+	}
+
+	if _, err := out.w.Write(h.marshal()); err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	err = l.eachRecord(spool.Name(), width, func(rec []byte) error {
+		if bl.bits != nil {
+			bl.add(string(trimRecord(rec)))
+		}
+		_, err := out.w.Write(rec)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	if _, err := out.w.Write(keys); err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	if _, err := out.w.Write(bl.bits); err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	return nil
+}
+
+// eachRecord calls fn with each code of the spool at path, padded with zero
+// bytes to width. rec is reused between calls.
+func (l *loader) eachRecord(path string, width int, fn func(rec []byte) error) error {
+	f, err := l.fs.Open(path)
+	if err != nil {
+		return fmt.Errorf("read index spool: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	rec := make([]byte, width)
+	scanner := bufio.NewScanner(bufio.NewReaderSize(f, buf1MB))
+	for scanner.Scan() {
+		clear(rec)
+		copy(rec, scanner.Bytes())
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read index spool: %w", err)
+	}
+	return nil
+}
+
+// spoolCodes checks the codes read from r, one per line in byte-wise
+// ascending order, and writes them to spool without surrounding whitespace.
+// It returns the length of the longest code.
+func spoolCodes(spool io.Writer, r io.Reader) (int, error) {
+	w := bufio.NewWriterSize(spool, 1<<20)
+	width := 0
+	prev := ""
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		c := strings.TrimSpace(scanner.Text())
+		if c == "" {
+			continue
+		}
+		if !domain.IsPromoCode(c) {
+			return 0, fmt.Errorf("%w: line %d: %q is not a promo code", ErrMalformedCodeFile, line, c)
+		}
+		if width > 0 && c <= prev {
+			return 0, fmt.Errorf("%w: line %d: %q is not after %q in byte order", ErrMalformedCodeFile, line, c, prev)
+		}
+		prev = c
+		width = max(width, len(c))
+
+		if _, err := w.WriteString(c + "\n"); err != nil {
+			return 0, fmt.Errorf("write index spool: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("read codes: %w", err)
+	}
+	if width == 0 {
+		return 0, fmt.Errorf("%w: no codes", ErrMalformedCodeFile)
+	}
+	if err := w.Flush(); err != nil {
+		return 0, fmt.Errorf("write index spool: %w", err)
+	}
+	return width, nil
+}
+
+// Index is a memory-mapped code index. It is safe for concurrent lookups
+// until Close is called. The file must be replaced by renaming a new one
+// over it, as WriteIndex does, and never rewritten in place.
+type Index struct {
+	data     []byte
+	release  func() error
+	header   indexHeader
+	records  []byte
+	keys     []byte
+	bloom    bloom
+	checksum string
+}
+
+// OpenIndex maps the code index at path. Files that are not complete
+// indexes fail with ErrMalformedIndex.
+func OpenIndex(path string) (*Index, error) {
+	data, release, err := mapFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("open promo code index: %w", err)
+	}
+
+	ix := &Index{data: data, release: release}
+	if err := ix.header.unmarshal(data); err != nil {
+		_ = release()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	h := &ix.header
+	if h.count > math.MaxInt64/uint64(h.width) || h.size() != uint64(len(data)) {
+		_ = release()
+		return nil, fmt.Errorf("%w: %s is %d bytes, header says %d", ErrMalformedIndex, path, len(data), h.size())
+	}
+
+	keysOff := indexHeaderSize + h.count*uint64(h.width)
+	bloomOff := keysOff + h.blocks()*uint64(h.width)
+	ix.records = data[indexHeaderSize:keysOff]
+	ix.keys = data[keysOff:bloomOff]
+	ix.bloom = bloom{bits: data[bloomOff:], hashes: h.bloomHashes}
+	ix.checksum = hex.EncodeToString(h.checksum[:])
+	return ix, nil
+}
+
+// IsValid reports whether code is in the index. Should the file be
+// truncated under the mapping, the fault is returned as an error rather
+// than crashing the process.
+func (ix *Index) IsValid(_ context.Context, code string) (ok bool, err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			ok, err = false, fmt.Errorf("%w: read failed: %v", ErrMalformedIndex, r)
+		}
+	}()
+	return ix.Contains(strings.TrimSpace(code)), nil
+}
+
+// Contains reports whether code is in the index.
+func (ix *Index) Contains(code string) bool {
+	w := int(ix.header.width)
+	if code == "" || len(code) > w {
+		return false
+	}
+	if len(ix.bloom.bits) > 0 && !ix.bloom.mayContain(code) {
+		return false
+	}
+
+	// The last block whose first code is not after code
+	blocks := len(ix.keys) / w
+	b := sort.Search(blocks, func(i int) bool {
+		return compareRecord(ix.keys[i*w:(i+1)*w], code) > 0
+	}) - 1
+	if b < 0 {
+		return false
+	}
+
+	bs := int(ix.header.blockSize)
+	lo := b * bs
+	n := min(bs, int(ix.header.count)-lo)
+	i := sort.Search(n, func(i int) bool {
+		return compareRecord(ix.records[(lo+i)*w:(lo+i+1)*w], code) >= 0
+	})
+	return i < n && compareRecord(ix.records[(lo+i)*w:(lo+i+1)*w], code) == 0
+}
+
+// Len returns the number of codes in the index.
+func (ix *Index) Len() int {
+	return int(ix.header.count)
+}
+
+// Checksum returns the hex SHA-256 of the indexed codes.
+func (ix *Index) Checksum() string {
+	return ix.checksum
+}
+
+// Close unmaps the index. It must not be used afterwards.
+func (ix *Index) Close() error {
+	return ix.release()
+}
+
+// compareRecord compares a zero-padded record with code byte-wise.
+func compareRecord(rec []byte, code string) int {
+	return strings.Compare(string(trimRecord(rec)), code)
+}
+
+func trimRecord(rec []byte) []byte {
+	for i, c := range rec {
+		if c == 0 {
+			return rec[:i]
+		}
+	}
+	return rec
+}
+
+// bloom is a Bloom filter over the indexed codes, probed with double
+// hashing of a 64-bit FNV-1a hash. The hash is inlined so lookups do not
+// allocate.
+type bloom struct {
+	bits   []byte
+	hashes uint32
+}
+
+func newBloom(n uint64, bitsPerKey int) bloom {
+	nbits := max(n*uint64(bitsPerKey), 64)
+	nbits = (nbits + 7) / 8 * 8
+	hashes := uint32(max(1, min(30, int(float64(bitsPerKey)*math.Ln2+0.5))))
+	return bloom{bits: make([]byte, nbits/8), hashes: hashes}
+}
+
+func (b bloom) add(code string) {
+	m := uint64(len(b.bits)) * 8
+	h, delta := bloomHash(code)
+	for i := uint32(0); i < b.hashes; i++ {
+		bit := h % m
+		b.bits[bit/8] |= 1 << (bit % 8)
+		h += delta
+	}
+}
+
+func (b bloom) mayContain(code string) bool {
+	m := uint64(len(b.bits)) * 8
+	h, delta := bloomHash(code)
+	for i := uint32(0); i < b.hashes; i++ {
+		bit := h % m
+		if b.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+		h += delta
+	}
+	return true
+}
+
+func bloomHash(code string) (h, delta uint64) {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h = offset64
+	for i := 0; i < len(code); i++ {
+		h ^= uint64(code[i])
+		h *= prime64
+	}
+	return h, h>>33 | h<<31
+}
@@ -0,0 +1,294 @@
+package promo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/promo"
+)
+
+// complie-time checks
+var (
+	_ domain.PromoCodeValidator = (*promo.Index)(nil)
+)
+
+// sortedCodes returns n distinct codes in byte order, of 8 to 10 characters.
+func sortedCodes(n int) []string {
+	codes := make([]string, n)
+	for i := range codes {
+		codes[i] = fmt.Sprintf("CODE%0*d", 4+i%3, i)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func writeIndex(t *testing.T, codes []string, opts promo.IndexOptions) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "codes.idx")
+	if err := promo.WriteIndex(path, strings.NewReader(strings.Join(codes, "\n")+"\n"), opts); err != nil {
+		t.Fatalf("WriteIndex() error = %v", err)
+	}
+	return path
+}
+
+func TestIndex_Contains(t *testing.T) {
+	codes := sortedCodes(1000)
+
+	tests := []struct {
+		name string
+		opts promo.IndexOptions
+	}{
+		{name: "defaults", opts: promo.IndexOptions{}},
+		{name: "small blocks with bloom", opts: promo.IndexOptions{BlockSize: 3, BloomBitsPerKey: 10}},
+		{name: "small blocks without bloom", opts: promo.IndexOptions{BlockSize: 7}},
+		{name: "one block per code", opts: promo.IndexOptions{BlockSize: 1, BloomBitsPerKey: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ix, err := promo.OpenIndex(writeIndex(t, codes, tt.opts))
+			if err != nil {
+				t.Fatalf("OpenIndex() error = %v", err)
+			}
+			defer func() {
+				_ = ix.Close()
+			}()
+
+			if ix.Len() != len(codes) {
+				t.Fatalf("Len() = %d, want %d", ix.Len(), len(codes))
+			}
+			for _, c := range codes {
+				if !ix.Contains(c) {
+					t.Fatalf("Contains(%q) = false, want true", c)
+				}
+			}
+
+			for _, c := range []string{"", "AAAAAAAA", "CODE0000x", "CODE00000", "ZZZZZZZZ", "CODE00000000", "code0000"} {
+				if ix.Contains(c) {
+					t.Errorf("Contains(%q) = true, want false", c)
+				}
+			}
+
+			if ok, err := ix.IsValid(context.Background(), " "+codes[10]+"\n"); !ok || err != nil {
+				t.Errorf("IsValid(padded) = %v, %v, want true, nil", ok, err)
+			}
+		})
+	}
+}
+
+func TestIndex_LongCodes(t *testing.T) {
+	// 12-character codes, longer than the default rules allow, next to a
+	// short one
+	codes := []string{"ABCDEFGHIJKL", "MNOPQRSTUVWX", "SHORT", "ZYXWVUTSRQPO"}
+
+	path := writeIndex(t, codes, promo.IndexOptions{BlockSize: 2, BloomBitsPerKey: 10})
+	codeSet, err := promo.NewReloadingIndex(path, nil)
+	if err != nil {
+		t.Fatalf("NewReloadingIndex() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for _, c := range codes {
+		if ok, err := codeSet.IsValid(ctx, c); !ok || err != nil {
+			t.Errorf("IsValid(%q) = %v, %v, want true, nil", c, ok, err)
+		}
+	}
+	for _, c := range []string{"ABCDEFGHIJK", "ABCDEFGHIJKLM", "SHORTER"} {
+		if ok, _ := codeSet.IsValid(ctx, c); ok {
+			t.Errorf("IsValid(%q) = true, want false", c)
+		}
+	}
+
+	// Reloading a rewritten index keeps serving long codes
+	if err := promo.WriteIndex(path, strings.NewReader("ABCDEFGHIJKLMN\n"), promo.IndexOptions{}); err != nil {
+		t.Fatalf("WriteIndex() error = %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if changed, err := codeSet.Reload(); !changed || err != nil {
+		t.Fatalf("Reload() = %v, %v, want true, nil", changed, err)
+	}
+	if ok, _ := codeSet.IsValid(ctx, "ABCDEFGHIJKLMN"); !ok {
+		t.Errorf("IsValid(ABCDEFGHIJKLMN) = false after reload, want true")
+	}
+	if ok, _ := codeSet.IsValid(ctx, "ABCDEFGHIJKL"); ok {
+		t.Errorf("IsValid(ABCDEFGHIJKL) = true after reload, want false")
+	}
+}
+
+func TestIndex_ContainsDoesNotAllocate(t *testing.T) {
+	ix, err := promo.OpenIndex(writeIndex(t, sortedCodes(500), promo.IndexOptions{BloomBitsPerKey: 10}))
+	if err != nil {
+		t.Fatalf("OpenIndex() error = %v", err)
+	}
+	defer func() {
+		_ = ix.Close()
+	}()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		ix.Contains("CODE00042")
+		ix.Contains("NOTACODE")
+	})
+	if allocs != 0 {
+		t.Fatalf("Contains() allocs = %v, want 0", allocs)
+	}
+}
+
+func TestWriteIndex_RejectsInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "unsorted", input: "BIRTHDAY\nAPRILFUN\n"},
+		{name: "duplicate", input: "APRILFUN\nAPRILFUN\n"},
+		{name: "not a code", input: "APRILFUN\nBIRTH DAY\n"},
+		{name: "empty", input: "\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "codes.idx")
+			err := promo.WriteIndex(path, strings.NewReader(tt.input), promo.IndexOptions{})
+			if !errors.Is(err, promo.ErrMalformedCodeFile) {
+				t.Fatalf("WriteIndex() error = %v, want %v", err, promo.ErrMalformedCodeFile)
+			}
+
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 0 {
+				t.Fatalf("WriteIndex() left %d files behind, want none", len(entries))
+			}
+		})
+	}
+}
+
+func TestOpenIndex_Malformed(t *testing.T) {
+	dir := t.TempDir()
+	valid, err := os.ReadFile(writeIndex(t, sortedCodes(100), promo.IndexOptions{BloomBitsPerKey: 10}))
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+
+	tests := map[string][]byte{
+		"code file": []byte(strings.Repeat("FIFTYOFF\n", 20)),
+		"truncated": valid[:len(valid)-1],
+		"too short": valid[:10],
+		"trailing":  append(append([]byte{}, valid...), 0),
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, content, 0o600); err != nil {
+				t.Fatalf("write index: %v", err)
+			}
+			if _, err := promo.OpenIndex(path); !errors.Is(err, promo.ErrMalformedIndex) {
+				t.Fatalf("OpenIndex() error = %v, want %v", err, promo.ErrMalformedIndex)
+			}
+		})
+	}
+}
+
+func TestReloadingIndex(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "codes.idx")
+	write := func(content string, mtime time.Time) {
+		t.Helper()
+		if err := promo.WriteIndex(path, strings.NewReader(content), promo.IndexOptions{BloomBitsPerKey: 10}); err != nil {
+			t.Fatalf("WriteIndex() error = %v", err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	mtime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	write("BIRTHDAY\nFIFTYOFF\n", mtime)
+	codes, err := promo.NewReloadingIndex(path, nil)
+	if err != nil {
+		t.Fatalf("NewReloadingIndex() error = %v", err)
+	}
+	first, _ := codes.CodeSetStatus(ctx)
+	if first.Source != "index" || first.CodeCount != 2 || first.Version == "" {
+		t.Fatalf("status = %+v, want an index with 2 codes and a version", first)
+	}
+
+	// Rewriting the same codes is not a change
+	write("BIRTHDAY\nFIFTYOFF\n", mtime.Add(time.Minute))
+	if changed, err := codes.Reload(); changed || err != nil {
+		t.Fatalf("Reload() same codes = %v, %v, want false, nil", changed, err)
+	}
+
+	write("NEWCODE1\n", mtime.Add(2*time.Minute))
+	if changed, err := codes.Reload(); !changed || err != nil {
+		t.Fatalf("Reload() changed = %v, %v, want true, nil", changed, err)
+	}
+	if ok, _ := codes.IsValid(ctx, "NEWCODE1"); !ok {
+		t.Fatalf("IsValid(NEWCODE1) = false after reload, want true")
+	}
+	if ok, _ := codes.IsValid(ctx, "FIFTYOFF"); ok {
+		t.Fatalf("IsValid(FIFTYOFF) = true after reload, want false")
+	}
+
+	// A file that is not an index keeps the previous one. It is renamed
+	// into place, as writing over a mapped file changes it under readers.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte("FIFTYOFF\n"), 0o600); err != nil {
+		t.Fatalf("write codes: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("rename codes: %v", err)
+	}
+	if _, err := codes.Reload(); !errors.Is(err, promo.ErrMalformedIndex) {
+		t.Fatalf("Reload() malformed error = %v, want %v", err, promo.ErrMalformedIndex)
+	}
+	if ok, _ := codes.IsValid(ctx, "NEWCODE1"); !ok {
+		t.Fatalf("IsValid(NEWCODE1) = false after rejected reload, want previous index kept")
+	}
+}
+
+func TestWriteIndex_FaultyFS(t *testing.T) {
+	for _, fsys := range []*faultFS{
+		{op: "write", match: "codes.idx"},
+		{op: "rename", match: "codes.idx"},
+		{op: "open", match: ".promo-codes-"},
+	} {
+		t.Run(fsys.op, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "codes.idx")
+			if err := promo.WriteIndex(path, strings.NewReader("APRILFUN\n"), promo.IndexOptions{}); err != nil {
+				t.Fatalf("WriteIndex() error = %v", err)
+			}
+
+			err := promo.WriteIndex(path, strings.NewReader("BIRTHDAY\n"), promo.IndexOptions{FS: fsys})
+			if !errors.Is(err, errInjected) {
+				t.Fatalf("WriteIndex() error = %v, want %v", err, errInjected)
+			}
+
+			// The index served so far is left as it was, and alone
+			ix, err := promo.OpenIndex(path)
+			if err != nil {
+				t.Fatalf("OpenIndex() error = %v", err)
+			}
+			defer func() {
+				_ = ix.Close()
+			}()
+			if !ix.Contains("APRILFUN") || ix.Contains("BIRTHDAY") {
+				t.Errorf("index changed by a failed WriteIndex()")
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Errorf("WriteIndex() left %d files, want only the index", len(entries))
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package promo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// StdinPath in Options.Files reads that input from standard input.
+const StdinPath = "-"
+
+// Input formats, detected from the first bytes of each input.
+const (
+	FormatPlain = "plain"
+	FormatGzip  = "gzip"
+	FormatBzip2 = "bzip2"
+	FormatZstd  = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	// A bzip2 stream starts with "BZh", the block size digit and the magic
+	// of its first block, or of its end for an empty stream.
+	bzip2Magic       = []byte("BZh")
+	bzip2BlockMagic  = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+	bzip2EndMagic    = []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90}
+	bzip2HeaderBytes = len(bzip2Magic) + 1 + len(bzip2BlockMagic)
+)
+
+// input is an opened input file, decompressed.
+type input struct {
+	io.Reader
+	format  string
+	size    int64 // of the file, 0 for stdin
+	raw     *countingReader
+	closers []io.Closer
+}
+
+// bytesRead is the number of bytes read from the file so far, before
+// decompression.
+func (in *input) bytesRead() int64 {
+	return in.raw.n
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (in *input) Close() error {
+	var first error
+	for i := len(in.closers) - 1; i >= 0; i-- {
+		if err := in.closers[i].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// openInput opens path, or the loader's stdin for StdinPath, and decompresses
+// it according to its magic bytes. Anything not compressed is read as plain
+// text. Concatenated gzip members or zstd frames are read as one stream.
+func (l *loader) openInput(path string) (*input, error) {
+	in := &input{}
+	f := l.stdin
+	if path != StdinPath {
+		file, err := l.fs.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open input: %w", err)
+		}
+		in.closers = append(in.closers, file)
+		f = file
+		if info, err := file.Stat(); err == nil {
+			in.size = info.Size()
+		}
+	}
+
+	in.raw = &countingReader{r: f}
+	br := bufio.NewReaderSize(in.raw, buf1MB)
+	magic, err := br.Peek(bzip2HeaderBytes)
+	if err != nil && !errors.Is(err, io.EOF) {
+		_ = in.Close()
+		return nil, fmt.Errorf("read input: %w", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			_ = in.Close()
+			return nil, fmt.Errorf("gzip reader: %w", err)
+		}
+		in.Reader, in.format = gz, FormatGzip
+		in.closers = append(in.closers, gz)
+	case isBzip2(magic):
+		in.Reader, in.format = bzip2.NewReader(br), FormatBzip2
+	case bytes.HasPrefix(magic, zstdMagic):
+		// Files are already decoded in parallel, so one goroutine each
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			_ = in.Close()
+			return nil, fmt.Errorf("zstd reader: %w", err)
+		}
+		rc := zr.IOReadCloser()
+		in.Reader, in.format = rc, FormatZstd
+		in.closers = append(in.closers, rc)
+	default:
+		in.Reader, in.format = br, FormatPlain
+	}
+	return in, nil
+}
+
+// isBzip2 reports whether magic is the start of a bzip2 stream. Checking
+// more than "BZh" keeps plain text that happens to start with it plain.
+func isBzip2(magic []byte) bool {
+	if len(magic) < bzip2HeaderBytes || !bytes.HasPrefix(magic, bzip2Magic) {
+		return false
+	}
+	if level := magic[len(bzip2Magic)]; level < '1' || level > '9' {
+		return false
+	}
+	block := magic[len(bzip2Magic)+1:]
+	return bytes.Equal(block, bzip2BlockMagic) || bytes.Equal(block, bzip2EndMagic)
+}
+
+// lineReader reads lines like bufio.Scanner, but reports lines longer than
+// its buffer instead of failing on them.
+type lineReader struct {
+	r *bufio.Reader
+}
+
+func newLineReader(r io.Reader, size int) *lineReader {
+	return &lineReader{r: bufio.NewReaderSize(r, size)}
+}
+
+// next returns the next line without its line ending, valid until the next
+// call, and io.EOF after the last line. A line longer than the buffer is
+// reported as overlong, with only its first maxSampleLength bytes.
+func (l *lineReader) next() (line []byte, overlong bool, err error) {
+	line, err = l.r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		start := bytes.Clone(line[:maxSampleLength])
+		for errors.Is(err, bufio.ErrBufferFull) {
+			_, err = l.r.ReadSlice('\n')
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, false, err
+		}
+		return start, true, nil
+	}
+	if err != nil && (!errors.Is(err, io.EOF) || len(line) == 0) {
+		return nil, false, err
+	}
+
+	line = bytes.TrimSuffix(line, []byte{'\n'})
+	return bytes.TrimSuffix(line, []byte{'\r'}), false, nil
+}
+
+// validateInputs checks that at most one input is read from standard input,
+// which can only be read once.
+func validateInputs(files []string) error {
+	stdin := 0
+	for _, f := range files {
+		if f == StdinPath {
+			stdin++
+		}
+	}
+	if stdin > 1 {
+		return fmt.Errorf("only one input can be read from stdin (%q)", StdinPath)
+	}
+	return nil
+}
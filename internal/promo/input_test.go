@@ -0,0 +1,285 @@
+package promo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func gzipMember(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(s)); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpenInput(t *testing.T) {
+	bz2, err := os.ReadFile(filepath.Join("testdata", "codes.txt.bz2"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	zst, err := os.ReadFile(filepath.Join("testdata", "codes.txt.zst"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	codes := "AAAAAAAA\nBBBBBBBB\nCCCCCCCC\n"
+
+	tests := []struct {
+		name       string
+		content    []byte
+		wantFormat string
+		want       string
+		wantErr    error
+	}{
+		{name: "plain", content: []byte(codes), wantFormat: FormatPlain, want: codes},
+		{name: "empty", content: nil, wantFormat: FormatPlain, want: ""},
+		{name: "gzip", content: gzipMember(t, codes), wantFormat: FormatGzip, want: codes},
+		{
+			name:       "multi-member gzip",
+			content:    append(gzipMember(t, "AAAAAAAA\nBBBBBBBB\n"), gzipMember(t, "CCCCCCCC\n")...),
+			wantFormat: FormatGzip,
+			want:       codes,
+		},
+		{name: "bzip2", content: bz2, wantFormat: FormatBzip2, want: codes},
+		{name: "empty bzip2", content: []byte("BZh9\x17\x72\x45\x38\x50\x90\x00\x00\x00\x00"), wantFormat: FormatBzip2, want: ""},
+		{name: "plain starting with BZh", content: []byte("BZh12345\n" + codes), wantFormat: FormatPlain, want: "BZh12345\n" + codes},
+		{name: "plain BZh", content: []byte("BZh"), wantFormat: FormatPlain, want: "BZh"},
+		{name: "zstd", content: zst, wantFormat: FormatZstd, want: codes},
+		{name: "multi-frame zstd", content: append(slices.Clone(zst), zst...), wantFormat: FormatZstd, want: codes + codes},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "codes")
+			if err := os.WriteFile(path, tt.content, 0o600); err != nil {
+				t.Fatalf("write input: %v", err)
+			}
+
+			in, err := newLoader(nil, nil, nil).openInput(path)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("newLoader(nil, nil, nil).openInput() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newLoader(nil, nil, nil).openInput() error = %v", err)
+			}
+			defer func() {
+				_ = in.Close()
+			}()
+
+			if in.format != tt.wantFormat {
+				t.Errorf("format = %s, want %s", in.format, tt.wantFormat)
+			}
+			got, err := io.ReadAll(in)
+			if err != nil {
+				t.Fatalf("read input: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtract_MixedInputs(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(plain, []byte("AAAAAAAA\nBBBBBBBB\nshort\n"), 0o600); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	gz := filepath.Join(dir, "b.gz")
+	if err := os.WriteFile(gz, gzipMember(t, "BBBBBBBB\nCCCCCCCC\n"), 0o600); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	zst := filepath.Join(dir, "c.zst")
+	b, err := os.ReadFile(filepath.Join("testdata", "codes.txt.zst"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	if err := os.WriteFile(zst, b, 0o600); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	stdin, err := os.Open(filepath.Join("testdata", "codes.txt.bz2"))
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	defer func() {
+		_ = stdin.Close()
+	}()
+
+	output := filepath.Join(dir, "valid.txt")
+	files := []string{plain, gz, StdinPath, zst}
+	report, err := Extract(context.Background(), Options{
+		Strategy: StrategySort,
+		Files:    files,
+		TmpDir:   filepath.Join(dir, "tmp"),
+		Output:   output,
+		Rules:    DefaultRules(),
+		Stdin:    stdin,
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if got, want := readLines(t, output), []string{"AAAAAAAA", "BBBBBBBB", "CCCCCCCC"}; !slices.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	formats := []string{report.Files[0].Format, report.Files[1].Format, report.Files[2].Format, report.Files[3].Format}
+	if want := []string{FormatPlain, FormatGzip, FormatBzip2, FormatZstd}; !slices.Equal(formats, want) {
+		t.Errorf("formats = %q, want %q", formats, want)
+	}
+
+	rejects := filepath.Join(dir, "rejects.json")
+	if err := WriteRejects(nil, rejects, report); err != nil {
+		t.Fatalf("WriteRejects() error = %v", err)
+	}
+	b, err = os.ReadFile(rejects)
+	if err != nil {
+		t.Fatalf("read rejects: %v", err)
+	}
+	want := `{"files":[` +
+		`{"path":"` + plain + `","rejected":{"tooShort":{"count":1,"samples":["short"]}}},` +
+		`{"path":"` + gz + `","rejected":{}},` +
+		`{"path":"-","rejected":{}},` +
+		`{"path":"` + zst + `","rejected":{}}]}`
+	var got bytes.Buffer
+	if err := json.Compact(&got, b); err != nil {
+		t.Fatalf("rejects are not JSON: %v", err)
+	}
+	if got.String() != want {
+		t.Errorf("rejects = %s, want %s", got.String(), want)
+	}
+
+	if _, err := Extract(context.Background(), Options{
+		Strategy: StrategySort,
+		Files:    []string{StdinPath, StdinPath},
+		TmpDir:   filepath.Join(dir, "tmp"),
+		Output:   output,
+		Rules:    DefaultRules(),
+	}); err == nil {
+		t.Errorf("two stdin inputs: error = nil, want error")
+	}
+}
+
+// codesSink collects the codes written to it.
+type codesSink struct {
+	codes []string
+}
+
+func (s *codesSink) WriteCode(code string) error {
+	s.codes = append(s.codes, code)
+	return nil
+}
+
+func (s *codesSink) Close() error { return nil }
+
+func (s *codesSink) discard() {}
+
+func TestFilterFile_Rejects(t *testing.T) {
+	overlong := strings.Repeat("X", 2*buf1MB)
+	lines := []string{
+		"AAAAAAAA",
+		"",
+		"   \t",
+		"SHORT",
+		"MUCHTOOLONGCODE",
+		"aaaa-bbbb",
+		"DDDDDDDD ", // trimmed, then kept
+		"XYZ 12345",
+		"EEEE\x00EEE",
+		overlong,
+		"BBBBBBBB\r", // CRLF endings are stripped
+		"CCCCCCCC",
+	}
+	for i := range 20 {
+		lines = append(lines, fmt.Sprintf("S%d", i))
+	}
+	path := filepath.Join(t.TempDir(), "codes.txt")
+	// The last line has no newline
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	rules := DefaultRules()
+	allowed, err := CompileAllowedChars("[A-Z0-9]")
+	if err != nil {
+		t.Fatalf("CompileAllowedChars() error = %v", err)
+	}
+	rules.AllowedChars = allowed
+
+	sink := &codesSink{}
+	stats, err := newLoader(nil, nil, nil).filterFile(context.Background(), path, sink, rules)
+	if err != nil {
+		t.Fatalf("newLoader(nil, nil, nil).filterFile() error = %v", err)
+	}
+
+	if want := []string{"AAAAAAAA", "DDDDDDDD", "BBBBBBBB", "CCCCCCCC"}; !slices.Equal(sink.codes, want) {
+		t.Errorf("codes = %q, want %q", sink.codes, want)
+	}
+	wantRejected := map[string]int64{
+		RejectEmpty:        2,
+		RejectTooShort:     21,
+		RejectTooLong:      1,
+		RejectInvalidChars: 1,
+		RejectMalformed:    2,
+		RejectOverlong:     1,
+	}
+	if !reflect.DeepEqual(stats.Rejected, wantRejected) {
+		t.Errorf("rejected = %v, want %v", stats.Rejected, wantRejected)
+	}
+	if stats.Lines != int64(len(lines)) || stats.Kept != 4 {
+		t.Errorf("lines = %d, kept = %d, want %d and 4", stats.Lines, stats.Kept, len(lines))
+	}
+
+	if got := stats.Samples[RejectOverlong]; len(got) != 1 || got[0] != overlong[:maxSampleLength] {
+		t.Errorf("overlong samples = %q, want the first %d bytes of the line", got, maxSampleLength)
+	}
+	if got := stats.Samples[RejectInvalidChars]; !slices.Equal(got, []string{"aaaa-bbbb"}) {
+		t.Errorf("invalid chars samples = %q", got)
+	}
+	short := stats.Samples[RejectTooShort]
+	if len(short) != maxRejectSamples {
+		t.Fatalf("short samples = %d, want %d", len(short), maxRejectSamples)
+	}
+	for _, s := range short {
+		if i := slices.Index(lines, s); i < 0 || len(s) >= minCodeLength {
+			t.Errorf("short sample %q is not a short line", s)
+		}
+	}
+}
+
+func TestFileStats_RejectUnsampledDoesNotAllocate(t *testing.T) {
+	stats := newFileStats("codes.txt", FormatPlain)
+	line := []byte("SHORT")
+	for range maxRejectSamples {
+		stats.reject(RejectTooShort, line)
+	}
+	// So many rejects already that the reservoir all but never takes a line
+	stats.Rejected[RejectTooShort] = 1 << 50
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		stats.reject(RejectTooShort, line)
+	})
+	if allocs != 0 {
+		t.Fatalf("reject() allocs = %v, want 0", allocs)
+	}
+}
@@ -0,0 +1,314 @@
+package promo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// manifestName is the manifest file in tmpDir.
+const manifestName = "manifest.json"
+
+// manifestData is the JSON written to manifestName.
+type manifestData struct {
+	// Params identifies the run: the inputs and every setting that changes
+	// the output. Stages of a run with other params are never reused.
+	Params string                  `json:"params"`
+	Stages map[string]*stageRecord `json:"stages"`
+}
+
+// stageRecord is one completed stage.
+type stageRecord struct {
+	Inputs      []fileRecord `json:"inputs"`
+	Outputs     []fileRecord `json:"outputs"`
+	CompletedAt time.Time    `json:"completedAt"`
+	// Stats are the stage's statistics for the run report, if it has any.
+	Stats json.RawMessage `json:"stats,omitempty"`
+}
+
+type fileRecord struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	SHA256  string    `json:"sha256"`
+}
+
+// runManifest records completed stages in tmpDir so that a re-run skips the
+// stages whose outputs are still intact. It is safe for concurrent use.
+type runManifest struct {
+	l    *loader
+	mu   sync.Mutex
+	path string
+	data manifestData
+}
+
+// loadManifest reads the manifest in tmpDir, starting afresh when there is
+// none, it cannot be read, or it belongs to a run with other params. Runs
+// reading stdin always start afresh: there is no telling whether stdin holds
+// what it held last time.
+func (l *loader) loadManifest(ctx context.Context, tmpDir, params string, files []string) *runManifest {
+	log := zerolog.Ctx(ctx)
+	m := &runManifest{
+		l:    l,
+		path: filepath.Join(tmpDir, manifestName),
+		data: manifestData{Params: params, Stages: make(map[string]*stageRecord)},
+	}
+	if slices.Contains(files, StdinPath) {
+		log.Info().Str("stage", "resume").Msg("not resuming a run that reads stdin")
+		return m
+	}
+
+	b, err := l.readFile(m.path)
+	if err != nil {
+		return m
+	}
+	var data manifestData
+	if err := json.Unmarshal(b, &data); err != nil || data.Params != params || data.Stages == nil {
+		log.Info().Str("stage", "resume").Str("manifest", m.path).Msg("ignoring manifest of another run")
+		return m
+	}
+	m.data = data
+	return m
+}
+
+// done reports whether stage completed and is still valid: its inputs have
+// the recorded size and modification time, its outputs the recorded size
+// and SHA-256, and the input files it was built from, through the stages
+// before it, are unchanged.
+func (m *runManifest) done(stage string) bool {
+	rec := m.stage(stage)
+	if rec == nil {
+		return false
+	}
+
+	for _, in := range rec.Inputs {
+		if !m.unchanged(in) {
+			return false
+		}
+	}
+	for _, out := range rec.Outputs {
+		got, err := m.l.recordFile(out.Path)
+		if err != nil || got.Size != out.Size || got.SHA256 != out.SHA256 {
+			return false
+		}
+	}
+	return m.sourcesUnchanged(stage, make(map[string]bool))
+}
+
+// sourcesUnchanged reports whether the input files of the run that stage
+// was built from still have their recorded size and modification time. The
+// inputs other stages produced are followed back to those stages rather
+// than checked, as they may be gone once used, like bucket parts.
+func (m *runManifest) sourcesUnchanged(stage string, seen map[string]bool) bool {
+	rec := m.stage(stage)
+	if rec == nil {
+		return false
+	}
+	for _, in := range rec.Inputs {
+		up, ok := m.producer(in.Path)
+		if !ok {
+			if !m.unchanged(in) {
+				return false
+			}
+			continue
+		}
+		if seen[up] {
+			continue
+		}
+		seen[up] = true
+		if !m.sourcesUnchanged(up, seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// unchanged reports whether the file of in has its recorded size and
+// modification time.
+func (m *runManifest) unchanged(in fileRecord) bool {
+	info, err := m.l.fs.Stat(in.Path)
+	return err == nil && info.Size() == in.Size && info.ModTime().Equal(in.ModTime)
+}
+
+// stage returns the record of stage, or nil if it has not completed.
+func (m *runManifest) stage(stage string) *stageRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data.Stages[stage]
+}
+
+// producer returns the stage that wrote path, if any.
+func (m *runManifest) producer(path string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, rec := range m.data.Stages {
+		for _, out := range rec.Outputs {
+			if out.Path == path {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// record marks stage complete with the given files and stats, which may be
+// nil, and saves the manifest.
+func (m *runManifest) record(stage string, inputs, outputs []string, stats any) error {
+	rec := &stageRecord{CompletedAt: m.l.now().UTC()}
+	if stats != nil {
+		b, err := json.Marshal(stats)
+		if err != nil {
+			return fmt.Errorf("encode %s stats: %w", stage, err)
+		}
+		rec.Stats = b
+	}
+	for _, p := range inputs {
+		if p == StdinPath {
+			continue
+		}
+		r, ok := m.recordedOutput(p)
+		if !ok {
+			var err error
+			if r, err = m.l.recordFile(p); err != nil {
+				return err
+			}
+		}
+		rec.Inputs = append(rec.Inputs, r)
+	}
+	for _, p := range outputs {
+		r, err := m.l.recordFile(p)
+		if err != nil {
+			return err
+		}
+		rec.Outputs = append(rec.Outputs, r)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data.Stages[stage] = rec
+	return m.save()
+}
+
+// stats decodes the stats recorded for stage into v.
+func (m *runManifest) stats(stage string, v any) error {
+	rec := m.stage(stage)
+	if rec == nil || rec.Stats == nil {
+		return fmt.Errorf("no stats recorded for %s", stage)
+	}
+	if err := json.Unmarshal(rec.Stats, v); err != nil {
+		return fmt.Errorf("decode %s stats: %w", stage, err)
+	}
+	return nil
+}
+
+// recordedOutput returns the record of path as the output of an earlier
+// stage, if the file has not changed since, to save hashing it again.
+func (m *runManifest) recordedOutput(path string) (fileRecord, bool) {
+	info, err := m.l.fs.Stat(path)
+	if err != nil {
+		return fileRecord{}, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rec := range m.data.Stages {
+		for _, out := range rec.Outputs {
+			if out.Path == path && out.Size == info.Size() && out.ModTime.Equal(info.ModTime()) {
+				return out, true
+			}
+		}
+	}
+	return fileRecord{}, false
+}
+
+// save writes the manifest atomically. m.mu must be held.
+func (m *runManifest) save() error {
+	b, err := json.MarshalIndent(m.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+
+	out, err := m.l.createFileSink(m.path)
+	if err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if _, err := out.w.Write(b); err != nil {
+		out.discard()
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
+
+// readFile reads the whole of path.
+func (l *loader) readFile(path string) ([]byte, error) {
+	f, err := l.fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return io.ReadAll(f)
+}
+
+func (l *loader) recordFile(path string) (fileRecord, error) {
+	f, err := l.fs.Open(path)
+	if err != nil {
+		return fileRecord{}, fmt.Errorf("checksum %s: %w", path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fileRecord{}, fmt.Errorf("checksum %s: %w", path, err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fileRecord{}, fmt.Errorf("checksum %s: %w", path, err)
+	}
+
+	return fileRecord{
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		SHA256:  hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+// runParams identifies a run for loadManifest.
+func runParams(strategy string, files []string, rules Rules, buckets int) string {
+	return fmt.Sprintf("strategy=%s files=%q rules={%s} buckets=%d", strategy, files, rules, buckets)
+}
+
+// mergeStage names the final stage; it includes the output paths, so a run
+// writing elsewhere does not count as done.
+func mergeStage(output, countsOutput string) string {
+	return fmt.Sprintf("merge %s %s", output, countsOutput)
+}
+
+// stageName names the stage of kind for input file i.
+func stageName(kind string, i int) string {
+	return fmt.Sprintf("%s %d", kind, i+1)
+}
+
+// outputsOf lists the final outputs of a run.
+func outputsOf(output, countsOutput string) []string {
+	if countsOutput == "" {
+		return []string{output}
+	}
+	return []string{output, countsOutput}
+}
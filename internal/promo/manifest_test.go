@@ -0,0 +1,182 @@
+package promo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExtract_Resume(t *testing.T) {
+	dir := t.TempDir()
+	files, content := writeCodeFixtures(t, dir, 3, 5_000)
+	want, _ := naiveValidCodes(DefaultRules(), content)
+
+	tmpDir := filepath.Join(dir, "tmp")
+	outDir := filepath.Join(dir, "out")
+	output := filepath.Join(outDir, "valid.txt")
+	run := func(files []string) (*Report, error) {
+		return Extract(context.Background(), Options{
+			Strategy:    StrategySort,
+			Files:       files,
+			TmpDir:      tmpDir,
+			Output:      output,
+			Rules:       DefaultRules(),
+			Parallelism: 2,
+		})
+	}
+
+	first, err := run(files)
+	if err != nil {
+		t.Fatalf("first run error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, manifestName)); err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+
+	// With the sorted files intact, a lost output is rebuilt by the merge
+	// alone.
+	sortedTimes := modTimes(t, tmpDir, "file_*.sorted")
+	if err := os.Remove(output); err != nil {
+		t.Fatalf("remove output: %v", err)
+	}
+	resumed, err := run(files)
+	if err != nil {
+		t.Fatalf("resumed run error = %v", err)
+	}
+	if got := readLines(t, output); !slices.Equal(got, want) {
+		t.Fatalf("resumed output has %d codes, want %d", len(got), len(want))
+	}
+	if got := modTimes(t, tmpDir, "file_*.sorted"); !reflect.DeepEqual(got, sortedTimes) {
+		t.Fatalf("resumed run rewrote sorted files")
+	}
+	// The report still covers the stages reused from the first run
+	if !reflect.DeepEqual(resumed.Files, first.Files) || resumed.ValidCodes != first.ValidCodes {
+		t.Fatalf("resumed report = %+v, want %+v", resumed.Files, first.Files)
+	}
+
+	// A damaged sorted file is rebuilt rather than trusted
+	sorted := filepath.Join(tmpDir, "file_2.sorted")
+	b, err := os.ReadFile(sorted)
+	if err != nil {
+		t.Fatalf("read sorted file: %v", err)
+	}
+	b[0] ^= 0xff
+	if err := os.WriteFile(sorted, b, 0o600); err != nil {
+		t.Fatalf("damage sorted file: %v", err)
+	}
+	if err := os.Remove(output); err != nil {
+		t.Fatalf("remove output: %v", err)
+	}
+	if _, err := run(files); err != nil {
+		t.Fatalf("run after damage error = %v", err)
+	}
+	if got := readLines(t, output); !slices.Equal(got, want) {
+		t.Fatalf("output after damage has %d codes, want %d", len(got), len(want))
+	}
+
+	// Only the output itself is left next to it
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatalf("read output dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("output dir has %d entries, want only the output", len(entries))
+	}
+}
+
+// TestExtract_ResumeChangedInput replaces an input at the same path between
+// runs: it must be filtered again, and every stage after it redone, however
+// intact the files in tmpDir are.
+func TestExtract_ResumeChangedInput(t *testing.T) {
+	for _, strategy := range []string{StrategySort, StrategyHash} {
+		t.Run(strategy, func(t *testing.T) {
+			dir := t.TempDir()
+			files, content := writeCodeFixtures(t, dir, 3, 5_000)
+			opts := Options{
+				Strategy:    strategy,
+				Files:       files,
+				TmpDir:      filepath.Join(dir, "tmp"),
+				Output:      filepath.Join(dir, "valid.txt"),
+				Rules:       DefaultRules(),
+				Buckets:     4,
+				Parallelism: 2,
+			}
+			if _, err := Extract(context.Background(), opts); err != nil {
+				t.Fatalf("first run error = %v", err)
+			}
+
+			// Next week's export of the first file: the second file's codes
+			b, err := os.ReadFile(files[1])
+			if err != nil {
+				t.Fatalf("read input: %v", err)
+			}
+			if err := os.WriteFile(files[0], b, 0o600); err != nil {
+				t.Fatalf("replace input: %v", err)
+			}
+			content[0] = content[1]
+
+			report, err := Extract(context.Background(), opts)
+			if err != nil {
+				t.Fatalf("run after replacing an input error = %v", err)
+			}
+			want, _ := naiveValidCodes(DefaultRules(), content)
+			if got := readLines(t, opts.Output); !slices.Equal(got, want) {
+				t.Fatalf("output has %d codes, want %d of the replaced input", len(got), len(want))
+			}
+			if got := report.Files[0].Bytes; got != int64(len(b)) {
+				t.Fatalf("report of the replaced input has %d bytes, want %d", got, len(b))
+			}
+		})
+	}
+}
+
+// modTimes returns the modification times of the files in dir matching
+// pattern, by name.
+func modTimes(t *testing.T, dir, pattern string) map[string]time.Time {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("glob %s: %v, %d files", pattern, err, len(paths))
+	}
+	times := make(map[string]time.Time, len(paths))
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("stat %s: %v", p, err)
+		}
+		times[filepath.Base(p)] = info.ModTime()
+	}
+	return times
+}
+
+func TestExtract_HashRulesChangeRestarts(t *testing.T) {
+	dir := t.TempDir()
+	files, content := writeCodeFixtures(t, dir, 3, 5_000)
+	tmpDir := filepath.Join(dir, "tmp")
+	output := filepath.Join(dir, "valid.txt")
+
+	for _, minFiles := range []int{2, 3, 2} {
+		rules := DefaultRules()
+		rules.MinFiles = minFiles
+		if _, err := Extract(context.Background(), Options{
+			Strategy:    StrategyHash,
+			Files:       files,
+			TmpDir:      tmpDir,
+			Output:      output,
+			Rules:       rules,
+			Buckets:     8,
+			Parallelism: 2,
+		}); err != nil {
+			t.Fatalf("run with min files %d error = %v", minFiles, err)
+		}
+
+		want, _ := naiveValidCodes(rules, content)
+		if got := readLines(t, output); !slices.Equal(got, want) {
+			t.Fatalf("min files %d: output has %d codes, want %d", minFiles, len(got), len(want))
+		}
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package promo
+
+import (
+	"fmt"
+	"os"
+)
+
+// mapFile reads path into memory on platforms without mmap support.
+func mapFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) < indexHeaderSize {
+		return nil, nil, fmt.Errorf("%w: %s is %d bytes", ErrMalformedIndex, path, len(data))
+	}
+	return data, func() error { return nil }, nil
+}
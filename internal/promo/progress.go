@@ -0,0 +1,89 @@
+package promo
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// progressInterval is the minimum time between two progress logs of a stage.
+const progressInterval = 10 * time.Second
+
+// progress reports one stage over one file, or over a set of files when file
+// is empty: lines read and kept, bytes processed, throughput and, when the
+// total size is known, an ETA.
+type progress struct {
+	log        *zerolog.Logger
+	now        func() time.Time
+	stage      string
+	file       string
+	totalBytes int64 // 0 when unknown, e.g. for stdin
+	start      time.Time
+	last       time.Time
+
+	lines, kept, bytes int64
+}
+
+// startProgress logs the start of stage with the logger of ctx.
+func (l *loader) startProgress(ctx context.Context, stage, file string, totalBytes int64) *progress {
+	now := l.now()
+	p := &progress{
+		log:        zerolog.Ctx(ctx),
+		now:        l.now,
+		stage:      stage,
+		file:       file,
+		totalBytes: totalBytes,
+		start:      now,
+		last:       now,
+	}
+	e := p.log.Info().Str("stage", stage)
+	if file != "" {
+		e = e.Str("file", file)
+	}
+	e.Int64("total_bytes", totalBytes).Msg("stage started")
+	return p
+}
+
+// update records the counts so far and logs them when progressInterval has
+// passed since the last log. It reads the clock, so call it every few
+// thousand lines rather than every line.
+func (p *progress) update(lines, kept, bytes int64) {
+	p.lines, p.kept, p.bytes = lines, kept, bytes
+	now := p.now()
+	if now.Sub(p.last) < progressInterval {
+		return
+	}
+	p.last = now
+	p.fields(p.log.Info(), now).Msg("progress")
+}
+
+// finish logs the final counts.
+func (p *progress) finish(lines, kept, bytes int64) {
+	p.lines, p.kept, p.bytes = lines, kept, bytes
+	p.fields(p.log.Info(), p.now()).Msg("stage done")
+}
+
+func (p *progress) fields(e *zerolog.Event, now time.Time) *zerolog.Event {
+	elapsed := now.Sub(p.start)
+	e = e.Str("stage", p.stage)
+	if p.file != "" {
+		e = e.Str("file", p.file)
+	}
+	e = e.Int64("lines", p.lines).
+		Int64("kept", p.kept).
+		Int64("bytes", p.bytes).
+		Dur("elapsed", elapsed)
+
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		return e
+	}
+	e = e.Float64("lines_per_sec", float64(p.lines)/secs).
+		Float64("mib_per_sec", float64(p.bytes)/secs/(1<<20))
+	if p.totalBytes > 0 && p.bytes > 0 && p.bytes < p.totalBytes {
+		remaining := float64(p.totalBytes-p.bytes) / float64(p.bytes)
+		e = e.Dur("eta", time.Duration(remaining*float64(elapsed)))
+	}
+	return e
+}
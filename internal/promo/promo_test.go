@@ -0,0 +1,124 @@
+package promo_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/promo"
+)
+
+// complie-time checks
+var (
+	_ domain.PromoCodeValidator   = (*promo.CodeSet)(nil)
+	_ domain.PromoCodeValidator   = (*promo.ReloadingCodeSet)(nil)
+	_ domain.PromoCodeSetReporter = (*promo.ReloadingCodeSet)(nil)
+)
+
+func TestLoadCodeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "codes.txt")
+	if err := os.WriteFile(path, []byte("BIRTHDAY\n\nFIFTYOFF \r\n"), 0o600); err != nil {
+		t.Fatalf("write codes: %v", err)
+	}
+
+	codes, err := promo.LoadCodeFile(path)
+	if err != nil {
+		t.Fatalf("LoadCodeFile() error = %v", err)
+	}
+	if codes.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", codes.Len())
+	}
+
+	for code, want := range map[string]bool{"FIFTYOFF": true, " BIRTHDAY ": true, "fiftyoff": false, "": false} {
+		got, err := codes.IsValid(context.Background(), code)
+		if err != nil || got != want {
+			t.Errorf("IsValid(%q) = %v, %v, want %v", code, got, err, want)
+		}
+	}
+
+	if _, err := promo.LoadCodeFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatalf("LoadCodeFile(missing) error = nil, want error")
+	}
+}
+
+func TestLoadCodeFile_Malformed(t *testing.T) {
+	for name, content := range map[string]string{
+		"empty":        "",
+		"only blanks":  "\n\n",
+		"control char": "FIFTYOFF\nAB\x01C\n",
+		"inner spaces": "FIFTY OFF\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "codes.txt")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatalf("write codes: %v", err)
+			}
+			if _, err := promo.LoadCodeFile(path); !errors.Is(err, promo.ErrMalformedCodeFile) {
+				t.Fatalf("LoadCodeFile() error = %v, want %v", err, promo.ErrMalformedCodeFile)
+			}
+		})
+	}
+}
+
+func TestReloadingCodeSet(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "codes.txt")
+	write := func(content string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write codes: %v", err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	mtime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	write("FIFTYOFF\nBIRTHDAY\n", mtime)
+	codes, err := promo.NewReloadingCodeSet(path, nil)
+	if err != nil {
+		t.Fatalf("NewReloadingCodeSet() error = %v", err)
+	}
+	first, _ := codes.CodeSetStatus(ctx)
+	if first.CodeCount != 2 || first.Version == "" {
+		t.Fatalf("status = %+v, want 2 codes and a version", first)
+	}
+
+	// Unchanged file is not swapped
+	if changed, err := codes.Reload(); changed || err != nil {
+		t.Fatalf("Reload() unchanged = %v, %v, want false, nil", changed, err)
+	}
+
+	// New contents are swapped in
+	write("NEWCODE1\n", mtime.Add(time.Minute))
+	if changed, err := codes.Reload(); !changed || err != nil {
+		t.Fatalf("Reload() changed = %v, %v, want true, nil", changed, err)
+	}
+	if ok, _ := codes.IsValid(ctx, "NEWCODE1"); !ok {
+		t.Fatalf("IsValid(NEWCODE1) = false after reload, want true")
+	}
+	if ok, _ := codes.IsValid(ctx, "FIFTYOFF"); ok {
+		t.Fatalf("IsValid(FIFTYOFF) = true after reload, want false")
+	}
+	second, _ := codes.CodeSetStatus(ctx)
+	if second.Version == first.Version || second.CodeCount != 1 {
+		t.Fatalf("status = %+v, want a new version with 1 code", second)
+	}
+
+	// A malformed file keeps the previous set and reports the error
+	write("NEWCODE1\nNEW CODE\n", mtime.Add(2*time.Minute))
+	if _, err := codes.Reload(); !errors.Is(err, promo.ErrMalformedCodeFile) {
+		t.Fatalf("Reload() malformed error = %v, want %v", err, promo.ErrMalformedCodeFile)
+	}
+	if ok, _ := codes.IsValid(ctx, "NEWCODE1"); !ok {
+		t.Fatalf("IsValid(NEWCODE1) = false after rejected reload, want previous set kept")
+	}
+	third, _ := codes.CodeSetStatus(ctx)
+	if third.Version != second.Version || third.LastError == "" {
+		t.Fatalf("status = %+v, want version %s and an error", third, second.Version)
+	}
+}
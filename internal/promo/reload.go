@@ -0,0 +1,194 @@
+package promo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/rs/zerolog"
+)
+
+// codeTable is a loaded set of codes, such as a *CodeSet or an *Index.
+type codeTable interface {
+	IsValid(ctx context.Context, code string) (bool, error)
+	Len() int
+}
+
+// loadedSet is one immutable version of a reloadable code set.
+type loadedSet struct {
+	codes    codeTable
+	checksum string
+	loadedAt time.Time
+	close    func() error // releases codes once replaced; nil if nothing to release
+}
+
+// ReloadingCodeSet serves codes from a file and swaps in new contents when
+// the file changes. Lookups only wait for the swap itself, never for a file
+// to be read; a file that fails to load leaves the previous set in place.
+type ReloadingCodeSet struct {
+	path   string
+	source string // reported as PromoCodeSetStatus.Source
+	load   func(path string) (*loadedSet, error)
+	now    func() time.Time
+
+	// currentMu is held for reading during lookups, so a replaced index is
+	// not unmapped under a lookup that is still using it.
+	currentMu sync.RWMutex
+	current   *loadedSet
+
+	mu          sync.Mutex // serialises reloads and guards the fields below
+	size        int64
+	modTime     time.Time
+	lastErr     error
+	lastChecked time.Time
+}
+
+// NewReloadingCodeSet loads the code file at path and returns a set serving
+// it. now defaults to time.Now when nil.
+func NewReloadingCodeSet(path string, now func() time.Time) (*ReloadingCodeSet, error) {
+	return newReloadingCodeSet(path, "file", func(path string) (*loadedSet, error) {
+		codes, checksum, err := readCodeFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return &loadedSet{codes: codes, checksum: checksum}, nil
+	}, now)
+}
+
+// NewReloadingIndex maps the code index at path, as written by WriteIndex,
+// and returns a set serving it. now defaults to time.Now when nil.
+func NewReloadingIndex(path string, now func() time.Time) (*ReloadingCodeSet, error) {
+	return newReloadingCodeSet(path, "index", func(path string) (*loadedSet, error) {
+		ix, err := OpenIndex(path)
+		if err != nil {
+			return nil, err
+		}
+		return &loadedSet{codes: ix, checksum: ix.Checksum(), close: ix.Close}, nil
+	}, now)
+}
+
+func newReloadingCodeSet(path, source string, load func(string) (*loadedSet, error), now func() time.Time) (*ReloadingCodeSet, error) {
+	if now == nil {
+		now = time.Now
+	}
+	s := &ReloadingCodeSet{
+		path:   path,
+		source: source,
+		load:   load,
+		now:    now,
+	}
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *ReloadingCodeSet) IsValid(ctx context.Context, code string) (bool, error) {
+	s.currentMu.RLock()
+	defer s.currentMu.RUnlock()
+	return s.current.codes.IsValid(ctx, code)
+}
+
+func (s *ReloadingCodeSet) loaded() *loadedSet {
+	s.currentMu.RLock()
+	defer s.currentMu.RUnlock()
+	return s.current
+}
+
+// Reload checks the file and swaps in its codes if its contents changed. A
+// file with the same size and modification time is not read again. On error
+// the previous set stays in use and the error is reported by CodeSetStatus.
+func (s *ReloadingCodeSet) Reload() (changed bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastChecked = s.now()
+	defer func() {
+		s.lastErr = err
+	}()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, fmt.Errorf("stat promo codes file: %w", err)
+	}
+	cur := s.loaded()
+	if cur != nil && info.Size() == s.size && info.ModTime().Equal(s.modTime) {
+		return false, nil
+	}
+
+	next, err := s.load(s.path)
+	if err != nil {
+		return false, err
+	}
+	s.size, s.modTime = info.Size(), info.ModTime()
+
+	if cur != nil && cur.checksum == next.checksum {
+		if next.close != nil {
+			_ = next.close()
+		}
+		return false, nil
+	}
+
+	next.loadedAt = s.now()
+	s.currentMu.Lock()
+	s.current = next
+	s.currentMu.Unlock()
+
+	if cur != nil && cur.close != nil {
+		_ = cur.close()
+	}
+	return true, nil
+}
+
+// Watch reloads the file every interval until ctx is done, logging changes
+// and rejected files with the logger from ctx.
+func (s *ReloadingCodeSet) Watch(ctx context.Context, interval time.Duration) {
+	logger := zerolog.Ctx(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := s.Reload()
+		if err != nil {
+			logger.Error().Err(err).Str("path", s.path).Msg("promo codes reload failed, keeping previous set")
+			continue
+		}
+		if changed {
+			cur := s.loaded()
+			logger.Info().
+				Str("path", s.path).
+				Str("version", cur.checksum).
+				Int("codes", cur.codes.Len()).
+				Msg("promo codes reloaded")
+		}
+	}
+}
+
+func (s *ReloadingCodeSet) CodeSetStatus(_ context.Context) (*domain.PromoCodeSetStatus, error) {
+	cur := s.loaded()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := &domain.PromoCodeSetStatus{
+		Source:        s.source,
+		Location:      s.path,
+		Version:       cur.checksum,
+		CodeCount:     int64(cur.codes.Len()),
+		LoadedAt:      cur.loadedAt,
+		LastCheckedAt: s.lastChecked,
+	}
+	if s.lastErr != nil {
+		st.LastError = s.lastErr.Error()
+	}
+	return st, nil
+}
@@ -0,0 +1,181 @@
+package promo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"time"
+)
+
+// Reasons a line is rejected while filtering, as keys of FileStats.Rejected.
+const (
+	RejectEmpty        = "empty" // empty or only whitespace
+	RejectTooShort     = "tooShort"
+	RejectTooLong      = "tooLong"
+	RejectInvalidChars = "invalidChars"
+	// RejectMalformed is a line with whitespace inside it or a non-printable
+	// character, which no campaign's code has.
+	RejectMalformed = "malformed"
+	// RejectOverlong is a line longer than the read buffer (buf1MB), far
+	// beyond any code length.
+	RejectOverlong = "overlong"
+)
+
+const (
+	// maxRejectSamples is the number of example lines kept per file and reason.
+	maxRejectSamples = 10
+	// maxSampleLength truncates example lines, which may be megabytes long.
+	maxSampleLength = 120
+)
+
+// FileStats are the statistics of one input file. The filter stage fills
+// everything but Duplicates, which the stage that counts codes adds.
+type FileStats struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+	// Bytes is the size read from the file, before decompression.
+	Bytes    int64            `json:"bytes"`
+	Lines    int64            `json:"lines"`
+	Kept     int64            `json:"kept"`
+	Rejected map[string]int64 `json:"rejected"`
+	// Samples are up to maxRejectSamples rejected lines per reason, chosen
+	// uniformly; buildReport moves them to Report.Rejects.
+	Samples map[string][]string `json:"samples,omitempty"`
+	// Duplicates is the number of kept lines repeating a code already kept
+	// from the same file.
+	Duplicates int64 `json:"duplicates"`
+}
+
+func newFileStats(path, format string) FileStats {
+	return FileStats{
+		Path:     path,
+		Format:   format,
+		Rejected: make(map[string]int64),
+		Samples:  make(map[string][]string),
+	}
+}
+
+// reject counts line as rejected for reason, keeping it as a sample with
+// reservoir sampling.
+func (s *FileStats) reject(reason string, line []byte) {
+	s.Rejected[reason]++
+	n := s.Rejected[reason]
+	// Most rejects are not sampled, so their sample is never built
+	if n <= maxRejectSamples {
+		s.Samples[reason] = append(s.Samples[reason], rejectSample(line))
+		return
+	}
+	if j := rand.Int64N(n); j < maxRejectSamples {
+		s.Samples[reason][j] = rejectSample(line)
+	}
+}
+
+func rejectSample(line []byte) string {
+	return string(line[:min(len(line), maxSampleLength)])
+}
+
+// mergeStats are the statistics of a stage that counts codes across files:
+// the merge of the sort strategy, or one bucket of the hash strategy.
+type mergeStats struct {
+	Valid      int64   `json:"valid"`
+	Duplicates []int64 `json:"duplicates"` // per input file
+
+	lines int64 // read, for progress only
+}
+
+func newMergeStats(files int) mergeStats {
+	return mergeStats{Duplicates: make([]int64, files)}
+}
+
+func (s *mergeStats) add(o mergeStats) {
+	s.Valid += o.Valid
+	s.lines += o.lines
+	for i, d := range o.Duplicates {
+		s.Duplicates[i] += d
+	}
+}
+
+// Report is the summary of a run, as returned by Extract.
+type Report struct {
+	Strategy   string      `json:"strategy"`
+	Output     string      `json:"output"`
+	ValidCodes int64       `json:"validCodes"`
+	StartedAt  time.Time   `json:"startedAt"`
+	FinishedAt time.Time   `json:"finishedAt"`
+	Files      []FileStats `json:"files"`
+	// Rejects are written separately, by WriteRejects.
+	Rejects []FileRejects `json:"-"`
+}
+
+// FileRejects are the rejected lines of one input file.
+type FileRejects struct {
+	Path     string                   `json:"path"`
+	Rejected map[string]RejectSummary `json:"rejected"`
+}
+
+type RejectSummary struct {
+	Count   int64    `json:"count"`
+	Samples []string `json:"samples"`
+}
+
+// buildReport assembles the report of a completed run from the stats its
+// stages recorded in m, including stages reused from an earlier run.
+func buildReport(m *runManifest, strategy string, files []string, output string, merged mergeStats, startedAt, finishedAt time.Time) (*Report, error) {
+	r := &Report{
+		Strategy:   strategy,
+		Output:     output,
+		ValidCodes: merged.Valid,
+		StartedAt:  startedAt.UTC(),
+		FinishedAt: finishedAt.UTC(),
+		Files:      make([]FileStats, len(files)),
+		Rejects:    make([]FileRejects, len(files)),
+	}
+	for i := range files {
+		f := &r.Files[i]
+		if err := m.stats(stageName("filter", i), f); err != nil {
+			return nil, err
+		}
+		f.Duplicates = merged.Duplicates[i]
+
+		r.Rejects[i] = FileRejects{Path: f.Path, Rejected: make(map[string]RejectSummary, len(f.Rejected))}
+		for reason, n := range f.Rejected {
+			r.Rejects[i].Rejected[reason] = RejectSummary{Count: n, Samples: f.Samples[reason]}
+		}
+		f.Samples = nil
+	}
+	return r, nil
+}
+
+// WriteReport writes r to path as indented JSON, through fsys (nil → OSFS).
+func WriteReport(fsys FS, path string, r *Report) error {
+	return newLoader(fsys, nil, nil).writeJSON(path, r)
+}
+
+// WriteRejects writes the rejected lines of r to path as indented JSON,
+// through fsys (nil → OSFS).
+func WriteRejects(fsys FS, path string, r *Report) error {
+	return newLoader(fsys, nil, nil).writeJSON(path, struct {
+		Files []FileRejects `json:"files"`
+	}{Files: r.Rejects})
+}
+
+// writeJSON writes v to path as indented JSON, atomically like the outputs.
+func (l *loader) writeJSON(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", filepath.Base(path), err)
+	}
+	out, err := l.createFileSink(path)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	if _, err := out.w.Write(append(b, '\n')); err != nil {
+		out.discard()
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
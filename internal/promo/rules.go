@@ -0,0 +1,156 @@
+package promo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+// Case normalisations accepted by Rules.Case.
+const (
+	CaseKeep  = "keep"
+	CaseUpper = "upper"
+	CaseLower = "lower"
+)
+
+// maxRequiredFiles bounds Rules.RequiredFiles so matches fit in a bitmask.
+const maxRequiredFiles = 64
+
+// Rules decide which codes are valid. Codes are normalised and checked line
+// by line while filtering, then counted across files when merging.
+type Rules struct {
+	MinLength int
+	MaxLength int
+	// AllowedChars matches one allowed character, e.g. `[A-Z0-9]`; nil
+	// allows any.
+	AllowedChars *regexp.Regexp
+	// Case is "keep", "upper" or "lower", applied before the other checks.
+	Case string
+	// MinFiles is the number of distinct input files a code must be in.
+	MinFiles int
+	// RequiredFiles are indexes of input files every code must be in, in
+	// addition to MinFiles.
+	RequiredFiles []int
+}
+
+// The original campaign's codes are 8 to 10 characters long.
+const (
+	minCodeLength = 8
+	maxCodeLength = 10
+)
+
+// DefaultRules are the original campaign's: 8 to 10 characters, in at least
+// two files.
+func DefaultRules() Rules {
+	return Rules{
+		MinLength: minCodeLength,
+		MaxLength: maxCodeLength,
+		Case:      CaseKeep,
+		MinFiles:  2,
+	}
+}
+
+// CompileAllowedChars turns a single-character pattern into one that
+// matches whole codes made of such characters.
+func CompileAllowedChars(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	if _, err := regexp.Compile(expr); err != nil {
+		return nil, err
+	}
+	return regexp.Compile(`^(?:` + expr + `)*$`)
+}
+
+// Validate checks the rules against the number of input files.
+func (r Rules) Validate(files int) error {
+	if r.MinLength < 1 || r.MaxLength < r.MinLength {
+		return fmt.Errorf("length range %d-%d is empty", r.MinLength, r.MaxLength)
+	}
+	switch r.Case {
+	case CaseKeep, CaseUpper, CaseLower:
+	default:
+		return fmt.Errorf("case must be keep, upper or lower, got %q", r.Case)
+	}
+	if r.MinFiles < 1 || r.MinFiles > files {
+		return fmt.Errorf("minimum files %d is not between 1 and the %d input files", r.MinFiles, files)
+	}
+	if len(r.RequiredFiles) > maxRequiredFiles {
+		return fmt.Errorf("at most %d required files are supported", maxRequiredFiles)
+	}
+	for _, i := range r.RequiredFiles {
+		if i < 0 || i >= files {
+			return fmt.Errorf("required file %d is not an input file", i+1)
+		}
+	}
+	return nil
+}
+
+// normalize applies the case rule to line and reports whether the result
+// can be a code.
+func (r Rules) normalize(line string) (string, bool) {
+	code, reason := r.check(line)
+	return code, reason == ""
+}
+
+// check is normalize that also says why line cannot be a code: one of the
+// reject* reasons, or "" when it can. Surrounding whitespace is trimmed, and
+// what remains must be a code LoadCodeFile and WriteIndex accept whatever the
+// rules, so the loader never writes a file its consumers refuse.
+func (r Rules) check(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", RejectEmpty
+	}
+	if !domain.IsPromoCode(line) {
+		return "", RejectMalformed
+	}
+	switch r.Case {
+	case CaseUpper:
+		line = strings.ToUpper(line)
+	case CaseLower:
+		line = strings.ToLower(line)
+	}
+	if n := len(line); n < r.MinLength {
+		return "", RejectTooShort
+	} else if n > r.MaxLength {
+		return "", RejectTooLong
+	}
+	if r.AllowedChars != nil && !r.AllowedChars.MatchString(line) {
+		return "", RejectInvalidChars
+	}
+	return line, ""
+}
+
+// fileMatch is where one code was found.
+type fileMatch struct {
+	files    int    // distinct input files
+	required uint64 // bit i set when found in RequiredFiles[i]
+}
+
+// requiredBits returns, per input file, its bit in fileMatch.required.
+func (r Rules) requiredBits(files int) []uint64 {
+	bits := make([]uint64, files)
+	for pos, i := range r.RequiredFiles {
+		bits[i] |= 1 << pos
+	}
+	return bits
+}
+
+// keep reports whether a code found as m is valid.
+func (r Rules) keep(m fileMatch) bool {
+	all := uint64(1)<<len(r.RequiredFiles) - 1 // all ones for 64 files
+	return m.files >= r.MinFiles && m.required == all
+}
+
+// String describes the rules, for logs and the run manifest.
+func (r Rules) String() string {
+	allowed := ""
+	if r.AllowedChars != nil {
+		allowed = r.AllowedChars.String()
+	}
+	return fmt.Sprintf("length=%d-%d allowed=%q case=%s minFiles=%d required=%v",
+		r.MinLength, r.MaxLength, allowed, r.Case, r.MinFiles, r.RequiredFiles)
+}
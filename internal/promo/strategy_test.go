@@ -0,0 +1,379 @@
+package promo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// Compressions of fixtureSpec.Compress.
+const (
+	fixturePlain = "plain"
+	fixtureGzip  = "gzip"
+	fixtureMixed = "mixed" // a random half of the files are gzip
+)
+
+// fixtureSpec describes the input files written by writeFixtures.
+type fixtureSpec struct {
+	Files int
+	Lines int // per file
+	// Pool is the number of distinct lines the files are drawn from, so
+	// that codes repeat within and across files; 0 means Lines.
+	Pool int
+	// Alphabet and the length range make up the lines, e.g. with mixed
+	// case, spaces or lengths outside the rules.
+	Alphabet             string
+	MinLength, MaxLength int
+	Compress             string
+}
+
+// writeFixtures writes the input files of spec to dir, drawing from rng. It
+// returns the paths and the lines of each file.
+func writeFixtures(tb testing.TB, rng *rand.Rand, dir string, spec fixtureSpec) ([]string, [][]string) {
+	tb.Helper()
+	pool := make([]string, spec.Pool)
+	if spec.Pool == 0 {
+		pool = make([]string, max(spec.Lines, 1))
+	}
+	for i := range pool {
+		b := make([]byte, spec.MinLength+rng.Intn(spec.MaxLength-spec.MinLength+1))
+		for j := range b {
+			b[j] = spec.Alphabet[rng.Intn(len(spec.Alphabet))]
+		}
+		pool[i] = string(b)
+	}
+
+	paths := make([]string, spec.Files)
+	content := make([][]string, spec.Files)
+	for f := range paths {
+		var sb strings.Builder
+		for range spec.Lines {
+			line := pool[rng.Intn(len(pool))]
+			content[f] = append(content[f], line)
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+		}
+		data := []byte(sb.String())
+		paths[f] = filepath.Join(dir, fmt.Sprintf("input_%d.txt", f+1))
+		if spec.Compress == fixtureGzip || spec.Compress == fixtureMixed && rng.Intn(2) == 0 {
+			paths[f] += ".gz"
+			data = gzipBytes(tb, data)
+		}
+		if err := os.WriteFile(paths[f], data, 0o600); err != nil {
+			tb.Fatalf("write fixture: %v", err)
+		}
+	}
+	return paths, content
+}
+
+// writeCodeFixtures writes files gzip files of lines codes each, of mixed
+// case and with some lines of invalid lengths, the same on every call.
+func writeCodeFixtures(tb testing.TB, dir string, files, lines int) ([]string, [][]string) {
+	tb.Helper()
+	return writeFixtures(tb, rand.New(rand.NewSource(1)), dir, fixtureSpec{
+		Files:     files,
+		Lines:     lines,
+		Alphabet:  "ABCDEFGHJKabcdefghjk0123456789-",
+		MinLength: 7,
+		MaxLength: 11,
+		Compress:  fixtureGzip,
+	})
+}
+
+func gzipBytes(tb testing.TB, data []byte) []byte {
+	tb.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		tb.Fatalf("gzip: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		tb.Fatalf("gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// naiveValidCodes is an independent, in-memory reading of the rules: the
+// codes Extract must write, sorted, and their counts lines.
+func naiveValidCodes(rules Rules, content [][]string) ([]string, []string) {
+	seenIn := make(map[string]map[int]int)
+	for f, lines := range content {
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.ContainsFunc(line, func(r rune) bool { return unicode.IsSpace(r) || !unicode.IsPrint(r) }) {
+				continue
+			}
+			switch rules.Case {
+			case CaseUpper:
+				line = strings.ToUpper(line)
+			case CaseLower:
+				line = strings.ToLower(line)
+			}
+			if len(line) < rules.MinLength || len(line) > rules.MaxLength {
+				continue
+			}
+			if rules.AllowedChars != nil && !rules.AllowedChars.MatchString(line) {
+				continue
+			}
+			if seenIn[line] == nil {
+				seenIn[line] = make(map[int]int)
+			}
+			seenIn[line][f]++
+		}
+	}
+
+	var want []string
+	for code, in := range seenIn {
+		ok := len(in) >= rules.MinFiles
+		for _, f := range rules.RequiredFiles {
+			ok = ok && in[f] > 0
+		}
+		if ok {
+			want = append(want, code)
+		}
+	}
+	slices.Sort(want)
+
+	counts := make([]string, len(want))
+	for i, code := range want {
+		occurrences := 0
+		for _, n := range seenIn[code] {
+			occurrences += n
+		}
+		counts[i] = fmt.Sprintf("%s\t%d\t%d", code, len(seenIn[code]), occurrences)
+	}
+	return want, counts
+}
+
+// naiveFileStats computes the report stats of each fixture file, but for
+// the path, format and bytes.
+func naiveFileStats(rules Rules, content [][]string) []FileStats {
+	stats := make([]FileStats, len(content))
+	for f, lines := range content {
+		s := FileStats{Rejected: make(map[string]int64)}
+		seen := make(map[string]bool)
+		for _, l := range lines {
+			s.Lines++
+			code, reason := rules.check(l)
+			if reason != "" {
+				s.Rejected[reason]++
+				continue
+			}
+			s.Kept++
+			if seen[code] {
+				s.Duplicates++
+			}
+			seen[code] = true
+		}
+		stats[f] = s
+	}
+	return stats
+}
+
+// readLines returns the lines of the file at path, nil when it is empty.
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
+
+func TestStrategiesAgree(t *testing.T) {
+	dir := t.TempDir()
+	files, content := writeCodeFixtures(t, dir, 4, 20_000)
+
+	custom := DefaultRules()
+	custom.MinLength = 9
+	custom.MaxLength = 11
+	allowed, err := CompileAllowedChars("[A-Z0-9]")
+	if err != nil {
+		t.Fatalf("CompileAllowedChars() error = %v", err)
+	}
+	custom.AllowedChars = allowed
+	custom.Case = CaseUpper
+	custom.MinFiles = 3
+	custom.RequiredFiles = []int{0, 3}
+
+	strategies := []struct {
+		name string
+		run  func(tmpDir, output, counts string, rules Rules) (*Report, error)
+	}{
+		{name: "sort", run: func(tmpDir, output, counts string, rules Rules) (*Report, error) {
+			return Extract(context.Background(), Options{
+				Strategy:     StrategySort,
+				Files:        files,
+				TmpDir:       tmpDir,
+				Output:       output,
+				CountsOutput: counts,
+				Rules:        rules,
+				Parallelism:  2,
+			})
+		}},
+		{name: "sort with spilled runs", run: func(tmpDir, output, counts string, rules Rules) (*Report, error) {
+			return Extract(context.Background(), Options{
+				Strategy:     StrategySort,
+				Files:        files,
+				TmpDir:       tmpDir,
+				Output:       output,
+				CountsOutput: counts,
+				Rules:        rules,
+				MemoryBudget: 64 << 10,
+				Parallelism:  2,
+			})
+		}},
+		{name: "hash", run: func(tmpDir, output, counts string, rules Rules) (*Report, error) {
+			return Extract(context.Background(), Options{
+				Strategy:     StrategyHash,
+				Files:        files,
+				TmpDir:       tmpDir,
+				Output:       output,
+				CountsOutput: counts,
+				Rules:        rules,
+				Parallelism:  2,
+			})
+		}},
+		{name: "hash with sorted buckets", run: func(tmpDir, output, counts string, rules Rules) (*Report, error) {
+			return Extract(context.Background(), Options{
+				Strategy:     StrategyHash,
+				Files:        files,
+				TmpDir:       tmpDir,
+				Output:       output,
+				CountsOutput: counts,
+				Rules:        rules,
+				Buckets:      4,
+				MemoryBudget: 64 << 10,
+				Parallelism:  2,
+			})
+		}},
+	}
+
+	for rulesName, rules := range map[string]Rules{"default rules": DefaultRules(), "custom rules": custom} {
+		want, wantCounts := naiveValidCodes(rules, content)
+		wantStats := naiveFileStats(rules, content)
+		if len(want) == 0 {
+			t.Fatalf("%s: fixture has no valid codes", rulesName)
+		}
+
+		for _, tt := range strategies {
+			t.Run(rulesName+"/"+tt.name, func(t *testing.T) {
+				dir := t.TempDir()
+				output := filepath.Join(dir, "valid.txt")
+				counts := filepath.Join(dir, "counts.tsv")
+				report, err := tt.run(filepath.Join(dir, "tmp"), output, counts, rules)
+				if err != nil {
+					t.Fatalf("run error = %v", err)
+				}
+				if got := readLines(t, output); !slices.Equal(got, want) {
+					t.Fatalf("output has %d codes, want %d", len(got), len(want))
+				}
+				if got := readLines(t, counts); !slices.Equal(got, wantCounts) {
+					t.Fatalf("counts differ from the naive counts")
+				}
+				if report.ValidCodes != int64(len(want)) {
+					t.Errorf("report valid codes = %d, want %d", report.ValidCodes, len(want))
+				}
+				for i, got := range report.Files {
+					got.Path, got.Format, got.Bytes = "", "", 0
+					if !reflect.DeepEqual(got, wantStats[i]) {
+						t.Errorf("report of file %d = %+v, want %+v", i+1, got, wantStats[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestMergeSortedFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, lines ...string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatalf("write input: %v", err)
+		}
+		return path
+	}
+
+	// Duplicates within one file must not count as a second file
+	inputs := []string{
+		write("a", "AAAAAAAA", "AAAAAAAA", "BBBBBBBB", "CCCCCCCC", "EEEEEEEE", "EEEEEEEE"),
+		write("b", "AAAAAAAA", "CCCCCCCC", "CCCCCCCC"),
+		write("c", "BBBBBBBB", "DDDDDDDD"),
+	}
+	output := filepath.Join(dir, "valid.txt")
+	counts := filepath.Join(dir, "counts.tsv")
+	if _, err := newLoader(nil, nil, nil).mergeSortedFiles(context.Background(), inputs, output, counts, DefaultRules()); err != nil {
+		t.Fatalf("newLoader(nil, nil, nil).mergeSortedFiles() error = %v", err)
+	}
+
+	if got, want := readLines(t, output), []string{"AAAAAAAA", "BBBBBBBB", "CCCCCCCC"}; !slices.Equal(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	wantCounts := []string{"AAAAAAAA\t2\t3", "BBBBBBBB\t2\t2", "CCCCCCCC\t2\t3"}
+	if got := readLines(t, counts); !slices.Equal(got, wantCounts) {
+		t.Errorf("counts = %q, want %q", got, wantCounts)
+	}
+}
+
+func TestMergeSortedFiles_ManyInputs(t *testing.T) {
+	const files = 300
+	dir := t.TempDir()
+
+	// File i holds codes i and i+1, so every code but the first and last
+	// is in two files.
+	inputs := make([]string, files)
+	for i := range inputs {
+		inputs[i] = filepath.Join(dir, fmt.Sprintf("file_%d.sorted", i))
+		content := fmt.Sprintf("CODE%04d\nCODE%04d\n", i, i+1)
+		if err := os.WriteFile(inputs[i], []byte(content), 0o600); err != nil {
+			t.Fatalf("write input: %v", err)
+		}
+	}
+
+	output := filepath.Join(dir, "valid.txt")
+	if _, err := newLoader(nil, nil, nil).mergeSortedFiles(context.Background(), inputs, output, "", DefaultRules()); err != nil {
+		t.Fatalf("newLoader(nil, nil, nil).mergeSortedFiles() error = %v", err)
+	}
+	got := readLines(t, output)
+	if len(got) != files-1 || got[0] != "CODE0001" || got[len(got)-1] != fmt.Sprintf("CODE%04d", files-1) {
+		t.Fatalf("output has %d codes from %s to %s, want %d from CODE0001", len(got), got[0], got[len(got)-1], files-1)
+	}
+}
+
+func TestRules_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Rules)
+	}{
+		{name: "empty length range", modify: func(r *Rules) { r.MinLength, r.MaxLength = 10, 8 }},
+		{name: "unknown case", modify: func(r *Rules) { r.Case = "title" }},
+		{name: "more files than inputs", modify: func(r *Rules) { r.MinFiles = 4 }},
+		{name: "required file out of range", modify: func(r *Rules) { r.RequiredFiles = []int{3} }},
+	}
+
+	if err := DefaultRules().Validate(3); err != nil {
+		t.Fatalf("DefaultRules().Validate() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := DefaultRules()
+			tt.modify(&r)
+			if err := r.Validate(3); err == nil {
+				t.Fatalf("validate() error = nil, want error")
+			}
+		})
+	}
+}
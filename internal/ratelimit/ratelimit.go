@@ -0,0 +1,72 @@
+// Package ratelimit implements token-bucket rate limiting over a pluggable
+// bucket store, so limits can be kept in memory for a single instance or in
+// a shared database for multi-instance deployments.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is a token-bucket limit: buckets hold up to Burst tokens and refill
+// at Rate tokens per second. Each request takes one token.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute returns a limit of n requests per minute with the given burst.
+func PerMinute(n, burst int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: burst}
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed   bool
+	Limit     int           // bucket capacity
+	Remaining int           // whole tokens left after this request
+	Reset     time.Duration // until the bucket is full again
+	// RetryAfter is how long to wait for the next token; zero when Allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets by key.
+type Store interface {
+	// Take removes one token from the bucket for key, creating a full
+	// bucket if there is none.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Take is the bucket arithmetic shared by Store implementations: given a
+// bucket holding tokens as of last, it refills it up to now, tries to take
+// one token and returns the bucket's new token count with the result.
+func Take(tokens float64, last, now time.Time, l Limit) (float64, Result) {
+	burst := float64(l.Burst)
+
+	elapsed := now.Sub(last).Seconds()
+	if elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed*l.Rate)
+	}
+
+	res := Result{Limit: l.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / l.Rate)
+	}
+
+	res.Remaining = int(math.Floor(tokens))
+	res.Reset = seconds((burst - tokens) / l.Rate)
+	return tokens, res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
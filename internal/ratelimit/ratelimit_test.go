@@ -0,0 +1,86 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/ratelimit"
+)
+
+func TestTake(t *testing.T) {
+	limit := ratelimit.Limit{Rate: 1, Burst: 3} // 1 token per second
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tokens := float64(limit.Burst)
+	last := start
+
+	// Drain the burst
+	for i := 2; i >= 0; i-- {
+		var res ratelimit.Result
+		tokens, res = ratelimit.Take(tokens, last, start, limit)
+		last = start
+		if !res.Allowed || res.Remaining != i {
+			t.Fatalf("take: got %+v, want allowed with %d remaining", res, i)
+		}
+	}
+
+	// Empty bucket is refused with a retry hint
+	tokens, res := ratelimit.Take(tokens, last, start, limit)
+	if res.Allowed {
+		t.Fatalf("take on empty bucket allowed, want refused")
+	}
+	if res.RetryAfter != time.Second {
+		t.Fatalf("RetryAfter = %v, want 1s", res.RetryAfter)
+	}
+	if res.Reset != 3*time.Second {
+		t.Fatalf("Reset = %v, want 3s", res.Reset)
+	}
+
+	// Refills over time, capped at the burst
+	_, res = ratelimit.Take(tokens, last, start.Add(time.Hour), limit)
+	if !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("take after an hour: got %+v, want allowed with 2 remaining", res)
+	}
+}
+
+func TestPerMinute(t *testing.T) {
+	l := ratelimit.PerMinute(120, 10)
+	if l.Rate != 2 || l.Burst != 10 || !l.Enabled() {
+		t.Fatalf("PerMinute(120, 10) = %+v, want 2/s burst 10", l)
+	}
+	if (ratelimit.Limit{}).Enabled() {
+		t.Fatalf("zero Limit should be disabled")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := ratelimit.NewMemoryStore(func() time.Time { return now })
+	limit := ratelimit.Limit{Rate: 1, Burst: 2}
+	ctx := context.Background()
+
+	for i, want := range []bool{true, true, false} {
+		res, err := store.Take(ctx, "a", limit)
+		if err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+		if res.Allowed != want {
+			t.Fatalf("take %d: Allowed = %v, want %v", i, res.Allowed, want)
+		}
+	}
+
+	// Keys have independent buckets
+	if res, _ := store.Take(ctx, "b", limit); !res.Allowed {
+		t.Fatalf("other key refused, want allowed")
+	}
+
+	// Refilled buckets are swept
+	now = now.Add(time.Hour)
+	if _, err := store.Take(ctx, "c", limit); err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if n := store.Len(); n != 1 {
+		t.Fatalf("Len() = %d after sweep, want 1", n)
+	}
+}
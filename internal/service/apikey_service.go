@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+// APIKeyService authenticates API clients by their raw key.
+type APIKeyService interface {
+	// Authenticate returns the client owning rawKey. It returns
+	// domain.ErrAPIKeyNotFound, domain.ErrAPIKeyExpired or domain.ErrAPIKeyRevoked
+	// when the key cannot be used.
+	Authenticate(ctx context.Context, rawKey string) (*domain.APIClient, error)
+}
+
+type apiKeyService struct {
+	repo domain.APIKeyRepository
+	now  func() time.Time
+}
+
+// NewAPIKeyService builds an APIKeyService. now defaults to time.Now when nil.
+func NewAPIKeyService(repo domain.APIKeyRepository, now func() time.Time) APIKeyService {
+	if now == nil {
+		now = time.Now
+	}
+	return &apiKeyService{
+		repo: repo,
+		now:  now,
+	}
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*domain.APIClient, error) {
+	if rawKey == "" {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	hash := domain.HashAPIKey(rawKey)
+
+	client, err := s.repo.FindByHash(ctx, hash)
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lookup api key: %w", err)
+	}
+
+	// The lookup is by hash already; compare again in constant time so a
+	// misbehaving store can never let a different key through.
+	if subtle.ConstantTimeCompare([]byte(client.KeyHash), []byte(hash)) != 1 {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	if err := client.CheckActive(s.now()); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
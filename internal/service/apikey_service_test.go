@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/service"
+)
+
+// stubAPIKeyRepo implements domain.APIKeyRepository for tests
+type stubAPIKeyRepo struct {
+	clients map[string]domain.APIClient
+	err     error
+}
+
+func (s *stubAPIKeyRepo) FindByHash(ctx context.Context, keyHash string) (*domain.APIClient, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	c, ok := s.clients[keyHash]
+	if !ok {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	return &c, nil
+}
+
+// complie-time checks
+var _ domain.APIKeyRepository = (*stubAPIKeyRepo)(nil)
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	clients := map[string]domain.APIClient{}
+	for _, kv := range []struct {
+		raw    string
+		client domain.APIClient
+	}{
+		{"good", domain.APIClient{ID: "good"}},
+		{"not-yet-expired", domain.APIClient{ID: "nye", ExpiresAt: &future}},
+		{"expired", domain.APIClient{ID: "exp", ExpiresAt: &past}},
+		{"revoked", domain.APIClient{ID: "rev", RevokedAt: &past}},
+	} {
+		kv.client.KeyHash = domain.HashAPIKey(kv.raw)
+		clients[kv.client.KeyHash] = kv.client
+	}
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantID  string
+		wantErr error
+	}{
+		{name: "valid", raw: "good", wantID: "good"},
+		{name: "expiry in the future", raw: "not-yet-expired", wantID: "nye"},
+		{name: "empty key", raw: "", wantErr: domain.ErrAPIKeyNotFound},
+		{name: "unknown key", raw: "nope", wantErr: domain.ErrAPIKeyNotFound},
+		{name: "expired", raw: "expired", wantErr: domain.ErrAPIKeyExpired},
+		{name: "revoked", raw: "revoked", wantErr: domain.ErrAPIKeyRevoked},
+	}
+
+	svc := service.NewAPIKeyService(&stubAPIKeyRepo{clients: clients}, fixedClock(now))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := svc.Authenticate(context.Background(), tt.raw)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v, want nil", err)
+			}
+			if c.ID != tt.wantID {
+				t.Fatalf("Authenticate() client = %q, want %q", c.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate_RepoError(t *testing.T) {
+	repoErr := errors.New("db down")
+	svc := service.NewAPIKeyService(&stubAPIKeyRepo{err: repoErr}, nil)
+
+	_, err := svc.Authenticate(context.Background(), "apitest")
+	if !errors.Is(err, repoErr) {
+		t.Fatalf("Authenticate() error = %v, want to wrap %v", err, repoErr)
+	}
+}
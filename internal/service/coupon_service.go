@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+// CouponService checks coupon codes entered with orders and locks out
+// callers that keep entering unknown codes.
+type CouponService interface {
+	// CheckCoupon checks code on behalf of attemptKey, which identifies the
+	// caller (for example "client:<id>" or "ip:<addr>").
+	//
+	// It returns domain.ErrUnknownCouponCode for unknown codes, and a
+	// *domain.CouponLockoutError while attemptKey is locked out, whether
+	// or not code is valid.
+	CheckCoupon(ctx context.Context, attemptKey, code string) error
+}
+
+type couponService struct {
+	validator domain.PromoCodeValidator
+	attempts  domain.CouponAttemptTracker
+}
+
+func NewCouponService(validator domain.PromoCodeValidator, attempts domain.CouponAttemptTracker) CouponService {
+	return &couponService{
+		validator: validator,
+		attempts:  attempts,
+	}
+}
+
+func (s *couponService) CheckCoupon(ctx context.Context, attemptKey, code string) error {
+	// Locked out callers learn nothing about the code they sent
+	until, err := s.attempts.LockedUntil(ctx, attemptKey)
+	if err != nil {
+		return fmt.Errorf("lookup coupon lockout: %w", err)
+	}
+	if !until.IsZero() {
+		return &domain.CouponLockoutError{Until: until}
+	}
+
+	ok, err := s.validator.IsValid(ctx, code)
+	if err != nil {
+		return fmt.Errorf("validate coupon: %w", err)
+	}
+	if ok {
+		// A valid code does not clear earlier failures, or guesses could
+		// be interleaved with a known code to dodge the lockout.
+		return nil
+	}
+
+	until, err = s.attempts.RecordFailure(ctx, attemptKey)
+	if err != nil {
+		return fmt.Errorf("record coupon failure: %w", err)
+	}
+	if !until.IsZero() {
+		return &domain.CouponLockoutError{Until: until}
+	}
+
+	return domain.ErrUnknownCouponCode
+}
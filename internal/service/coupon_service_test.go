@@ -0,0 +1,77 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/coupon"
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/promo"
+	"github.com/M-Arthur/order-food-api/internal/service"
+)
+
+// failingValidator implements domain.PromoCodeValidator and always fails
+type failingValidator struct{}
+
+func (failingValidator) IsValid(ctx context.Context, code string) (bool, error) {
+	return false, errors.New("validator down")
+}
+
+// complie-time checks
+var _ domain.PromoCodeValidator = failingValidator{}
+
+func TestCouponService_CheckCoupon(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	codes := promo.NewCodeSet("FIFTYOFF", "BIRTHDAY")
+	policy := coupon.LockoutPolicy{Threshold: 3, Window: 10 * time.Minute, Duration: 15 * time.Minute}
+	svc := service.NewCouponService(codes, coupon.NewMemoryLockout(policy, clock))
+
+	if err := svc.CheckCoupon(ctx, "ip:1.2.3.4", "FIFTYOFF"); err != nil {
+		t.Fatalf("CheckCoupon(valid) error = %v, want nil", err)
+	}
+
+	// Two misses are reported as unknown codes
+	for _, code := range []string{"GUESS000", "GUESS001"} {
+		if err := svc.CheckCoupon(ctx, "ip:1.2.3.4", code); !errors.Is(err, domain.ErrUnknownCouponCode) {
+			t.Fatalf("CheckCoupon(%s) error = %v, want %v", code, err, domain.ErrUnknownCouponCode)
+		}
+	}
+
+	// The third locks the caller out
+	err := svc.CheckCoupon(ctx, "ip:1.2.3.4", "GUESS002")
+	var lockout *domain.CouponLockoutError
+	if !errors.As(err, &lockout) {
+		t.Fatalf("third miss: error = %v, want *domain.CouponLockoutError", err)
+	}
+	if want := now.Add(15 * time.Minute); !lockout.Until.Equal(want) {
+		t.Fatalf("lockout.Until = %v, want %v", lockout.Until, want)
+	}
+
+	// Even valid codes are refused while locked out, other callers are not
+	if err := svc.CheckCoupon(ctx, "ip:1.2.3.4", "FIFTYOFF"); !errors.Is(err, domain.ErrCouponLockedOut) {
+		t.Fatalf("valid code while locked: error = %v, want %v", err, domain.ErrCouponLockedOut)
+	}
+	if err := svc.CheckCoupon(ctx, "client:dev", "FIFTYOFF"); err != nil {
+		t.Fatalf("other caller: error = %v, want nil", err)
+	}
+
+	// The lockout ends
+	now = now.Add(15 * time.Minute)
+	if err := svc.CheckCoupon(ctx, "ip:1.2.3.4", "BIRTHDAY"); err != nil {
+		t.Fatalf("after lockout: error = %v, want nil", err)
+	}
+}
+
+func TestCouponService_CheckCoupon_ValidatorError(t *testing.T) {
+	svc := service.NewCouponService(failingValidator{}, coupon.NewMemoryLockout(coupon.LockoutPolicy{}, nil))
+
+	err := svc.CheckCoupon(context.Background(), "ip:1.2.3.4", "FIFTYOFF")
+	if err == nil || errors.Is(err, domain.ErrUnknownCouponCode) {
+		t.Fatalf("CheckCoupon() error = %v, want validator failure", err)
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/google/uuid"
+)
+
+// CreateCustomerInput carries the details of a new customer.
+type CreateCustomerInput struct {
+	Name  string
+	Email string
+	Phone string
+}
+
+// CustomerService manages customers and their order history.
+type CustomerService interface {
+	CreateCustomer(ctx context.Context, in CreateCustomerInput) (*domain.Customer, error)
+	// GetCustomer returns domain.ErrCustomerNotFound for unknown customers.
+	GetCustomer(ctx context.Context, id domain.CustomerID) (*domain.Customer, error)
+	// ListOrders returns the customer's orders, newest first, together with
+	// the products they reference, by store and as sold at that store, so
+	// callers can render them.
+	ListOrders(ctx context.Context, id domain.CustomerID) ([]domain.Order, map[domain.StoreID]map[domain.ProductID]domain.Product, error)
+}
+
+type customerService struct {
+	customerRepo domain.CustomerRepository
+	orderRepo    domain.OrderRepository
+	productRepo  domain.ProductRepository
+	now          func() time.Time
+}
+
+// NewCustomerService builds a CustomerService. now defaults to time.Now when nil.
+func NewCustomerService(
+	customerRepo domain.CustomerRepository,
+	orderRepo domain.OrderRepository,
+	productRepo domain.ProductRepository,
+	now func() time.Time,
+) CustomerService {
+	if now == nil {
+		now = time.Now
+	}
+	return &customerService{
+		customerRepo: customerRepo,
+		orderRepo:    orderRepo,
+		productRepo:  productRepo,
+		now:          now,
+	}
+}
+
+func (s *customerService) CreateCustomer(ctx context.Context, in CreateCustomerInput) (*domain.Customer, error) {
+	id := domain.CustomerID(uuid.NewString())
+
+	customer, err := domain.NewCustomer(id, in.Name, in.Email, in.Phone, s.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.customerRepo.Create(ctx, customer); err != nil {
+		return nil, fmt.Errorf("persist customer: %w", err)
+	}
+
+	return customer, nil
+}
+
+func (s *customerService) GetCustomer(ctx context.Context, id domain.CustomerID) (*domain.Customer, error) {
+	return s.customerRepo.GetByID(ctx, id)
+}
+
+func (s *customerService) ListOrders(
+	ctx context.Context,
+	id domain.CustomerID,
+) ([]domain.Order, map[domain.StoreID]map[domain.ProductID]domain.Product, error) {
+	// Distinguish "unknown customer" from "customer without orders".
+	if _, err := s.customerRepo.GetByID(ctx, id); err != nil {
+		return nil, nil, err
+	}
+
+	orders, err := s.orderRepo.ListByCustomer(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list orders for customer %s: %w", id, err)
+	}
+
+	// Prices and availability differ per store, so products are looked up
+	// at the store each order was placed at.
+	uniqueIDsByStore := make(map[domain.StoreID]map[domain.ProductID]struct{})
+	for _, order := range orders {
+		ids := uniqueIDsByStore[order.StoreID]
+		if ids == nil {
+			ids = make(map[domain.ProductID]struct{})
+			uniqueIDsByStore[order.StoreID] = ids
+		}
+		for _, item := range order.Items {
+			ids[item.ProductID] = struct{}{}
+		}
+	}
+
+	products := make(map[domain.StoreID]map[domain.ProductID]domain.Product, len(uniqueIDsByStore))
+	for storeID, ids := range uniqueIDsByStore {
+		uniqueIDs := make([]domain.ProductID, 0, len(ids))
+		for pid := range ids {
+			uniqueIDs = append(uniqueIDs, pid)
+		}
+
+		storeProducts, err := s.productRepo.GetProductByIDsForStore(ctx, storeID, uniqueIDs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("lookup products for customer orders at store %s: %w", storeID, err)
+		}
+		products[storeID] = storeProducts
+	}
+
+	return orders, products, nil
+}
@@ -0,0 +1,176 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/service"
+)
+
+// stubCustomerRepo implements domain.CustomerRepository for tests.
+// A nil customers map means every customer exists.
+type stubCustomerRepo struct {
+	customers map[domain.CustomerID]domain.Customer
+	createErr error
+
+	created *domain.Customer
+}
+
+func (s *stubCustomerRepo) Create(ctx context.Context, customer *domain.Customer) error {
+	if s.createErr != nil {
+		return s.createErr
+	}
+	s.created = customer
+	return nil
+}
+
+func (s *stubCustomerRepo) GetByID(ctx context.Context, id domain.CustomerID) (*domain.Customer, error) {
+	if s.customers == nil {
+		return &domain.Customer{ID: id}, nil
+	}
+	c, ok := s.customers[id]
+	if !ok {
+		return nil, domain.ErrCustomerNotFound
+	}
+	return &c, nil
+}
+
+// complie-time checks
+var _ domain.CustomerRepository = (*stubCustomerRepo)(nil)
+
+func TestCustomerService_CreateCustomer(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	repo := &stubCustomerRepo{}
+	svc := service.NewCustomerService(repo, &stubOrderRepo{}, &stubProductRepoForOrder{}, fixedClock(now))
+
+	c, err := svc.CreateCustomer(context.Background(), service.CreateCustomerInput{
+		Name:  " Ada Lovelace ",
+		Email: "Ada@Example.com",
+		Phone: "+61 400 000 000",
+	})
+	if err != nil {
+		t.Fatalf("CreateCustomer() error = %v, want nil", err)
+	}
+	if c.ID == "" {
+		t.Fatalf("customer.ID is empty, want non-empty")
+	}
+	if c.Name != "Ada Lovelace" || c.Email != "ada@example.com" {
+		t.Errorf("customer = %+v, want trimmed name and lower-cased email", c)
+	}
+	if !c.CreatedAt.Equal(now) {
+		t.Errorf("customer.CreatedAt = %v, want %v", c.CreatedAt, now)
+	}
+	if repo.created != c {
+		t.Errorf("customer was not persisted")
+	}
+}
+
+func TestCustomerService_CreateCustomer_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      service.CreateCustomerInput
+		repoErr error
+		wantErr error
+	}{
+		{
+			name:    "missing name",
+			in:      service.CreateCustomerInput{Email: "ada@example.com"},
+			wantErr: domain.ErrInvalidCustomerName,
+		},
+		{
+			name:    "bad email",
+			in:      service.CreateCustomerInput{Name: "Ada", Email: "not-an-email"},
+			wantErr: domain.ErrInvalidCustomerEmail,
+		},
+		{
+			name:    "bad phone",
+			in:      service.CreateCustomerInput{Name: "Ada", Email: "ada@example.com", Phone: "call me"},
+			wantErr: domain.ErrInvalidCustomerPhone,
+		},
+		{
+			name:    "email taken",
+			in:      service.CreateCustomerInput{Name: "Ada", Email: "ada@example.com"},
+			repoErr: domain.ErrCustomerEmailTaken,
+			wantErr: domain.ErrCustomerEmailTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubCustomerRepo{createErr: tt.repoErr}
+			svc := service.NewCustomerService(repo, &stubOrderRepo{}, &stubProductRepoForOrder{}, nil)
+
+			_, err := svc.CreateCustomer(context.Background(), tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateCustomer() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// storeProductRepo serves products with per-store prices, and panics on
+// global lookups.
+type storeProductRepo struct {
+	*stubProductRepoForOrder
+	byStore map[domain.StoreID]map[domain.ProductID]domain.Product
+}
+
+func (s *storeProductRepo) GetProductByIDs(ctx context.Context, ids []domain.ProductID) (map[domain.ProductID]domain.Product, error) {
+	panic("GetProductByIDs should not be called for customer orders")
+}
+
+func (s *storeProductRepo) GetProductByIDsForStore(ctx context.Context, storeID domain.StoreID, ids []domain.ProductID) (map[domain.ProductID]domain.Product, error) {
+	out := make(map[domain.ProductID]domain.Product, len(ids))
+	for _, id := range ids {
+		if p, ok := s.byStore[storeID][id]; ok {
+			out[id] = p
+		}
+	}
+	return out, nil
+}
+
+func TestCustomerService_ListOrders(t *testing.T) {
+	customers := map[domain.CustomerID]domain.Customer{"c1": {ID: "c1"}}
+	orderRepo := &stubOrderRepo{
+		orders: []domain.Order{
+			{ID: "o3", StoreID: "cbd", Items: []domain.OrderItem{{ProductID: "10", Quantity: 1}}},
+			{ID: "o2", StoreID: domain.DefaultStoreID, Items: []domain.OrderItem{{ProductID: "10", Quantity: 1}}},
+			{ID: "o1", StoreID: domain.DefaultStoreID, Items: []domain.OrderItem{{ProductID: "11", Quantity: 2}}},
+		},
+	}
+	productRepo := &storeProductRepo{byStore: map[domain.StoreID]map[domain.ProductID]domain.Product{
+		domain.DefaultStoreID: {
+			"10": {ID: "10", Name: "Chicken Waffle", Price: 650},
+			"11": {ID: "11", Name: "Fries", Price: 400},
+		},
+		"cbd": {
+			"10": {ID: "10", Name: "Chicken Waffle", Price: 750},
+		},
+	}}
+	svc := service.NewCustomerService(&stubCustomerRepo{customers: customers}, orderRepo, productRepo, nil)
+
+	orders, products, err := svc.ListOrders(context.Background(), "c1")
+	if err != nil {
+		t.Fatalf("ListOrders() error = %v, want nil", err)
+	}
+	if len(orders) != 3 || orders[0].ID != "o3" {
+		t.Fatalf("orders = %+v, want repo order preserved", orders)
+	}
+	if len(products) != 2 || len(products[domain.DefaultStoreID]) != 2 || len(products["cbd"]) != 1 {
+		t.Fatalf("products = %+v, want 2 products at the default store and 1 at cbd", products)
+	}
+	if got := products["cbd"]["10"].Price; got != 750 {
+		t.Errorf("cbd price of product 10 = %d, want the store's price 750", got)
+	}
+	if got := products[domain.DefaultStoreID]["10"].Price; got != 650 {
+		t.Errorf("default store price of product 10 = %d, want 650", got)
+	}
+
+	_, _, err = svc.ListOrders(context.Background(), "unknown")
+	if !errors.Is(err, domain.ErrCustomerNotFound) {
+		t.Fatalf("ListOrders() error = %v, want %v", err, domain.ErrCustomerNotFound)
+	}
+}
@@ -3,33 +3,84 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/M-Arthur/order-food-api/internal/domain"
 	"github.com/google/uuid"
 )
 
+// CreateOrderInput carries everything needed to place an order.
+type CreateOrderInput struct {
+	StoreID    domain.StoreID
+	CustomerID *domain.CustomerID // optional, nil for guest orders
+	Items      []domain.OrderItem
+	CouponCode *string
+	Fulfilment domain.Fulfilment
+	// AttemptKey identifies the caller for coupon lockouts, e.g. "client:<id>"
+	AttemptKey string
+}
+
 type OrderService interface {
-	CreateOrder(ctx context.Context, items []domain.OrderItem, couponCode *string) (*domain.Order, []domain.Product, error)
+	CreateOrder(ctx context.Context, in CreateOrderInput) (*domain.Order, []domain.Product, error)
+
+	// CancelOrder cancels a placed order and releases its coupon redemption.
+	// It returns domain.ErrOrderNotFound or domain.ErrOrderAlreadyCancelled
+	// when there is nothing to cancel.
+	CancelOrder(ctx context.Context, id domain.OrderID) error
 }
 
 type orderService struct {
-	productRepo domain.ProductRepository
-	orderRepo   domain.OrderRepository
+	productRepo  domain.ProductRepository
+	orderRepo    domain.OrderRepository
+	zoneRepo     domain.DeliveryZoneRepository
+	customerRepo domain.CustomerRepository
+	store        StoreService
+	coupons      CouponService
+	now          func() time.Time
 }
 
-func NewOrderService(orderRepo domain.OrderRepository, productRepo domain.ProductRepository) OrderService {
+// NewOrderService builds an OrderService. now, which stamps orders when they
+// are placed and cancelled, defaults to time.Now when nil.
+func NewOrderService(
+	orderRepo domain.OrderRepository,
+	productRepo domain.ProductRepository,
+	zoneRepo domain.DeliveryZoneRepository,
+	customerRepo domain.CustomerRepository,
+	store StoreService,
+	coupons CouponService,
+	now func() time.Time,
+) OrderService {
+	if now == nil {
+		now = time.Now
+	}
 	return &orderService{
-		productRepo: productRepo,
-		orderRepo:   orderRepo,
+		productRepo:  productRepo,
+		orderRepo:    orderRepo,
+		zoneRepo:     zoneRepo,
+		customerRepo: customerRepo,
+		store:        store,
+		coupons:      coupons,
+		now:          now,
 	}
 }
 
 func (s *orderService) CreateOrder(
 	ctx context.Context,
-	items []domain.OrderItem,
-	couponCode *string,
+	in CreateOrderInput,
 ) (*domain.Order, []domain.Product, error) {
-	// 1. Collect unique product IDs from the order items
+	items := in.Items
+
+	// 1. Make sure the store, and the customer if one is given, exist
+	if _, err := s.store.GetStore(ctx, in.StoreID); err != nil {
+		return nil, nil, fmt.Errorf("lookup store %s for order: %w", in.StoreID, err)
+	}
+	if in.CustomerID != nil {
+		if _, err := s.customerRepo.GetByID(ctx, *in.CustomerID); err != nil {
+			return nil, nil, fmt.Errorf("lookup customer %s for order: %w", *in.CustomerID, err)
+		}
+	}
+
+	// 2. Collect unique product IDs from the order items
 	uniqueIDsMap := make(map[domain.ProductID]struct{})
 	for _, item := range items {
 		uniqueIDsMap[item.ProductID] = struct{}{}
@@ -40,33 +91,62 @@ func (s *orderService) CreateOrder(
 		uniqueIDs = append(uniqueIDs, id)
 	}
 
-	// 2. Batch fetch from product repo
-	productsByID, err := s.productRepo.GetProductByIDs(ctx, uniqueIDs)
+	// 3. Batch fetch from product repo, with the store's availability and prices
+	productsByID, err := s.productRepo.GetProductByIDsForStore(ctx, in.StoreID, uniqueIDs)
 	if err != nil {
 		return nil, nil, fmt.Errorf("lookup products for order: %w", err)
 	}
 
-	// 3. Ensure all products exist
+	// 4. Ensure all products exist at this store
 	for _, item := range items {
 		if _, ok := productsByID[item.ProductID]; !ok {
-			return nil, nil, fmt.Errorf("product %s does not exist: %w", item.ProductID, domain.ErrProductNotFound)
+			return nil, nil, fmt.Errorf("product %s is not available at store %s: %w", item.ProductID, in.StoreID, domain.ErrProductNotFound)
 		}
 	}
 
-	// 4. Generate a new OrderID
+	// 5. Generate a new OrderID
 	newOrderID := domain.OrderID(uuid.NewString())
 
-	order, err := domain.NewOrder(newOrderID, items, couponCode)
+	order, err := domain.NewOrder(newOrderID, in.StoreID, items, in.CouponCode, in.Fulfilment)
 	if err != nil {
 		return nil, nil, err
 	}
+	order.CustomerID = in.CustomerID
+	order.CreatedAt = s.now().UTC()
+
+	// 6. Check the coupon, if any, once the order itself is valid
+	if order.CouponCode != nil {
+		if err := s.coupons.CheckCoupon(ctx, in.AttemptKey, *order.CouponCode); err != nil {
+			return nil, nil, fmt.Errorf("check coupon: %w", err)
+		}
+	}
 
-	// 5. Persist into DB
+	// 7. Price the order, including the delivery fee for the customer's zone
+	var deliveryFee domain.Money
+	if order.Fulfilment.Type == domain.FulfilmentDelivery {
+		zone, err := s.zoneRepo.FindZoneForPostcode(ctx, in.StoreID, order.Fulfilment.Address.Postcode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("lookup delivery zone: %w", err)
+		}
+		deliveryFee = zone.Fee
+	}
+
+	order.Pricing, err = domain.PriceOrder(order.Items, productsByID, deliveryFee)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 8. Make sure the store is open and ordering isn't paused
+	if err := s.store.EnsureAcceptingOrders(ctx, in.StoreID); err != nil {
+		return nil, nil, err
+	}
+
+	// 9. Persist into DB, redeeming the coupon within its caps
 	if err := s.orderRepo.Save(ctx, order); err != nil {
 		return nil, nil, fmt.Errorf("persist order: %w", err)
 	}
 
-	// 6. Prepare the slice of products in a consistent order
+	// 10. Prepare the slice of products in a consistent order
 	products := make([]domain.Product, 0, len(productsByID))
 	for _, item := range items {
 		// This preserves the order as used in the request
@@ -77,3 +157,10 @@ func (s *orderService) CreateOrder(
 
 	return order, products, nil
 }
+
+func (s *orderService) CancelOrder(ctx context.Context, id domain.OrderID) error {
+	if err := s.orderRepo.Cancel(ctx, id, s.now().UTC()); err != nil {
+		return fmt.Errorf("cancel order %s: %w", id, err)
+	}
+	return nil
+}
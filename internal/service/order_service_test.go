@@ -3,7 +3,9 @@ package service_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/M-Arthur/order-food-api/internal/domain"
 	"github.com/M-Arthur/order-food-api/internal/service"
@@ -13,6 +15,8 @@ import (
 type stubProductRepoForOrder struct {
 	productsByID map[domain.ProductID]domain.Product
 	err          error
+
+	gotStoreID domain.StoreID
 }
 
 func (s *stubProductRepoForOrder) ListProducts(ctx context.Context) ([]domain.Product, error) {
@@ -48,11 +52,27 @@ func (s *stubProductRepoForOrder) GetProductByIDs(ctx context.Context, ids []dom
 	return out, nil
 }
 
+func (s *stubProductRepoForOrder) ListProductsByStore(ctx context.Context, storeID domain.StoreID) ([]domain.Product, error) {
+	panic("ListProductsByStore should not be called in OrderService tests")
+}
+
+func (s *stubProductRepoForOrder) GetProductByIDsForStore(ctx context.Context, storeID domain.StoreID, ids []domain.ProductID) (map[domain.ProductID]domain.Product, error) {
+	s.gotStoreID = storeID
+	return s.GetProductByIDs(ctx, ids)
+}
+
 // stubOrderRepo implements domain.OrderRepository for Orderservice tests
 type stubOrderRepo struct {
 	savedOrder *domain.Order
 	saveErr    error
 	saveCalls  int
+
+	orders  []domain.Order
+	listErr error
+
+	cancelErr   error
+	cancelledID domain.OrderID
+	cancelledAt time.Time
 }
 
 func (s *stubOrderRepo) Save(ctx context.Context, order *domain.Order) error {
@@ -61,10 +81,75 @@ func (s *stubOrderRepo) Save(ctx context.Context, order *domain.Order) error {
 	return s.saveErr
 }
 
+func (s *stubOrderRepo) Cancel(ctx context.Context, id domain.OrderID, at time.Time) error {
+	s.cancelledID = id
+	s.cancelledAt = at
+	return s.cancelErr
+}
+
+func (s *stubOrderRepo) ListByCustomer(ctx context.Context, customerID domain.CustomerID) ([]domain.Order, error) {
+	return s.orders, s.listErr
+}
+
+// stubZoneRepo implements domain.DeliveryZoneRepository for OrderService tests
+type stubZoneRepo struct {
+	zones map[string]domain.DeliveryZone
+}
+
+func (s *stubZoneRepo) FindZoneForPostcode(ctx context.Context, storeID domain.StoreID, postcode string) (*domain.DeliveryZone, error) {
+	z, ok := s.zones[postcode]
+	if !ok {
+		return nil, domain.ErrDeliveryZoneNotFound
+	}
+	return &z, nil
+}
+
+// stubStoreService implements service.StoreService for OrderService tests
+type stubStoreService struct {
+	getErr    error
+	ensureErr error
+}
+
+func (s *stubStoreService) GetStore(ctx context.Context, id domain.StoreID) (*domain.Store, error) {
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	return &domain.Store{ID: id}, nil
+}
+
+func (s *stubStoreService) Status(ctx context.Context, id domain.StoreID) (domain.StoreStatus, error) {
+	panic("Status should not be called in OrderService tests")
+}
+
+func (s *stubStoreService) EnsureAcceptingOrders(ctx context.Context, id domain.StoreID) error {
+	return s.ensureErr
+}
+
+func (s *stubStoreService) SetOrderingPaused(ctx context.Context, id domain.StoreID, paused bool, reason string) error {
+	panic("SetOrderingPaused should not be called in OrderService tests")
+}
+
+// stubCouponService implements service.CouponService for OrderService tests
+type stubCouponService struct {
+	err error
+
+	gotKey  string
+	gotCode string
+}
+
+func (s *stubCouponService) CheckCoupon(ctx context.Context, attemptKey, code string) error {
+	s.gotKey = attemptKey
+	s.gotCode = code
+	return s.err
+}
+
 // complie-time checks
 var (
-	_ domain.ProductRepository = (*stubProductRepoForOrder)(nil)
-	_ domain.OrderRepository   = (*stubOrderRepo)(nil)
+	_ domain.ProductRepository      = (*stubProductRepoForOrder)(nil)
+	_ domain.OrderRepository        = (*stubOrderRepo)(nil)
+	_ domain.DeliveryZoneRepository = (*stubZoneRepo)(nil)
+	_ service.StoreService          = (*stubStoreService)(nil)
+	_ service.CouponService         = (*stubCouponService)(nil)
 )
 
 func TestOrderService_CreateOrder_Success(t *testing.T) {
@@ -77,8 +162,9 @@ func TestOrderService_CreateOrder_Success(t *testing.T) {
 
 	productRepo := &stubProductRepoForOrder{productsByID: productsByID}
 	orderRepo := &stubOrderRepo{}
+	now := time.Date(2025, 3, 4, 12, 30, 0, 0, time.UTC)
 
-	svc := service.NewOrderService(orderRepo, productRepo)
+	svc := service.NewOrderService(orderRepo, productRepo, &stubZoneRepo{}, &stubCustomerRepo{}, &stubStoreService{}, &stubCouponService{}, fixedClock(now))
 
 	items := []domain.OrderItem{
 		{ProductID: "10", Quantity: 2},
@@ -86,7 +172,7 @@ func TestOrderService_CreateOrder_Success(t *testing.T) {
 	}
 	coupon := ptr("PROMO10")
 
-	order, products, err := svc.CreateOrder(ctx, items, coupon)
+	order, products, err := svc.CreateOrder(ctx, service.CreateOrderInput{StoreID: "cbd", Items: items, CouponCode: coupon})
 	if err != nil {
 		t.Fatalf("CreateOrder() error = %v, want nil", err)
 	}
@@ -112,10 +198,28 @@ func TestOrderService_CreateOrder_Success(t *testing.T) {
 		}
 	}
 
+	if order.StoreID != "cbd" {
+		t.Errorf("order.StoreID = %s, want %s", order.StoreID, "cbd")
+	}
+	if !order.CreatedAt.Equal(now) {
+		t.Errorf("order.CreatedAt = %v, want %v", order.CreatedAt, now)
+	}
+	if productRepo.gotStoreID != "cbd" {
+		t.Errorf("products looked up for store %q, want %q", productRepo.gotStoreID, "cbd")
+	}
+
 	if order.CouponCode == nil || *order.CouponCode != *coupon {
 		t.Errorf("order.CouponCode = %v, want %s", order.CouponCode, *coupon)
 	}
 
+	// 2 x 12.50 + 3 x 5.00, takeaway by default
+	if order.Fulfilment.Type != domain.FulfilmentTakeaway {
+		t.Errorf("order.Fulfilment.Type = %s, want %s", order.Fulfilment.Type, domain.FulfilmentTakeaway)
+	}
+	if order.Pricing.Total != domain.Money(4000) || order.Pricing.DeliveryFee != 0 {
+		t.Errorf("order.Pricing = %+v, want total 4000 and no delivery fee", order.Pricing)
+	}
+
 	// Products returned for response
 	if len(products) != 2 {
 		t.Fatalf("len(products) = %d, want %d", len(products), 2)
@@ -144,14 +248,14 @@ func TestOrderService_CreateOrder_ProductNotFound(t *testing.T) {
 	productRepo := &stubProductRepoForOrder{productsByID: productsByID}
 	orderRepo := &stubOrderRepo{}
 
-	svc := service.NewOrderService(orderRepo, productRepo)
+	svc := service.NewOrderService(orderRepo, productRepo, &stubZoneRepo{}, &stubCustomerRepo{}, &stubStoreService{}, &stubCouponService{}, nil)
 
 	items := []domain.OrderItem{
 		{ProductID: "10", Quantity: 1},
 		{ProductID: "11", Quantity: 1}, // missing
 	}
 
-	_, _, err := svc.CreateOrder(ctx, items, nil)
+	_, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{StoreID: domain.DefaultStoreID, Items: items})
 	if err == nil {
 		t.Fatalf("CreateOrder() error = nil, want non-nil")
 	}
@@ -172,13 +276,13 @@ func TestOrderService_CreateOrder_ProductRepoError(t *testing.T) {
 	}
 	orderRepo := &stubOrderRepo{}
 
-	svc := service.NewOrderService(orderRepo, productRepo)
+	svc := service.NewOrderService(orderRepo, productRepo, &stubZoneRepo{}, &stubCustomerRepo{}, &stubStoreService{}, &stubCouponService{}, nil)
 
 	items := []domain.OrderItem{
 		{ProductID: "10", Quantity: 1},
 	}
 
-	_, _, err := svc.CreateOrder(ctx, items, nil)
+	_, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{StoreID: domain.DefaultStoreID, Items: items})
 	if err == nil {
 		t.Fatalf("CreateOrder() error = nil, want non-nil")
 	}
@@ -201,13 +305,13 @@ func TestOrderService_CreateOrder_OrderRepoError(t *testing.T) {
 	orderRepoErr := errors.New("insert failed")
 	orderRepo := &stubOrderRepo{saveErr: orderRepoErr}
 
-	svc := service.NewOrderService(orderRepo, productRepo)
+	svc := service.NewOrderService(orderRepo, productRepo, &stubZoneRepo{}, &stubCustomerRepo{}, &stubStoreService{}, &stubCouponService{}, nil)
 
 	items := []domain.OrderItem{
 		{ProductID: "10", Quantity: 1},
 	}
 
-	_, _, err := svc.CreateOrder(ctx, items, nil)
+	_, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{StoreID: domain.DefaultStoreID, Items: items})
 	if err == nil {
 		t.Fatalf("CreateOrder() error = nil, want non-nil")
 	}
@@ -229,13 +333,13 @@ func TestOrderService_CreateOrder_InvalidQuantity(t *testing.T) {
 	productRepo := &stubProductRepoForOrder{productsByID: productsByID}
 	orderRepo := &stubOrderRepo{}
 
-	svc := service.NewOrderService(orderRepo, productRepo)
+	svc := service.NewOrderService(orderRepo, productRepo, &stubZoneRepo{}, &stubCustomerRepo{}, &stubStoreService{}, &stubCouponService{}, nil)
 
 	items := []domain.OrderItem{
 		{ProductID: "10", Quantity: 0}, // invalid
 	}
 
-	_, _, err := svc.CreateOrder(ctx, items, nil)
+	_, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{StoreID: domain.DefaultStoreID, Items: items})
 	if err == nil {
 		t.Fatalf("CreateOrder() error = nil, want non-nil")
 	}
@@ -247,6 +351,281 @@ func TestOrderService_CreateOrder_InvalidQuantity(t *testing.T) {
 	}
 }
 
+func TestOrderService_CreateOrder_StoreClosed(t *testing.T) {
+	ctx := context.Background()
+
+	productsByID := map[domain.ProductID]domain.Product{
+		"10": {ID: "10", Name: "Chicken Waffle", Price: domain.NewMoneyFromFloat(12.5), Category: "Waffle"},
+	}
+
+	productRepo := &stubProductRepoForOrder{productsByID: productsByID}
+	orderRepo := &stubOrderRepo{}
+	store := &stubStoreService{ensureErr: fmt.Errorf("%w (%s)", domain.ErrStoreClosed, domain.StoreOrderingPaused)}
+
+	svc := service.NewOrderService(orderRepo, productRepo, &stubZoneRepo{}, &stubCustomerRepo{}, store, &stubCouponService{}, nil)
+
+	items := []domain.OrderItem{
+		{ProductID: "10", Quantity: 1},
+	}
+
+	_, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{StoreID: domain.DefaultStoreID, Items: items})
+	if !errors.Is(err, domain.ErrStoreClosed) {
+		t.Fatalf("CreateOrder() error = %v, want to wrap %v", err, domain.ErrStoreClosed)
+	}
+	if orderRepo.saveCalls != 0 {
+		t.Fatalf("orderRepo.saveCalls = %d, want 0 (order should not be saved)", orderRepo.saveCalls)
+	}
+}
+
+func TestOrderService_CreateOrder_Delivery(t *testing.T) {
+	ctx := context.Background()
+
+	productsByID := map[domain.ProductID]domain.Product{
+		"10": {ID: "10", Name: "Chicken Waffle", Price: domain.NewMoneyFromFloat(12.5), Category: "Waffle"},
+	}
+	zones := &stubZoneRepo{zones: map[string]domain.DeliveryZone{
+		"2000": {ID: "inner", Fee: domain.NewMoneyFromFloat(5)},
+	}}
+
+	items := []domain.OrderItem{
+		{ProductID: "10", Quantity: 2},
+	}
+	address := func(postcode string) domain.Fulfilment {
+		return domain.Fulfilment{
+			Type: domain.FulfilmentDelivery,
+			Address: &domain.DeliveryAddress{
+				Line1: "1 George St", Suburb: "Sydney", State: "NSW", Postcode: postcode,
+			},
+		}
+	}
+
+	t.Run("inside zone", func(t *testing.T) {
+		orderRepo := &stubOrderRepo{}
+		svc := service.NewOrderService(orderRepo, &stubProductRepoForOrder{productsByID: productsByID}, zones, &stubCustomerRepo{}, &stubStoreService{}, &stubCouponService{}, nil)
+
+		order, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{
+			StoreID:    domain.DefaultStoreID,
+			Items:      items,
+			Fulfilment: address("2000"),
+		})
+		if err != nil {
+			t.Fatalf("CreateOrder() error = %v, want nil", err)
+		}
+
+		want := domain.OrderPricing{Subtotal: 2500, DeliveryFee: 500, Total: 3000}
+		if order.Pricing != want {
+			t.Fatalf("order.Pricing = %+v, want %+v", order.Pricing, want)
+		}
+		if orderRepo.savedOrder.Pricing != want {
+			t.Fatalf("saved order pricing = %+v, want %+v", orderRepo.savedOrder.Pricing, want)
+		}
+	})
+
+	t.Run("outside zone", func(t *testing.T) {
+		orderRepo := &stubOrderRepo{}
+		svc := service.NewOrderService(orderRepo, &stubProductRepoForOrder{productsByID: productsByID}, zones, &stubCustomerRepo{}, &stubStoreService{}, &stubCouponService{}, nil)
+
+		_, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{
+			StoreID:    domain.DefaultStoreID,
+			Items:      items,
+			Fulfilment: address("6000"),
+		})
+		if !errors.Is(err, domain.ErrDeliveryZoneNotFound) {
+			t.Fatalf("CreateOrder() error = %v, want to wrap %v", err, domain.ErrDeliveryZoneNotFound)
+		}
+		if orderRepo.saveCalls != 0 {
+			t.Fatalf("orderRepo.saveCalls = %d, want 0", orderRepo.saveCalls)
+		}
+	})
+}
+
+func TestOrderService_CreateOrder_StoreNotFound(t *testing.T) {
+	ctx := context.Background()
+
+	productRepo := &stubProductRepoForOrder{}
+	orderRepo := &stubOrderRepo{}
+	store := &stubStoreService{getErr: domain.ErrStoreNotFound}
+
+	svc := service.NewOrderService(orderRepo, productRepo, &stubZoneRepo{}, &stubCustomerRepo{}, store, &stubCouponService{}, nil)
+
+	items := []domain.OrderItem{
+		{ProductID: "10", Quantity: 1},
+	}
+
+	_, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{StoreID: "nowhere", Items: items})
+	if !errors.Is(err, domain.ErrStoreNotFound) {
+		t.Fatalf("CreateOrder() error = %v, want to wrap %v", err, domain.ErrStoreNotFound)
+	}
+	if orderRepo.saveCalls != 0 {
+		t.Fatalf("orderRepo.saveCalls = %d, want 0 (order should not be saved)", orderRepo.saveCalls)
+	}
+}
+
 func ptr[T any](v T) *T {
 	return &v
 }
+
+func TestOrderService_CreateOrder_Customer(t *testing.T) {
+	ctx := context.Background()
+
+	productsByID := map[domain.ProductID]domain.Product{
+		"10": {ID: "10", Name: "Chicken Waffle", Price: domain.NewMoneyFromFloat(12.5), Category: "Waffle"},
+	}
+	customers := &stubCustomerRepo{customers: map[domain.CustomerID]domain.Customer{"c1": {ID: "c1"}}}
+	items := []domain.OrderItem{{ProductID: "10", Quantity: 1}}
+
+	t.Run("linked to customer", func(t *testing.T) {
+		orderRepo := &stubOrderRepo{}
+		svc := service.NewOrderService(orderRepo, &stubProductRepoForOrder{productsByID: productsByID}, &stubZoneRepo{}, customers, &stubStoreService{}, &stubCouponService{}, nil)
+
+		order, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{
+			StoreID:    domain.DefaultStoreID,
+			CustomerID: ptr(domain.CustomerID("c1")),
+			Items:      items,
+		})
+		if err != nil {
+			t.Fatalf("CreateOrder() error = %v, want nil", err)
+		}
+		if order.CustomerID == nil || *order.CustomerID != "c1" {
+			t.Fatalf("order.CustomerID = %v, want c1", order.CustomerID)
+		}
+		if order.CreatedAt.IsZero() {
+			t.Fatalf("order.CreatedAt is zero, want set")
+		}
+	})
+
+	t.Run("unknown customer", func(t *testing.T) {
+		orderRepo := &stubOrderRepo{}
+		svc := service.NewOrderService(orderRepo, &stubProductRepoForOrder{productsByID: productsByID}, &stubZoneRepo{}, customers, &stubStoreService{}, &stubCouponService{}, nil)
+
+		_, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{
+			StoreID:    domain.DefaultStoreID,
+			CustomerID: ptr(domain.CustomerID("ghost")),
+			Items:      items,
+		})
+		if !errors.Is(err, domain.ErrCustomerNotFound) {
+			t.Fatalf("CreateOrder() error = %v, want to wrap %v", err, domain.ErrCustomerNotFound)
+		}
+		if orderRepo.saveCalls != 0 {
+			t.Fatalf("orderRepo.saveCalls = %d, want 0", orderRepo.saveCalls)
+		}
+	})
+}
+
+func TestOrderService_CreateOrder_Coupon(t *testing.T) {
+	ctx := context.Background()
+
+	productsByID := map[domain.ProductID]domain.Product{
+		"10": {ID: "10", Name: "Chicken Waffle", Price: domain.NewMoneyFromFloat(12.5), Category: "Waffle"},
+	}
+	items := []domain.OrderItem{{ProductID: "10", Quantity: 1}}
+
+	t.Run("checked for the caller", func(t *testing.T) {
+		coupons := &stubCouponService{}
+		svc := service.NewOrderService(&stubOrderRepo{}, &stubProductRepoForOrder{productsByID: productsByID}, &stubZoneRepo{}, &stubCustomerRepo{}, &stubStoreService{}, coupons, nil)
+
+		_, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{
+			StoreID:    domain.DefaultStoreID,
+			Items:      items,
+			CouponCode: ptr("FIFTYOFF"),
+			AttemptKey: "client:dev",
+		})
+		if err != nil {
+			t.Fatalf("CreateOrder() error = %v, want nil", err)
+		}
+		if coupons.gotCode != "FIFTYOFF" || coupons.gotKey != "client:dev" {
+			t.Fatalf("CheckCoupon() got (%q, %q), want (client:dev, FIFTYOFF)", coupons.gotKey, coupons.gotCode)
+		}
+	})
+
+	t.Run("checked and saved trimmed", func(t *testing.T) {
+		orderRepo := &stubOrderRepo{}
+		coupons := &stubCouponService{}
+		svc := service.NewOrderService(orderRepo, &stubProductRepoForOrder{productsByID: productsByID}, &stubZoneRepo{}, &stubCustomerRepo{}, &stubStoreService{}, coupons, nil)
+
+		_, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{
+			StoreID:    domain.DefaultStoreID,
+			Items:      items,
+			CouponCode: ptr(" FIFTYOFF "),
+		})
+		if err != nil {
+			t.Fatalf("CreateOrder() error = %v, want nil", err)
+		}
+		if coupons.gotCode != "FIFTYOFF" {
+			t.Fatalf("CheckCoupon() got code %q, want FIFTYOFF", coupons.gotCode)
+		}
+		if got := orderRepo.savedOrder.CouponCode; got == nil || *got != "FIFTYOFF" {
+			t.Fatalf("saved CouponCode = %v, want FIFTYOFF", got)
+		}
+	})
+
+	t.Run("not checked without a coupon", func(t *testing.T) {
+		coupons := &stubCouponService{err: domain.ErrUnknownCouponCode}
+		svc := service.NewOrderService(&stubOrderRepo{}, &stubProductRepoForOrder{productsByID: productsByID}, &stubZoneRepo{}, &stubCustomerRepo{}, &stubStoreService{}, coupons, nil)
+
+		if _, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{StoreID: domain.DefaultStoreID, Items: items}); err != nil {
+			t.Fatalf("CreateOrder() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		orderRepo := &stubOrderRepo{}
+		coupons := &stubCouponService{err: &domain.CouponLockoutError{Until: time.Now().Add(time.Minute)}}
+		svc := service.NewOrderService(orderRepo, &stubProductRepoForOrder{productsByID: productsByID}, &stubZoneRepo{}, &stubCustomerRepo{}, &stubStoreService{}, coupons, nil)
+
+		_, _, err := svc.CreateOrder(ctx, service.CreateOrderInput{
+			StoreID:    domain.DefaultStoreID,
+			Items:      items,
+			CouponCode: ptr("GUESS123"),
+		})
+		if !errors.Is(err, domain.ErrCouponLockedOut) {
+			t.Fatalf("CreateOrder() error = %v, want to wrap %v", err, domain.ErrCouponLockedOut)
+		}
+		if orderRepo.saveCalls != 0 {
+			t.Fatalf("orderRepo.saveCalls = %d, want 0", orderRepo.saveCalls)
+		}
+	})
+}
+
+func TestOrderService_CreateOrder_CouponCapReached(t *testing.T) {
+	productsByID := map[domain.ProductID]domain.Product{
+		"10": {ID: "10", Name: "Chicken Waffle", Price: domain.NewMoneyFromFloat(12.5), Category: "Waffle"},
+	}
+	orderRepo := &stubOrderRepo{saveErr: domain.ErrCouponExhausted}
+	svc := service.NewOrderService(orderRepo, &stubProductRepoForOrder{productsByID: productsByID}, &stubZoneRepo{}, &stubCustomerRepo{}, &stubStoreService{}, &stubCouponService{}, nil)
+
+	_, _, err := svc.CreateOrder(context.Background(), service.CreateOrderInput{
+		StoreID:    domain.DefaultStoreID,
+		Items:      []domain.OrderItem{{ProductID: "10", Quantity: 1}},
+		CouponCode: ptr("BIRTHDAY"),
+	})
+	if !errors.Is(err, domain.ErrCouponExhausted) {
+		t.Fatalf("CreateOrder() error = %v, want to wrap %v", err, domain.ErrCouponExhausted)
+	}
+	if orderRepo.savedOrder == nil || orderRepo.savedOrder.Status != domain.OrderPlaced {
+		t.Fatalf("saved order = %+v, want status %s", orderRepo.savedOrder, domain.OrderPlaced)
+	}
+}
+
+func TestOrderService_CancelOrder(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2025, 3, 4, 12, 30, 0, 0, time.FixedZone("AEDT", 11*60*60))
+	orderRepo := &stubOrderRepo{}
+	svc := service.NewOrderService(orderRepo, &stubProductRepoForOrder{}, &stubZoneRepo{}, &stubCustomerRepo{}, &stubStoreService{}, &stubCouponService{}, fixedClock(now))
+
+	if err := svc.CancelOrder(ctx, "order-1"); err != nil {
+		t.Fatalf("CancelOrder() error = %v, want nil", err)
+	}
+	if orderRepo.cancelledID != "order-1" {
+		t.Fatalf("Cancel() called with %q, want order-1", orderRepo.cancelledID)
+	}
+	if !orderRepo.cancelledAt.Equal(now) || orderRepo.cancelledAt.Location() != time.UTC {
+		t.Fatalf("Cancel() called at %v, want %v in UTC", orderRepo.cancelledAt, now)
+	}
+
+	orderRepo.cancelErr = domain.ErrOrderAlreadyCancelled
+	if err := svc.CancelOrder(ctx, "order-1"); !errors.Is(err, domain.ErrOrderAlreadyCancelled) {
+		t.Fatalf("CancelOrder() error = %v, want to wrap %v", err, domain.ErrOrderAlreadyCancelled)
+	}
+}
@@ -10,15 +10,19 @@ import (
 type ProductService interface {
 	ListProducts(ctx context.Context) ([]domain.Product, error)
 	GetProduct(ctx context.Context, id domain.ProductID) (*domain.Product, error)
+	// ListStoreProducts returns domain.ErrStoreNotFound for unknown stores.
+	ListStoreProducts(ctx context.Context, storeID domain.StoreID) ([]domain.Product, error)
 }
 
 type productService struct {
-	repo domain.ProductRepository
+	repo      domain.ProductRepository
+	storeRepo domain.StoreRepository
 }
 
-func NewProductService(repo domain.ProductRepository) ProductService {
+func NewProductService(repo domain.ProductRepository, storeRepo domain.StoreRepository) ProductService {
 	return &productService{
-		repo: repo,
+		repo:      repo,
+		storeRepo: storeRepo,
 	}
 }
 
@@ -30,3 +34,11 @@ func (s *productService) ListProducts(ctx context.Context) ([]domain.Product, er
 func (s *productService) GetProduct(ctx context.Context, id domain.ProductID) (*domain.Product, error) {
 	return s.repo.GetProductByID(ctx, id)
 }
+
+func (s *productService) ListStoreProducts(ctx context.Context, storeID domain.StoreID) ([]domain.Product, error) {
+	// Distinguish "unknown store" from "store with an empty menu".
+	if _, err := s.storeRepo.GetStore(ctx, storeID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListProductsByStore(ctx, storeID)
+}
@@ -13,6 +13,8 @@ import (
 type stubProductRepo struct {
 	products []domain.Product
 	err      error
+
+	storeProducts map[domain.StoreID][]domain.Product
 }
 
 func (s *stubProductRepo) ListProducts(ctx context.Context) ([]domain.Product, error) {
@@ -30,6 +32,17 @@ func (s *stubProductRepo) GetProductByIDs(ctx context.Context, ids []domain.Prod
 	panic("GetProductByIDs should not be called in ProductService tests")
 }
 
+func (s *stubProductRepo) ListProductsByStore(ctx context.Context, storeID domain.StoreID) ([]domain.Product, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.storeProducts[storeID], nil
+}
+
+func (s *stubProductRepo) GetProductByIDsForStore(ctx context.Context, storeID domain.StoreID, ids []domain.ProductID) (map[domain.ProductID]domain.Product, error) {
+	panic("GetProductByIDsForStore should not be called in ProductService tests")
+}
+
 // compile-time check
 var _ domain.ProductRepository = (*stubProductRepo)(nil)
 
@@ -42,7 +55,7 @@ func TestProductService_ListProducts_Success(t *testing.T) {
 	}
 
 	repo := &stubProductRepo{products: products}
-	svc := service.NewProductService(repo)
+	svc := service.NewProductService(repo, &stubStoreRepo{})
 
 	got, err := svc.ListProducts(ctx)
 	if err != nil {
@@ -75,7 +88,7 @@ func TestProductService_ListProducts_RepoError(t *testing.T) {
 
 	repoErr := errors.New("db error")
 	repo := &stubProductRepo{err: repoErr}
-	svc := service.NewProductService(repo)
+	svc := service.NewProductService(repo, &stubStoreRepo{})
 
 	_, err := svc.ListProducts(ctx)
 	if err == nil {
@@ -85,3 +98,27 @@ func TestProductService_ListProducts_RepoError(t *testing.T) {
 		t.Fatalf("ListProducts() error = %v, want %v", err, repoErr)
 	}
 }
+
+func TestProductService_ListStoreProducts(t *testing.T) {
+	ctx := context.Background()
+
+	cbd := []domain.Product{
+		{ID: "1", Name: "Waffle with Berries", Price: domain.NewMoneyFromFloat(7.5), Category: "Waffle"},
+	}
+	repo := &stubProductRepo{storeProducts: map[domain.StoreID][]domain.Product{"cbd": cbd}}
+	storeRepo := &stubStoreRepo{stores: map[domain.StoreID]domain.Store{"cbd": {ID: "cbd"}}}
+	svc := service.NewProductService(repo, storeRepo)
+
+	got, err := svc.ListStoreProducts(ctx, "cbd")
+	if err != nil {
+		t.Fatalf("ListStoreProducts() error = %v, want nil", err)
+	}
+	if len(got) != 1 || got[0].Price != cbd[0].Price {
+		t.Fatalf("ListStoreProducts() = %+v, want %+v", got, cbd)
+	}
+
+	_, err = svc.ListStoreProducts(ctx, "nowhere")
+	if !errors.Is(err, domain.ErrStoreNotFound) {
+		t.Fatalf("ListStoreProducts() error = %v, want %v", err, domain.ErrStoreNotFound)
+	}
+}
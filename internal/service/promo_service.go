@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+// PromoService reports on the promo code set used to validate coupons.
+type PromoService interface {
+	// CodeSetStatus returns the version and size of the code set in use.
+	// It returns domain.ErrNoActivePromoCodes when there is none.
+	CodeSetStatus(ctx context.Context) (*domain.PromoCodeSetStatus, error)
+}
+
+type promoService struct {
+	codes domain.PromoCodeSetReporter
+}
+
+func NewPromoService(codes domain.PromoCodeSetReporter) PromoService {
+	return &promoService{
+		codes: codes,
+	}
+}
+
+func (s *promoService) CodeSetStatus(ctx context.Context) (*domain.PromoCodeSetStatus, error) {
+	return s.codes.CodeSetStatus(ctx)
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+// StoreService exposes the store's opening state to handlers and other services.
+type StoreService interface {
+	// GetStore returns domain.ErrStoreNotFound for unknown stores.
+	GetStore(ctx context.Context, id domain.StoreID) (*domain.Store, error)
+	Status(ctx context.Context, id domain.StoreID) (domain.StoreStatus, error)
+	// EnsureAcceptingOrders returns an error wrapping domain.ErrStoreClosed
+	// when the store cannot take orders right now.
+	EnsureAcceptingOrders(ctx context.Context, id domain.StoreID) error
+	SetOrderingPaused(ctx context.Context, id domain.StoreID, paused bool, reason string) error
+}
+
+type storeService struct {
+	repo domain.StoreRepository
+	now  func() time.Time
+}
+
+// NewStoreService builds a StoreService. now defaults to time.Now when nil.
+func NewStoreService(repo domain.StoreRepository, now func() time.Time) StoreService {
+	if now == nil {
+		now = time.Now
+	}
+	return &storeService{
+		repo: repo,
+		now:  now,
+	}
+}
+
+func (s *storeService) GetStore(ctx context.Context, id domain.StoreID) (*domain.Store, error) {
+	return s.repo.GetStore(ctx, id)
+}
+
+func (s *storeService) Status(ctx context.Context, id domain.StoreID) (domain.StoreStatus, error) {
+	if _, err := s.repo.GetStore(ctx, id); err != nil {
+		return domain.StoreStatus{}, err
+	}
+
+	schedule, err := s.repo.GetSchedule(ctx, id)
+	if err != nil {
+		return domain.StoreStatus{}, fmt.Errorf("load schedule for store %s: %w", id, err)
+	}
+
+	return schedule.StatusAt(s.now()), nil
+}
+
+func (s *storeService) EnsureAcceptingOrders(ctx context.Context, id domain.StoreID) error {
+	status, err := s.Status(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !status.AcceptingOrders {
+		return fmt.Errorf("%w (%s)", domain.ErrStoreClosed, status.Reason)
+	}
+	return nil
+}
+
+func (s *storeService) SetOrderingPaused(ctx context.Context, id domain.StoreID, paused bool, reason string) error {
+	if _, err := s.repo.GetStore(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.SetOrderingPaused(ctx, id, paused, reason); err != nil {
+		return fmt.Errorf("set ordering paused for store %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/service"
+)
+
+// stubStoreRepo implements domain.StoreRepository for StoreService tests.
+//
+// A nil stores map means every store exists.
+type stubStoreRepo struct {
+	stores   map[domain.StoreID]domain.Store
+	schedule *domain.StoreSchedule
+	err      error
+
+	paused      bool
+	pauseReason string
+}
+
+func (s *stubStoreRepo) GetStore(ctx context.Context, id domain.StoreID) (*domain.Store, error) {
+	if s.stores == nil {
+		return &domain.Store{ID: id}, nil
+	}
+	st, ok := s.stores[id]
+	if !ok {
+		return nil, domain.ErrStoreNotFound
+	}
+	return &st, nil
+}
+
+func (s *stubStoreRepo) GetSchedule(ctx context.Context, id domain.StoreID) (*domain.StoreSchedule, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.schedule, nil
+}
+
+func (s *stubStoreRepo) SetOrderingPaused(ctx context.Context, id domain.StoreID, paused bool, reason string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.paused = paused
+	s.pauseReason = reason
+	return nil
+}
+
+// compile-time check
+var _ domain.StoreRepository = (*stubStoreRepo)(nil)
+
+func fixedClock(t time.Time) func() time.Time {
+	return func() time.Time { return t }
+}
+
+func weekdayHours(opens, closes time.Duration) []domain.OpeningPeriod {
+	periods := make([]domain.OpeningPeriod, 0, 7)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		periods = append(periods, domain.OpeningPeriod{Weekday: d, Opens: opens, Closes: closes})
+	}
+	return periods
+}
+
+func TestStoreService_EnsureAcceptingOrders(t *testing.T) {
+	ctx := context.Background()
+
+	// Wednesday 2025-01-15
+	noon := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	midnight := time.Date(2025, 1, 15, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		paused  bool
+		wantErr bool
+	}{
+		{name: "open", now: noon},
+		{name: "after hours", now: midnight, wantErr: true},
+		{name: "paused", now: noon, paused: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubStoreRepo{schedule: &domain.StoreSchedule{
+				Location:       time.UTC,
+				OpeningHours:   weekdayHours(9*time.Hour, 22*time.Hour),
+				OrderingPaused: tt.paused,
+			}}
+			svc := service.NewStoreService(repo, fixedClock(tt.now))
+
+			err := svc.EnsureAcceptingOrders(ctx, domain.DefaultStoreID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EnsureAcceptingOrders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, domain.ErrStoreClosed) {
+				t.Fatalf("EnsureAcceptingOrders() error = %v, want to wrap %v", err, domain.ErrStoreClosed)
+			}
+		})
+	}
+}
+
+func TestStoreService_Status_RepoError(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &stubStoreRepo{err: domain.ErrStoreScheduleNotFound}
+	svc := service.NewStoreService(repo, nil)
+
+	_, err := svc.Status(ctx, domain.DefaultStoreID)
+	if !errors.Is(err, domain.ErrStoreScheduleNotFound) {
+		t.Fatalf("Status() error = %v, want to wrap %v", err, domain.ErrStoreScheduleNotFound)
+	}
+
+	// A broken schedule must never be mistaken for "open".
+	if err := svc.EnsureAcceptingOrders(ctx, domain.DefaultStoreID); err == nil {
+		t.Fatalf("EnsureAcceptingOrders() error = nil, want non-nil")
+	}
+}
+
+func TestStoreService_SetOrderingPaused(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &stubStoreRepo{}
+	svc := service.NewStoreService(repo, nil)
+
+	if err := svc.SetOrderingPaused(ctx, domain.DefaultStoreID, true, "kitchen overloaded"); err != nil {
+		t.Fatalf("SetOrderingPaused() error = %v, want nil", err)
+	}
+	if !repo.paused || repo.pauseReason != "kitchen overloaded" {
+		t.Fatalf("repo paused = %v (%q), want true (%q)", repo.paused, repo.pauseReason, "kitchen overloaded")
+	}
+}
+
+func TestStoreService_UnknownStore(t *testing.T) {
+	ctx := context.Background()
+
+	repo := &stubStoreRepo{stores: map[domain.StoreID]domain.Store{}}
+	svc := service.NewStoreService(repo, nil)
+
+	if _, err := svc.Status(ctx, "nowhere"); !errors.Is(err, domain.ErrStoreNotFound) {
+		t.Fatalf("Status() error = %v, want %v", err, domain.ErrStoreNotFound)
+	}
+	if err := svc.SetOrderingPaused(ctx, "nowhere", true, ""); !errors.Is(err, domain.ErrStoreNotFound) {
+		t.Fatalf("SetOrderingPaused() error = %v, want %v", err, domain.ErrStoreNotFound)
+	}
+	if repo.paused {
+		t.Fatalf("repo.paused = true, want unknown store to be left untouched")
+	}
+}
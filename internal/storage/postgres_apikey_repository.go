@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/lib/pq"
+)
+
+type PgAPIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewPgAPIKeyRepository(db *sql.DB) domain.APIKeyRepository {
+	return &PgAPIKeyRepository{
+		db: db,
+	}
+}
+
+func (r *PgAPIKeyRepository) FindByHash(ctx context.Context, keyHash string) (*domain.APIClient, error) {
+	const query = `
+		SELECT id, client_name, key_hash, scopes, expires_at, revoked_at
+		FROM api_keys
+		WHERE key_hash = $1
+	`
+
+	var (
+		client    domain.APIClient
+		scopes    pq.StringArray
+		expiresAt sql.NullTime
+		revokedAt sql.NullTime
+	)
+
+	err := r.db.QueryRowContext(ctx, query, keyHash).
+		Scan(&client.ID, &client.Name, &client.KeyHash, &scopes, &expiresAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find api key by hash: %w", err)
+	}
+
+	client.Scopes = make([]domain.APIScope, 0, len(scopes))
+	for _, s := range scopes {
+		client.Scopes = append(client.Scopes, domain.APIScope(s))
+	}
+	if expiresAt.Valid {
+		client.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		client.RevokedAt = &revokedAt.Time
+	}
+
+	return &client, nil
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/coupon"
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/rs/zerolog"
+)
+
+// couponLockoutPruneEvery is how many failures pass between deletions of
+// idle attempt records.
+const couponLockoutPruneEvery = 1000
+
+// PgCouponLockout keeps failed coupon attempts in Postgres so a lockout
+// applies on every API instance. Attempt time comes from the database clock.
+type PgCouponLockout struct {
+	db       *sql.DB
+	policy   coupon.LockoutPolicy
+	failures atomic.Uint64
+}
+
+func NewPgCouponLockout(db *sql.DB, policy coupon.LockoutPolicy) domain.CouponAttemptTracker {
+	return &PgCouponLockout{
+		db:     db,
+		policy: policy,
+	}
+}
+
+func (l *PgCouponLockout) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	const query = `
+		SELECT locked_until
+		FROM coupon_attempts
+		WHERE key = $1 AND locked_until > clock_timestamp()
+	`
+	var lockedUntil time.Time
+	err := l.db.QueryRowContext(ctx, query, key).Scan(&lockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("select coupon lockout: %w", err)
+	}
+	return lockedUntil, nil
+}
+
+func (l *PgCouponLockout) RecordFailure(ctx context.Context, key string) (time.Time, error) {
+	if !l.policy.Enabled() {
+		return time.Time{}, nil
+	}
+
+	// Housekeeping only, so a failed prune must not fail the attempt
+	if l.failures.Add(1)%couponLockoutPruneEvery == 0 {
+		if err := l.pruneIdle(ctx); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("prune coupon attempts failed, recording attempt anyway")
+		}
+	}
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("begin tx for coupon attempt: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Make sure the record exists, then lock it for the read-modify-write.
+	const insertAttempts = `
+		INSERT INTO coupon_attempts (key, failures, window_start, updated_at)
+		VALUES ($1, 0, clock_timestamp(), clock_timestamp())
+		ON CONFLICT (key) DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, insertAttempts, key); err != nil {
+		return time.Time{}, fmt.Errorf("insert coupon attempts: %w", err)
+	}
+
+	const selectAttempts = `
+		SELECT failures, window_start, locked_until, clock_timestamp()
+		FROM coupon_attempts
+		WHERE key = $1
+		FOR UPDATE
+	`
+	var (
+		a           coupon.Attempts
+		lockedUntil sql.NullTime
+		now         time.Time
+	)
+	if err := tx.QueryRowContext(ctx, selectAttempts, key).Scan(&a.Failures, &a.WindowStart, &lockedUntil, &now); err != nil {
+		return time.Time{}, fmt.Errorf("select coupon attempts: %w", err)
+	}
+	a.LockedUntil = lockedUntil.Time
+
+	next := l.policy.Fail(a, now)
+
+	const updateAttempts = `
+		UPDATE coupon_attempts
+		SET failures = $2, window_start = $3, locked_until = $4, updated_at = $5
+		WHERE key = $1
+	`
+	lockedUntil = sql.NullTime{Time: next.LockedUntil, Valid: !next.LockedUntil.IsZero()}
+	if _, err := tx.ExecContext(ctx, updateAttempts, key, next.Failures, next.WindowStart, lockedUntil, now); err != nil {
+		return time.Time{}, fmt.Errorf("update coupon attempts: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, fmt.Errorf("commit tx for coupon attempt: %w", err)
+	}
+
+	if next.LockedUntil.Equal(a.LockedUntil) {
+		return time.Time{}, nil
+	}
+	return next.LockedUntil, nil
+}
+
+// pruneIdle deletes records with no live lockout that have not changed for
+// longer than the window, so they no longer count towards anything.
+func (l *PgCouponLockout) pruneIdle(ctx context.Context) error {
+	const query = `
+		DELETE FROM coupon_attempts
+		WHERE updated_at < clock_timestamp() - $1 * INTERVAL '1 second'
+		  AND (locked_until IS NULL OR locked_until < clock_timestamp())
+	`
+	if _, err := l.db.ExecContext(ctx, query, l.policy.Window.Seconds()); err != nil {
+		return fmt.Errorf("prune coupon attempts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/lib/pq"
+)
+
+// pgUniqueViolation is the SQLSTATE Postgres reports for unique constraint violations.
+const pgUniqueViolation = "23505"
+
+type PgCustomerRepository struct {
+	db *sql.DB
+}
+
+func NewPgCustomerRepository(db *sql.DB) domain.CustomerRepository {
+	return &PgCustomerRepository{
+		db: db,
+	}
+}
+
+func (r *PgCustomerRepository) Create(ctx context.Context, customer *domain.Customer) error {
+	const query = `
+		INSERT INTO customers (id, name, email, phone, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	var phone *string
+	if customer.Phone != "" {
+		phone = &customer.Phone
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		string(customer.ID), customer.Name, customer.Email, phone, customer.CreatedAt,
+	)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation {
+		return domain.ErrCustomerEmailTaken
+	}
+	if err != nil {
+		return fmt.Errorf("insert customer: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PgCustomerRepository) GetByID(ctx context.Context, id domain.CustomerID) (*domain.Customer, error) {
+	const query = `
+		SELECT id, name, email, COALESCE(phone, ''), created_at
+		FROM customers
+		WHERE id = $1
+	`
+
+	var (
+		rawID    string
+		customer domain.Customer
+	)
+
+	err := r.db.QueryRowContext(ctx, query, string(id)).
+		Scan(&rawID, &customer.Name, &customer.Email, &customer.Phone, &customer.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get customer by id %s: %w", id, err)
+	}
+
+	customer.ID = domain.CustomerID(rawID)
+	return &customer, nil
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+type PgDeliveryZoneRepository struct {
+	db *sql.DB
+}
+
+func NewPgDeliveryZoneRepository(db *sql.DB) domain.DeliveryZoneRepository {
+	return &PgDeliveryZoneRepository{
+		db: db,
+	}
+}
+
+func (r *PgDeliveryZoneRepository) FindZoneForPostcode(ctx context.Context, storeID domain.StoreID, postcode string) (*domain.DeliveryZone, error) {
+	const query = `
+		SELECT z.id, z.name, z.fee_cents
+		FROM delivery_zone_postcodes zp
+		JOIN delivery_zones z ON z.id = zp.zone_id
+		WHERE zp.store_id = $1
+		  AND zp.postcode = $2
+	`
+
+	var (
+		id       string
+		name     string
+		feeCents int64
+	)
+
+	err := r.db.QueryRowContext(ctx, query, string(storeID), postcode).Scan(&id, &name, &feeCents)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrDeliveryZoneNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find delivery zone (store_id=%s, postcode=%s): %w", storeID, postcode, err)
+	}
+
+	return &domain.DeliveryZone{
+		ID:   id,
+		Name: name,
+		Fee:  domain.Money(feeCents),
+	}, nil
+}
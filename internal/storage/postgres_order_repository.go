@@ -3,13 +3,23 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/lib/pq"
 )
 
 type PgOrderRepository struct {
 	db *sql.DB
+
+	// The statements of Save, prepared on first use. database/sql prepares
+	// them again on each connection they run on.
+	stmtsMu     sync.Mutex
+	insertOrder *sql.Stmt
+	insertItems *sql.Stmt
 }
 
 func NewPgOrderRepository(db *sql.DB) domain.OrderRepository {
@@ -18,7 +28,51 @@ func NewPgOrderRepository(db *sql.DB) domain.OrderRepository {
 	}
 }
 
+const (
+	insertOrderQuery = `
+		INSERT INTO orders (
+			id, store_id, customer_id, coupon_code,
+			fulfilment_type, table_number,
+			delivery_line1, delivery_line2, delivery_suburb, delivery_state, delivery_postcode,
+			subtotal_cents, delivery_fee_cents, total_cents, status, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	// All items of an order in one round-trip, however many there are:
+	// the product IDs and quantities are sent as two arrays of equal length.
+	insertItemsQuery = `
+		INSERT INTO order_items (order_id, product_id, quantity)
+		SELECT $1::varchar, item.product_id, item.quantity
+		FROM unnest($2::varchar[], $3::int[]) AS item(product_id, quantity)
+	`
+)
+
+// statements returns the prepared statements of Save, preparing them on the
+// first call. A failed prepare is retried on the next call.
+func (r *PgOrderRepository) statements(ctx context.Context) (insertOrder, insertItems *sql.Stmt, err error) {
+	r.stmtsMu.Lock()
+	defer r.stmtsMu.Unlock()
+
+	if r.insertOrder == nil {
+		if r.insertOrder, err = r.db.PrepareContext(ctx, insertOrderQuery); err != nil {
+			return nil, nil, fmt.Errorf("prepare insert order: %w", err)
+		}
+	}
+	if r.insertItems == nil {
+		if r.insertItems, err = r.db.PrepareContext(ctx, insertItemsQuery); err != nil {
+			return nil, nil, fmt.Errorf("prepare insert order items: %w", err)
+		}
+	}
+	return r.insertOrder, r.insertItems, nil
+}
+
 func (r *PgOrderRepository) Save(ctx context.Context, order *domain.Order) error {
+	insertOrder, insertItems, err := r.statements(ctx)
+	if err != nil {
+		return err
+	}
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("begin tx for save order: %w", err)
@@ -28,34 +82,320 @@ func (r *PgOrderRepository) Save(ctx context.Context, order *domain.Order) error
 		_ = tx.Rollback()
 	}()
 
-	const insertOrder = `
-		INSERT INTO orders (id, coupon_code)
-		VALUES ($1, $2)
-	`
+	var customerID *string
+	if order.CustomerID != nil {
+		id := string(*order.CustomerID)
+		customerID = &id
+	}
+
+	createdAt := order.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	status := order.Status
+	if status == "" {
+		status = domain.OrderPlaced
+	}
 
 	var couponCode *string
 	if order.CouponCode != nil && *order.CouponCode != "" {
 		couponCode = order.CouponCode
 	}
 
-	if _, err := tx.ExecContext(ctx, insertOrder, string(order.ID), couponCode); err != nil {
+	var tableNumber *int
+	if order.Fulfilment.TableNumber > 0 {
+		tableNumber = &order.Fulfilment.TableNumber
+	}
+
+	var line1, line2, suburb, state, postcode *string
+	if addr := order.Fulfilment.Address; addr != nil {
+		line1, suburb, state, postcode = &addr.Line1, &addr.Suburb, &addr.State, &addr.Postcode
+		if addr.Line2 != "" {
+			line2 = &addr.Line2
+		}
+	}
+
+	if _, err := tx.StmtContext(ctx, insertOrder).ExecContext(ctx,
+		string(order.ID), string(order.StoreID), customerID, couponCode,
+		string(order.Fulfilment.Type), tableNumber,
+		line1, line2, suburb, state, postcode,
+		int64(order.Pricing.Subtotal), int64(order.Pricing.DeliveryFee), int64(order.Pricing.Total),
+		string(status), createdAt,
+	); err != nil {
 		return fmt.Errorf("insert order: %w", err)
 	}
 
-	const insertItem = `
-		INSERT INTO order_items(order_id, product_id, quantity)
-		VALUES ($1, $2, $3)
+	if couponCode != nil {
+		if err := redeemCoupon(ctx, tx, order.ID, *couponCode, customerID, createdAt); err != nil {
+			return err
+		}
+	}
+
+	productIDs := make([]string, len(order.Items))
+	quantities := make([]int64, len(order.Items))
+	for i, item := range order.Items {
+		productIDs[i] = string(item.ProductID)
+		quantities[i] = int64(item.Quantity)
+	}
+	if _, err := tx.StmtContext(ctx, insertItems).ExecContext(ctx,
+		string(order.ID), pq.Array(productIDs), pq.Array(quantities),
+	); err != nil {
+		return fmt.Errorf("insert order items (order_id=%s, items=%d): %w", order.ID, len(order.Items), err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx for save order: %w", err)
+	}
+
+	return nil
+}
+
+// redeemCoupon records the order's use of code, enforcing the code's caps
+// from promo_code_limits. The limits row is locked for the rest of the
+// transaction, so concurrent orders for a capped code are counted one at a
+// time.
+func redeemCoupon(ctx context.Context, tx *sql.Tx, orderID domain.OrderID, code string, customerID *string, at time.Time) error {
+	const selectLimits = `
+		SELECT max_redemptions, max_per_customer
+		FROM promo_code_limits
+		WHERE code = $1
+		FOR UPDATE
 	`
+	var maxRedemptions, maxPerCustomer sql.NullInt64
+	err := tx.QueryRowContext(ctx, selectLimits, code).Scan(&maxRedemptions, &maxPerCustomer)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("select promo code limits: %w", err)
+	}
 
-	for _, item := range order.Items {
-		if _, err := tx.ExecContext(ctx, insertItem, string(order.ID), string(item.ProductID), item.Quantity); err != nil {
-			return fmt.Errorf("insert order item (order_id=%s, product_id=%s): %w", order.ID, item.ProductID, err)
+	if maxRedemptions.Valid || maxPerCustomer.Valid {
+		if maxPerCustomer.Valid && customerID == nil {
+			return domain.ErrCouponCustomerRequired
+		}
+
+		const countRedemptions = `
+			SELECT
+				count(*),
+				count(*) FILTER (WHERE customer_id = $2)
+			FROM promo_redemptions
+			WHERE code = $1 AND released_at IS NULL
+		`
+		var total, forCustomer int64
+		if err := tx.QueryRowContext(ctx, countRedemptions, code, customerID).Scan(&total, &forCustomer); err != nil {
+			return fmt.Errorf("count promo redemptions: %w", err)
+		}
+
+		if maxRedemptions.Valid && total >= maxRedemptions.Int64 {
+			return domain.ErrCouponExhausted
+		}
+		if maxPerCustomer.Valid && forCustomer >= maxPerCustomer.Int64 {
+			return domain.ErrCouponCustomerLimit
 		}
 	}
 
+	const insertRedemption = `
+		INSERT INTO promo_redemptions (order_id, code, customer_id, redeemed_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.ExecContext(ctx, insertRedemption, string(orderID), code, customerID, at); err != nil {
+		return fmt.Errorf("insert promo redemption: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PgOrderRepository) Cancel(ctx context.Context, id domain.OrderID, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx for cancel order: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	const selectStatus = `
+		SELECT status
+		FROM orders
+		WHERE id = $1
+		FOR UPDATE
+	`
+	var status string
+	err = tx.QueryRowContext(ctx, selectStatus, string(id)).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrOrderNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("select order %s status: %w", id, err)
+	}
+	if domain.OrderStatus(status) == domain.OrderCancelled {
+		return domain.ErrOrderAlreadyCancelled
+	}
+
+	const updateOrder = `
+		UPDATE orders
+		SET status = $2, cancelled_at = $3
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, updateOrder, string(id), string(domain.OrderCancelled), at); err != nil {
+		return fmt.Errorf("cancel order %s: %w", id, err)
+	}
+
+	const releaseRedemption = `
+		UPDATE promo_redemptions
+		SET released_at = $2
+		WHERE order_id = $1 AND released_at IS NULL
+	`
+	if _, err := tx.ExecContext(ctx, releaseRedemption, string(id), at); err != nil {
+		return fmt.Errorf("release promo redemption for order %s: %w", id, err)
+	}
+
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit tx for save order: %w", err)
+		return fmt.Errorf("commit tx for cancel order: %w", err)
 	}
 
 	return nil
 }
+
+func (r *PgOrderRepository) ListByCustomer(ctx context.Context, customerID domain.CustomerID) ([]domain.Order, error) {
+	const ordersQuery = `
+		SELECT
+			id, store_id, coupon_code,
+			fulfilment_type, table_number,
+			delivery_line1, delivery_line2, delivery_suburb, delivery_state, delivery_postcode,
+			subtotal_cents, delivery_fee_cents, total_cents, status, created_at, cancelled_at
+		FROM orders
+		WHERE customer_id = $1
+		ORDER BY created_at DESC, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, ordersQuery, string(customerID))
+	if err != nil {
+		return nil, fmt.Errorf("list orders for customer %s: %w", customerID, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var (
+		orders   []domain.Order
+		orderIDs []string
+	)
+	for rows.Next() {
+		var (
+			id, storeID, fulfilmentType, status         string
+			couponCode                                  sql.NullString
+			tableNumber                                 sql.NullInt64
+			line1, line2, suburb, state, postcode       sql.NullString
+			subtotalCents, deliveryFeeCents, totalCents int64
+			createdAt                                   time.Time
+			cancelledAt                                 sql.NullTime
+		)
+
+		if err := rows.Scan(
+			&id, &storeID, &couponCode,
+			&fulfilmentType, &tableNumber,
+			&line1, &line2, &suburb, &state, &postcode,
+			&subtotalCents, &deliveryFeeCents, &totalCents, &status, &createdAt, &cancelledAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan order row: %w", err)
+		}
+
+		cid := customerID
+		order := domain.Order{
+			ID:         domain.OrderID(id),
+			StoreID:    domain.StoreID(storeID),
+			CustomerID: &cid,
+			Fulfilment: domain.Fulfilment{
+				Type:        domain.FulfilmentType(fulfilmentType),
+				TableNumber: int(tableNumber.Int64),
+			},
+			Pricing: domain.OrderPricing{
+				Subtotal:    domain.Money(subtotalCents),
+				DeliveryFee: domain.Money(deliveryFeeCents),
+				Total:       domain.Money(totalCents),
+			},
+			Status:    domain.OrderStatus(status),
+			CreatedAt: createdAt,
+		}
+		if cancelledAt.Valid {
+			at := cancelledAt.Time
+			order.CancelledAt = &at
+		}
+		if couponCode.Valid {
+			code := couponCode.String
+			order.CouponCode = &code
+		}
+		if line1.Valid {
+			order.Fulfilment.Address = &domain.DeliveryAddress{
+				Line1:    line1.String,
+				Line2:    line2.String,
+				Suburb:   suburb.String,
+				State:    state.String,
+				Postcode: postcode.String,
+			}
+		}
+
+		orders = append(orders, order)
+		orderIDs = append(orderIDs, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate order rows: %w", err)
+	}
+
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	items, err := r.listItems(ctx, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		orders[i].Items = items[orders[i].ID]
+	}
+
+	return orders, nil
+}
+
+// listItems loads the items of the given orders, keyed by order ID.
+func (r *PgOrderRepository) listItems(ctx context.Context, orderIDs []string) (map[domain.OrderID][]domain.OrderItem, error) {
+	const query = `
+		SELECT order_id, product_id, quantity
+		FROM order_items
+		WHERE order_id = ANY($1)
+		ORDER BY order_id, product_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(orderIDs))
+	if err != nil {
+		return nil, fmt.Errorf("list order items: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	items := make(map[domain.OrderID][]domain.OrderItem, len(orderIDs))
+	for rows.Next() {
+		var (
+			orderID   string
+			productID string
+			quantity  int
+		)
+
+		if err := rows.Scan(&orderID, &productID, &quantity); err != nil {
+			return nil, fmt.Errorf("scan order item row: %w", err)
+		}
+
+		items[domain.OrderID(orderID)] = append(items[domain.OrderID(orderID)], domain.OrderItem{
+			ProductID: domain.ProductID(productID),
+			Quantity:  quantity,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate order item rows: %w", err)
+	}
+
+	return items, nil
+}
@@ -148,3 +148,102 @@ func (r *PgProductRepository) GetProductByIDs(ctx context.Context, ids []domain.
 
 	return result, nil
 }
+
+func (r *PgProductRepository) ListProductsByStore(ctx context.Context, storeID domain.StoreID) ([]domain.Product, error) {
+	// Products without a store_products row are available at their base price.
+	const query = `
+		SELECT p.id, p.name, COALESCE(sp.price_cents, p.price_cents), p.category
+		FROM products p
+		LEFT JOIN store_products sp
+		       ON sp.product_id = p.id AND sp.store_id = $1
+		WHERE COALESCE(sp.available, TRUE)
+		ORDER BY p.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, string(storeID))
+	if err != nil {
+		return nil, fmt.Errorf("list products for store %s: %w", storeID, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var products []domain.Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product rows: %w", err)
+	}
+
+	return products, nil
+}
+
+func (r *PgProductRepository) GetProductByIDsForStore(ctx context.Context, storeID domain.StoreID, ids []domain.ProductID) (map[domain.ProductID]domain.Product, error) {
+	if len(ids) == 0 {
+		return map[domain.ProductID]domain.Product{}, nil
+	}
+
+	idStrings := make([]string, 0, len(ids))
+	for _, id := range ids {
+		idStrings = append(idStrings, string(id))
+	}
+
+	const query = `
+		SELECT p.id, p.name, COALESCE(sp.price_cents, p.price_cents), p.category
+		FROM products p
+		LEFT JOIN store_products sp
+		       ON sp.product_id = p.id AND sp.store_id = $1
+		WHERE p.id = ANY($2)
+		  AND COALESCE(sp.available, TRUE)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, string(storeID), pq.Array(idStrings))
+	if err != nil {
+		return nil, fmt.Errorf("get products by ids for store %s: %w", storeID, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := make(map[domain.ProductID]domain.Product, len(ids))
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		result[p.ID] = p
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate product rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// scanProduct reads a (id, name, price_cents, category) row.
+func scanProduct(rows *sql.Rows) (domain.Product, error) {
+	var (
+		id         string
+		name       string
+		priceCents int64
+		category   string
+	)
+
+	if err := rows.Scan(&id, &name, &priceCents, &category); err != nil {
+		return domain.Product{}, fmt.Errorf("scan product row: %w", err)
+	}
+
+	return domain.Product{
+		ID:       domain.ProductID(id),
+		Name:     name,
+		Price:    domain.Money(priceCents),
+		Category: category,
+	}, nil
+}
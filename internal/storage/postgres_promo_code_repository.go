@@ -0,0 +1,327 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/lib/pq"
+)
+
+// PgPromoCodeValidator checks codes against the active version of the
+// promo_codes table, so every API instance sees the same set.
+type PgPromoCodeValidator struct {
+	db *sql.DB
+}
+
+func NewPgPromoCodeValidator(db *sql.DB) *PgPromoCodeValidator {
+	return &PgPromoCodeValidator{
+		db: db,
+	}
+}
+
+func (v *PgPromoCodeValidator) IsValid(ctx context.Context, code string) (bool, error) {
+	// Served by the (version, code) primary key of the active partition
+	const query = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM promo_codes c
+			JOIN promo_code_active a ON a.version = c.version
+			WHERE c.code = $1
+		)
+	`
+
+	var ok bool
+	if err := v.db.QueryRowContext(ctx, query, strings.TrimSpace(code)).Scan(&ok); err != nil {
+		return false, fmt.Errorf("lookup promo code: %w", err)
+	}
+	return ok, nil
+}
+
+func (v *PgPromoCodeValidator) CodeSetStatus(ctx context.Context) (*domain.PromoCodeSetStatus, error) {
+	const query = `
+		SELECT v.version, v.source, v.code_count, v.activated_at, clock_timestamp()
+		FROM promo_code_active a
+		JOIN promo_code_versions v ON v.version = a.version
+	`
+
+	var (
+		version     int64
+		activatedAt sql.NullTime
+		st          = domain.PromoCodeSetStatus{Source: "postgres"}
+	)
+	err := v.db.QueryRowContext(ctx, query).Scan(&version, &st.Location, &st.CodeCount, &activatedAt, &st.LastCheckedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNoActivePromoCodes
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select active promo code version: %w", err)
+	}
+	st.Version = strconv.FormatInt(version, 10)
+	st.LoadedAt = activatedAt.Time
+
+	return &st, nil
+}
+
+// PgPromoCodeImporter bulk-loads promo code sets into versioned partitions
+// of promo_codes and switches the active version.
+type PgPromoCodeImporter struct {
+	db *sql.DB
+}
+
+func NewPgPromoCodeImporter(db *sql.DB) *PgPromoCodeImporter {
+	return &PgPromoCodeImporter{
+		db: db,
+	}
+}
+
+// Import loads the codes in r, one per line, as a new inactive version and
+// returns it. Blank lines and repeats of the previous line are skipped, so
+// the sorted promo-loader output can be imported as is; other duplicates
+// fail the import. Input without codes, or with a line that cannot be a
+// code, fails with domain.ErrMalformedPromoCodes. A failed import leaves no
+// trace behind.
+func (i *PgPromoCodeImporter) Import(ctx context.Context, source string, r io.Reader) (*domain.PromoCodeVersion, error) {
+	const insertVersion = `
+		INSERT INTO promo_code_versions (source)
+		VALUES ($1)
+		RETURNING version, imported_at
+	`
+	v := &domain.PromoCodeVersion{Source: source}
+	if err := i.db.QueryRowContext(ctx, insertVersion, source).Scan(&v.Version, &v.ImportedAt); err != nil {
+		return nil, fmt.Errorf("insert promo code version: %w", err)
+	}
+
+	count, err := i.load(ctx, v.Version, r)
+	if err != nil {
+		// Use a fresh context so a cancelled import is still cleaned up
+		if dropErr := i.drop(context.Background(), v.Version); dropErr != nil {
+			err = errors.Join(err, dropErr)
+		}
+		return nil, err
+	}
+	v.CodeCount = count
+
+	return v, nil
+}
+
+// load copies the codes into a standalone table, indexes it and attaches it
+// as the version's partition. Indexing after the COPY is much faster than
+// maintaining the index row by row.
+func (i *PgPromoCodeImporter) load(ctx context.Context, version int64, r io.Reader) (int64, error) {
+	table := promoCodePartition(version)
+
+	createTable := fmt.Sprintf(`
+		CREATE TABLE %[1]s (
+			version BIGINT NOT NULL DEFAULT %[2]d CHECK (version = %[2]d),
+			code    TEXT NOT NULL
+		)
+	`, table, version)
+	if _, err := i.db.ExecContext(ctx, createTable); err != nil {
+		return 0, fmt.Errorf("create promo code partition: %w", err)
+	}
+
+	count, err := i.copyCodes(ctx, table, r)
+	if err != nil {
+		return 0, err
+	}
+
+	addKey := fmt.Sprintf(`ALTER TABLE %s ADD PRIMARY KEY (version, code)`, table)
+	if _, err := i.db.ExecContext(ctx, addKey); err != nil {
+		return 0, fmt.Errorf("index promo code partition: %w", err)
+	}
+
+	// The CHECK constraint lets Postgres attach without rescanning the table
+	attach := fmt.Sprintf(`ALTER TABLE promo_codes ATTACH PARTITION %s FOR VALUES IN (%d)`, table, version)
+	if _, err := i.db.ExecContext(ctx, attach); err != nil {
+		return 0, fmt.Errorf("attach promo code partition: %w", err)
+	}
+
+	const updateCount = `
+		UPDATE promo_code_versions
+		SET code_count = $2
+		WHERE version = $1
+	`
+	if _, err := i.db.ExecContext(ctx, updateCount, version, count); err != nil {
+		return 0, fmt.Errorf("update promo code count: %w", err)
+	}
+
+	return count, nil
+}
+
+func (i *PgPromoCodeImporter) copyCodes(ctx context.Context, table string, r io.Reader) (int64, error) {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx for promo code copy: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, "code"))
+	if err != nil {
+		return 0, fmt.Errorf("prepare promo code copy: %w", err)
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	var (
+		count int64
+		prev  string
+		line  int
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		code := strings.TrimSpace(scanner.Text())
+		if code == "" || code == prev {
+			continue
+		}
+		if !domain.IsPromoCode(code) {
+			return 0, fmt.Errorf("%w: line %d: %q is not a promo code", domain.ErrMalformedPromoCodes, line, code)
+		}
+		prev = code
+
+		if _, err := stmt.ExecContext(ctx, code); err != nil {
+			return 0, fmt.Errorf("copy promo code: %w", err)
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("read promo codes: %w", err)
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("%w: no codes", domain.ErrMalformedPromoCodes)
+	}
+
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, fmt.Errorf("flush promo code copy: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit tx for promo code copy: %w", err)
+	}
+
+	return count, nil
+}
+
+// Activate makes version the set the API validates against. Lookups switch
+// over atomically when the transaction commits. Versions without codes,
+// including imports still in progress, are refused with
+// domain.ErrMalformedPromoCodes, as serving them would reject every coupon.
+func (i *PgPromoCodeImporter) Activate(ctx context.Context, version int64) error {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx for promo code activation: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Lock the version so Prune cannot drop it before the swap commits
+	const selectVersion = `
+		SELECT code_count
+		FROM promo_code_versions
+		WHERE version = $1
+		FOR UPDATE
+	`
+	var count int64
+	err = tx.QueryRowContext(ctx, selectVersion, version).Scan(&count)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("promo code version %d does not exist", version)
+	}
+	if err != nil {
+		return fmt.Errorf("select promo code version %d: %w", version, err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: version %d has no codes", domain.ErrMalformedPromoCodes, version)
+	}
+
+	const upsertActive = `
+		INSERT INTO promo_code_active (id, version)
+		VALUES (TRUE, $1)
+		ON CONFLICT (id) DO UPDATE SET version = EXCLUDED.version
+	`
+	if _, err := tx.ExecContext(ctx, upsertActive, version); err != nil {
+		return fmt.Errorf("activate promo code version %d: %w", version, err)
+	}
+
+	const updateVersion = `
+		UPDATE promo_code_versions
+		SET activated_at = now()
+		WHERE version = $1
+	`
+	if _, err := tx.ExecContext(ctx, updateVersion, version); err != nil {
+		return fmt.Errorf("mark promo code version %d active: %w", version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx for promo code activation: %w", err)
+	}
+	return nil
+}
+
+// Prune keeps the active version plus the newest keep-1 others, drops the
+// rest and returns the dropped version numbers.
+func (i *PgPromoCodeImporter) Prune(ctx context.Context, keep int) ([]int64, error) {
+	const query = `
+		SELECT version
+		FROM promo_code_versions
+		WHERE version NOT IN (SELECT version FROM promo_code_active)
+		ORDER BY version DESC
+		OFFSET $1
+	`
+	rows, err := i.db.QueryContext(ctx, query, max(keep-1, 0))
+	if err != nil {
+		return nil, fmt.Errorf("list old promo code versions: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var versions []int64
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			return nil, fmt.Errorf("scan promo code version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate promo code versions: %w", err)
+	}
+
+	for _, v := range versions {
+		if err := i.drop(ctx, v); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+// drop removes a version with its partition.
+func (i *PgPromoCodeImporter) drop(ctx context.Context, version int64) error {
+	dropTable := fmt.Sprintf(`DROP TABLE IF EXISTS %s`, promoCodePartition(version))
+	if _, err := i.db.ExecContext(ctx, dropTable); err != nil {
+		return fmt.Errorf("drop promo code partition: %w", err)
+	}
+
+	const deleteVersion = `
+		DELETE FROM promo_code_versions
+		WHERE version = $1
+	`
+	if _, err := i.db.ExecContext(ctx, deleteVersion, version); err != nil {
+		return fmt.Errorf("delete promo code version %d: %w", version, err)
+	}
+	return nil
+}
+
+func promoCodePartition(version int64) string {
+	return fmt.Sprintf("promo_codes_v%d", version)
+}
@@ -0,0 +1,295 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+	"github.com/M-Arthur/order-food-api/internal/storage"
+)
+
+// testPromoSource is the source of the versions the tests import, by which
+// they are cleaned up.
+const testPromoSource = "test-promo-codes.txt"
+
+// openPromoTestDB is openTestDB that also puts the active promo code version
+// back and drops the versions the test imported once it is done.
+func openPromoTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db := openTestDB(t)
+
+	var active sql.NullInt64
+	err := db.QueryRow(`SELECT version FROM promo_code_active`).Scan(&active)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("select active promo code version: %v", err)
+	}
+
+	t.Cleanup(func() {
+		var err error
+		if active.Valid {
+			_, err = db.Exec(`UPDATE promo_code_active SET version = $1`, active.Int64)
+		} else {
+			_, err = db.Exec(`DELETE FROM promo_code_active`)
+		}
+		if err != nil {
+			t.Errorf("cleanup: restore active promo code version: %v", err)
+		}
+		for _, v := range testPromoVersions(t, db) {
+			if _, err := db.Exec(`DROP TABLE IF EXISTS promo_codes_v` + strconv.FormatInt(v, 10)); err != nil {
+				t.Errorf("cleanup: %v", err)
+			}
+		}
+		if _, err := db.Exec(`DELETE FROM promo_code_versions WHERE source = $1`, testPromoSource); err != nil {
+			t.Errorf("cleanup: %v", err)
+		}
+	})
+	return db
+}
+
+// testPromoVersions lists the versions imported by the tests, oldest first.
+func testPromoVersions(t *testing.T, db *sql.DB) []int64 {
+	t.Helper()
+	rows, err := db.Query(`SELECT version FROM promo_code_versions WHERE source = $1 ORDER BY version`, testPromoSource)
+	if err != nil {
+		t.Fatalf("list promo code versions: %v", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var versions []int64
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			t.Fatalf("scan promo code version: %v", err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("list promo code versions: %v", err)
+	}
+	return versions
+}
+
+// promoPartitions counts the partitions attached to promo_codes.
+func promoPartitions(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(`SELECT count(*) FROM pg_inherits WHERE inhparent = 'promo_codes'::regclass`).Scan(&n); err != nil {
+		t.Fatalf("count promo code partitions: %v", err)
+	}
+	return n
+}
+
+func importCodes(t *testing.T, importer *storage.PgPromoCodeImporter, codes ...string) int64 {
+	t.Helper()
+	v, err := importer.Import(context.Background(), testPromoSource, strings.NewReader(strings.Join(codes, "\n")+"\n"))
+	if err != nil {
+		t.Fatalf("Import(%q) error = %v", codes, err)
+	}
+	return v.Version
+}
+
+func TestPgPromoCodeImporter_ImportDuplicates(t *testing.T) {
+	db := openPromoTestDB(t)
+	importer := storage.NewPgPromoCodeImporter(db)
+	ctx := context.Background()
+
+	// Blank lines and repeats of the previous line, as in sorted loader
+	// output, are skipped
+	v, err := importer.Import(ctx, testPromoSource, strings.NewReader("TESTCODE1\nTESTCODE1\n\n  TESTCODE2 \nTESTCODE2\n"))
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if v.CodeCount != 2 {
+		t.Fatalf("Import() CodeCount = %d, want 2", v.CodeCount)
+	}
+	var stored int64
+	if err := db.QueryRow(`SELECT count(*) FROM promo_codes WHERE version = $1`, v.Version).Scan(&stored); err != nil {
+		t.Fatalf("count promo codes: %v", err)
+	}
+	if stored != 2 {
+		t.Fatalf("stored %d codes, want 2", stored)
+	}
+
+	// Any other duplicate fails the import
+	if _, err := importer.Import(ctx, testPromoSource, strings.NewReader("TESTCODE1\nTESTCODE2\nTESTCODE1\n")); err == nil {
+		t.Fatalf("Import() of unsorted duplicates error = nil, want error")
+	}
+}
+
+func TestPgPromoCodeImporter_FailedImportLeavesNoTrace(t *testing.T) {
+	db := openPromoTestDB(t)
+	importer := storage.NewPgPromoCodeImporter(db)
+
+	partitions := promoPartitions(t, db)
+	versions := testPromoVersions(t, db)
+
+	if _, err := importer.Import(context.Background(), testPromoSource, strings.NewReader("TESTCODE1\nTESTCODE2\nTESTCODE1\n")); err == nil {
+		t.Fatalf("Import() of duplicates error = nil, want error")
+	}
+
+	if got := promoPartitions(t, db); got != partitions {
+		t.Errorf("promo_codes has %d partitions after a failed import, want %d", got, partitions)
+	}
+	if got := testPromoVersions(t, db); !slices.Equal(got, versions) {
+		t.Errorf("versions after a failed import = %v, want %v", got, versions)
+	}
+	var tables int
+	const query = `SELECT count(*) FROM pg_tables WHERE tablename LIKE 'promo\_codes\_v%' AND tablename NOT IN (
+		SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid WHERE i.inhparent = 'promo_codes'::regclass
+	)`
+	if err := db.QueryRow(query).Scan(&tables); err != nil {
+		t.Fatalf("count detached promo code tables: %v", err)
+	}
+	if tables != 0 {
+		t.Errorf("%d detached promo code tables left behind", tables)
+	}
+}
+
+func TestPgPromoCodeImporter_ActivateSwapsServedSet(t *testing.T) {
+	db := openPromoTestDB(t)
+	importer := storage.NewPgPromoCodeImporter(db)
+	validator := storage.NewPgPromoCodeValidator(db)
+	ctx := context.Background()
+
+	valid := func(code string) bool {
+		t.Helper()
+		ok, err := validator.IsValid(ctx, code)
+		if err != nil {
+			t.Fatalf("IsValid(%s) error = %v", code, err)
+		}
+		return ok
+	}
+
+	first := importCodes(t, importer, "TESTOLD01")
+	if err := importer.Activate(ctx, first); err != nil {
+		t.Fatalf("Activate(%d) error = %v", first, err)
+	}
+	if !valid("TESTOLD01") {
+		t.Fatalf("code of the active version is not valid")
+	}
+
+	// An imported version is not served until it is activated
+	second := importCodes(t, importer, "TESTNEW01")
+	if valid("TESTNEW01") || !valid("TESTOLD01") {
+		t.Fatalf("inactive version served before activation")
+	}
+
+	if err := importer.Activate(ctx, second); err != nil {
+		t.Fatalf("Activate(%d) error = %v", second, err)
+	}
+	if !valid("TESTNEW01") || valid("TESTOLD01") {
+		t.Fatalf("after activation, new code valid = %v, old code valid = %v, want true, false", valid("TESTNEW01"), valid("TESTOLD01"))
+	}
+	st, err := validator.CodeSetStatus(ctx)
+	if err != nil {
+		t.Fatalf("CodeSetStatus() error = %v", err)
+	}
+	if st.Version != strconv.FormatInt(second, 10) || st.CodeCount != 1 || st.LoadedAt.IsZero() {
+		t.Fatalf("CodeSetStatus() = %+v, want version %d with 1 code", st, second)
+	}
+}
+
+func TestPgPromoCodeImporter_PruneKeepsActive(t *testing.T) {
+	db := openPromoTestDB(t)
+	// Prune drops the oldest versions first, whoever imported them
+	var others int
+	if err := db.QueryRow(`SELECT count(*) FROM promo_code_versions WHERE source <> $1`, testPromoSource).Scan(&others); err != nil {
+		t.Fatalf("count promo code versions: %v", err)
+	}
+	if others > 0 {
+		t.Skipf("database has %d promo code versions of its own, which Prune would drop", others)
+	}
+
+	importer := storage.NewPgPromoCodeImporter(db)
+	ctx := context.Background()
+	oldest := importCodes(t, importer, "TESTPRUNE1")
+	middle := importCodes(t, importer, "TESTPRUNE2")
+	newest := importCodes(t, importer, "TESTPRUNE3")
+
+	// The active version is kept even though it is the oldest
+	if err := importer.Activate(ctx, oldest); err != nil {
+		t.Fatalf("Activate(%d) error = %v", oldest, err)
+	}
+	dropped, err := importer.Prune(ctx, 2)
+	if err != nil {
+		t.Fatalf("Prune(2) error = %v", err)
+	}
+	if !slices.Equal(dropped, []int64{middle}) {
+		t.Fatalf("Prune(2) dropped %v, want [%d]", dropped, middle)
+	}
+	if got := testPromoVersions(t, db); !slices.Equal(got, []int64{oldest, newest}) {
+		t.Fatalf("versions after Prune(2) = %v, want [%d %d]", got, oldest, newest)
+	}
+
+	dropped, err = importer.Prune(ctx, 1)
+	if err != nil {
+		t.Fatalf("Prune(1) error = %v", err)
+	}
+	if !slices.Equal(dropped, []int64{newest}) {
+		t.Fatalf("Prune(1) dropped %v, want [%d]", dropped, newest)
+	}
+	if ok, err := storage.NewPgPromoCodeValidator(db).IsValid(ctx, "TESTPRUNE1"); !ok || err != nil {
+		t.Fatalf("IsValid() of the active version after Prune = %v, %v, want true, nil", ok, err)
+	}
+}
+
+func TestPgPromoCodeImporter_ImportMalformed(t *testing.T) {
+	db := openPromoTestDB(t)
+	importer := storage.NewPgPromoCodeImporter(db)
+	versions := testPromoVersions(t, db)
+
+	for name, input := range map[string]string{
+		"empty":        "",
+		"only blanks":  "\n \n",
+		"inner spaces": "TESTCODE1\nTEST CODE2\n",
+		"control char": "TESTCODE1\nTEST\x01CODE2\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := importer.Import(context.Background(), testPromoSource, strings.NewReader(input))
+			if !errors.Is(err, domain.ErrMalformedPromoCodes) {
+				t.Fatalf("Import() error = %v, want %v", err, domain.ErrMalformedPromoCodes)
+			}
+			if got := testPromoVersions(t, db); !slices.Equal(got, versions) {
+				t.Fatalf("versions after a malformed import = %v, want %v", got, versions)
+			}
+		})
+	}
+}
+
+func TestPgPromoCodeImporter_ActivateRefusesEmptyVersion(t *testing.T) {
+	db := openPromoTestDB(t)
+	importer := storage.NewPgPromoCodeImporter(db)
+	ctx := context.Background()
+
+	active := importCodes(t, importer, "TESTKEEP01")
+	if err := importer.Activate(ctx, active); err != nil {
+		t.Fatalf("Activate(%d) error = %v", active, err)
+	}
+
+	// A version row without codes, as left while an import is in progress
+	var empty int64
+	if err := db.QueryRow(`INSERT INTO promo_code_versions (source) VALUES ($1) RETURNING version`, testPromoSource).Scan(&empty); err != nil {
+		t.Fatalf("insert promo code version: %v", err)
+	}
+	if err := importer.Activate(ctx, empty); !errors.Is(err, domain.ErrMalformedPromoCodes) {
+		t.Fatalf("Activate(%d) error = %v, want %v", empty, err, domain.ErrMalformedPromoCodes)
+	}
+	if err := importer.Activate(ctx, empty+1000); err == nil {
+		t.Fatalf("Activate() of a missing version error = nil, want error")
+	}
+
+	st, err := storage.NewPgPromoCodeValidator(db).CodeSetStatus(ctx)
+	if err != nil {
+		t.Fatalf("CodeSetStatus() error = %v", err)
+	}
+	if st.Version != strconv.FormatInt(active, 10) {
+		t.Fatalf("active version = %s, want %d", st.Version, active)
+	}
+}
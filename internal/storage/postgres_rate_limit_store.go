@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/ratelimit"
+)
+
+// rateLimitPruneEvery is how many takes pass between deletions of idle buckets.
+const rateLimitPruneEvery = 1000
+
+// PgRateLimitStore keeps token buckets in Postgres so every API instance
+// shares the same limits. Bucket time comes from the database clock.
+type PgRateLimitStore struct {
+	db    *sql.DB
+	takes atomic.Uint64
+}
+
+func NewPgRateLimitStore(db *sql.DB) ratelimit.Store {
+	return &PgRateLimitStore{
+		db: db,
+	}
+}
+
+func (s *PgRateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	if s.takes.Add(1)%rateLimitPruneEvery == 0 {
+		if err := s.pruneIdle(ctx); err != nil {
+			return ratelimit.Result{}, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("begin tx for rate limit: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Make sure the bucket exists, then lock it for the read-modify-write.
+	const insertBucket = `
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+		VALUES ($1, $2, clock_timestamp())
+		ON CONFLICT (key) DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, insertBucket, key, float64(limit.Burst)); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("insert rate limit bucket: %w", err)
+	}
+
+	const selectBucket = `
+		SELECT tokens, updated_at, clock_timestamp()
+		FROM rate_limit_buckets
+		WHERE key = $1
+		FOR UPDATE
+	`
+	var (
+		tokens    float64
+		updatedAt time.Time
+		now       time.Time
+	)
+	if err := tx.QueryRowContext(ctx, selectBucket, key).Scan(&tokens, &updatedAt, &now); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("select rate limit bucket: %w", err)
+	}
+
+	tokens, res := ratelimit.Take(tokens, updatedAt, now, limit)
+
+	const updateBucket = `
+		UPDATE rate_limit_buckets
+		SET tokens = $2, updated_at = $3
+		WHERE key = $1
+	`
+	if _, err := tx.ExecContext(ctx, updateBucket, key, tokens, now); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("update rate limit bucket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("commit tx for rate limit: %w", err)
+	}
+
+	return res, nil
+}
+
+// pruneIdle deletes buckets untouched for an hour; every limit we configure
+// refills well within that, so a missing bucket behaves the same.
+func (s *PgRateLimitStore) pruneIdle(ctx context.Context) error {
+	const query = `
+		DELETE FROM rate_limit_buckets
+		WHERE updated_at < clock_timestamp() - INTERVAL '1 hour'
+	`
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("prune rate limit buckets: %w", err)
+	}
+	return nil
+}
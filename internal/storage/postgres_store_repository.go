@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/M-Arthur/order-food-api/internal/domain"
+)
+
+type PgStoreRepository struct {
+	db *sql.DB
+}
+
+func NewPgStoreRepository(db *sql.DB) domain.StoreRepository {
+	return &PgStoreRepository{
+		db: db,
+	}
+}
+
+func (r *PgStoreRepository) GetStore(ctx context.Context, id domain.StoreID) (*domain.Store, error) {
+	const query = `
+		SELECT id, name
+		FROM stores
+		WHERE id = $1
+	`
+
+	var (
+		rawID string
+		name  string
+	)
+
+	err := r.db.QueryRowContext(ctx, query, string(id)).Scan(&rawID, &name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrStoreNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get store by id %s: %w", id, err)
+	}
+
+	return &domain.Store{ID: domain.StoreID(rawID), Name: name}, nil
+}
+
+func (r *PgStoreRepository) GetSchedule(ctx context.Context, id domain.StoreID) (*domain.StoreSchedule, error) {
+	const settingsQuery = `
+		SELECT timezone, ordering_paused, COALESCE(pause_reason, '')
+		FROM store_settings
+		WHERE store_id = $1
+	`
+
+	var (
+		timezone string
+		schedule domain.StoreSchedule
+	)
+
+	err := r.db.QueryRowContext(ctx, settingsQuery, string(id)).
+		Scan(&timezone, &schedule.OrderingPaused, &schedule.PauseReason)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrStoreScheduleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get store settings for %s: %w", id, err)
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("load store timezone %q: %w", timezone, err)
+	}
+	schedule.Location = loc
+
+	if schedule.OpeningHours, err = r.listOpeningHours(ctx, id); err != nil {
+		return nil, err
+	}
+	if schedule.Closures, err = r.listClosures(ctx, id); err != nil {
+		return nil, err
+	}
+	if schedule.Blackouts, err = r.listBlackouts(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+func (r *PgStoreRepository) SetOrderingPaused(ctx context.Context, id domain.StoreID, paused bool, reason string) error {
+	const query = `
+		UPDATE store_settings
+		SET ordering_paused = $2, pause_reason = NULLIF($3, '')
+		WHERE store_id = $1
+	`
+
+	res, err := r.db.ExecContext(ctx, query, string(id), paused, reason)
+	if err != nil {
+		return fmt.Errorf("set ordering paused: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("set ordering paused rows affected: %w", err)
+	}
+	if n == 0 {
+		return domain.ErrStoreScheduleNotFound
+	}
+
+	return nil
+}
+
+func (r *PgStoreRepository) listOpeningHours(ctx context.Context, id domain.StoreID) ([]domain.OpeningPeriod, error) {
+	// TIME columns are returned as seconds since midnight so closes_at = '24:00' survives the trip.
+	const query = `
+		SELECT weekday,
+		       EXTRACT(EPOCH FROM opens_at)::INT,
+		       EXTRACT(EPOCH FROM closes_at)::INT
+		FROM store_opening_hours
+		WHERE store_id = $1
+		ORDER BY weekday, opens_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, string(id))
+	if err != nil {
+		return nil, fmt.Errorf("list opening hours: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var periods []domain.OpeningPeriod
+	for rows.Next() {
+		var weekday, opens, closes int
+		if err := rows.Scan(&weekday, &opens, &closes); err != nil {
+			return nil, fmt.Errorf("scan opening hours row: %w", err)
+		}
+
+		periods = append(periods, domain.OpeningPeriod{
+			Weekday: time.Weekday(weekday),
+			Opens:   time.Duration(opens) * time.Second,
+			Closes:  time.Duration(closes) * time.Second,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate opening hours rows: %w", err)
+	}
+
+	return periods, nil
+}
+
+func (r *PgStoreRepository) listClosures(ctx context.Context, id domain.StoreID) ([]domain.Closure, error) {
+	const query = `
+		SELECT to_char(closed_on, 'YYYY-MM-DD'), COALESCE(reason, '')
+		FROM store_closures
+		WHERE store_id = $1
+		  AND closed_on >= CURRENT_DATE - 1
+		ORDER BY closed_on
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, string(id))
+	if err != nil {
+		return nil, fmt.Errorf("list store closures: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var closures []domain.Closure
+	for rows.Next() {
+		var c domain.Closure
+		if err := rows.Scan(&c.Date, &c.Reason); err != nil {
+			return nil, fmt.Errorf("scan store closure row: %w", err)
+		}
+		closures = append(closures, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate store closure rows: %w", err)
+	}
+
+	return closures, nil
+}
+
+func (r *PgStoreRepository) listBlackouts(ctx context.Context, id domain.StoreID) ([]domain.Blackout, error) {
+	const query = `
+		SELECT starts_at, ends_at, COALESCE(reason, '')
+		FROM store_blackouts
+		WHERE store_id = $1
+		  AND ends_at > now()
+		ORDER BY starts_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, string(id))
+	if err != nil {
+		return nil, fmt.Errorf("list store blackouts: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var blackouts []domain.Blackout
+	for rows.Next() {
+		var b domain.Blackout
+		if err := rows.Scan(&b.Start, &b.End, &b.Reason); err != nil {
+			return nil, fmt.Errorf("scan store blackout row: %w", err)
+		}
+		blackouts = append(blackouts, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate store blackout rows: %w", err)
+	}
+
+	return blackouts, nil
+}